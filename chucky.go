@@ -21,6 +21,7 @@ package chuckysdk
 
 import (
 	"github.com/chucky-cloud/chucky-sdk-go/pkg/chucky"
+	"github.com/chucky-cloud/chucky-sdk-go/pkg/config"
 	"github.com/chucky-cloud/chucky-sdk-go/pkg/tools"
 	"github.com/chucky-cloud/chucky-sdk-go/pkg/types"
 	"github.com/chucky-cloud/chucky-sdk-go/pkg/utils"
@@ -33,6 +34,45 @@ type (
 	ClientEventHandlers = chucky.ClientEventHandlers
 	SessionEventHandlers = chucky.SessionEventHandlers
 	SessionState        = chucky.SessionState
+
+	// Cost tracking
+	CostLedger   = chucky.CostLedger
+	CostEntry    = chucky.CostEntry
+	CostFilter   = chucky.CostFilter
+	CostSummary  = chucky.CostSummary
+	CostGroupBy  = chucky.CostGroupBy
+	BudgetGuard  = chucky.BudgetGuard
+	BudgetLimits = chucky.BudgetLimits
+
+	// Batch prompting
+	PromptRequest   = chucky.PromptRequest
+	BatchOptions    = chucky.BatchOptions
+	BatchItemResult = chucky.BatchItemResult
+	BatchProgress   = chucky.BatchProgress
+	BatchResult     = chucky.BatchResult
+
+	// Session pooling
+	SessionPool        = chucky.SessionPool
+	SessionPoolOptions = chucky.SessionPoolOptions
+	PoolMetrics        = chucky.PoolMetrics
+
+	// Subscriptions
+	MessageFilter    = chucky.MessageFilter
+	SubscribeOptions = chucky.SubscribeOptions
+
+	// Subagent trees
+	AgentTree   = chucky.AgentTree
+	AgentNode   = chucky.AgentNode
+	AgentUpdate = chucky.AgentUpdate
+	AgentStatus = chucky.AgentStatus
+
+	// Sandbox files
+	TransferOptions = chucky.TransferOptions
+
+	// Configuration
+	Config            = config.Config
+	ConfigProfile     = config.Profile
+	LoadConfigOptions = config.LoadOptions
 )
 
 // Re-export session states
@@ -44,11 +84,66 @@ const (
 	SessionStateWaitingTool = chucky.SessionStateWaitingTool
 	SessionStateCompleted   = chucky.SessionStateCompleted
 	SessionStateError       = chucky.SessionStateError
+	SessionStateReconnecting = chucky.SessionStateReconnecting
+	SessionStateClosed      = chucky.SessionStateClosed
 )
 
+// Re-export subscriber overflow policies
+const (
+	OverflowBlock      = types.OverflowBlock
+	OverflowDropOldest = types.OverflowDropOldest
+	OverflowError      = types.OverflowError
+)
+
+// Re-export subagent statuses
+const (
+	AgentStatusRunning     = chucky.AgentStatusRunning
+	AgentStatusCompleted   = chucky.AgentStatusCompleted
+	AgentStatusFailed      = chucky.AgentStatusFailed
+	AgentStatusInterrupted = chucky.AgentStatusInterrupted
+)
+
+// Re-export file transfer directions
+const (
+	TransferUpload   = types.TransferUpload
+	TransferDownload = types.TransferDownload
+)
+
+// Re-export cost groupings
+const (
+	CostGroupBySession = chucky.CostGroupBySession
+	CostGroupByUser    = chucky.CostGroupByUser
+	CostGroupByModel   = chucky.CostGroupByModel
+)
+
+// DefaultLedgerRetention is the number of entries a new CostLedger retains.
+const DefaultLedgerRetention = chucky.DefaultLedgerRetention
+
 // NewClient creates a new Chucky client.
 var NewClient = chucky.NewClient
 
+// DefaultRetryPolicy returns a retry policy with exponential backoff.
+var DefaultRetryPolicy = types.DefaultRetryPolicy
+
+// NewCostLedger creates an empty cost ledger.
+var NewCostLedger = chucky.NewCostLedger
+
+// NewBudgetGuard creates a local budget guard.
+var NewBudgetGuard = chucky.NewBudgetGuard
+
+// NewAgentTree creates an empty subagent tree.
+var NewAgentTree = chucky.NewAgentTree
+
+// LoadConfig loads client and session options from CHUCKY_* environment
+// variables and the default config file.
+var LoadConfig = config.Load
+
+// LoadConfigWith loads the configuration from an explicit file or profile.
+var LoadConfigWith = config.LoadWith
+
+// CanTransition reports whether a session may move between two states.
+var CanTransition = chucky.CanTransition
+
 // Re-export type definitions
 type (
 	// Options
@@ -58,6 +153,23 @@ type (
 	Model          = types.Model
 	PermissionMode = types.PermissionMode
 	OutputFormat   = types.OutputFormat
+	RetryPolicy    = types.RetryPolicy
+	OverflowPolicy = types.OverflowPolicy
+	SystemPromptPreset = types.SystemPromptPreset
+
+	// Permissions
+	CanUseToolFunc     = types.CanUseToolFunc
+	PermissionDecision = types.PermissionDecision
+	PermissionBehavior = types.PermissionBehavior
+	PermissionRules    = types.PermissionRules
+
+	// Hooks
+	HookEvent    = types.HookEvent
+	HookDecision = types.HookDecision
+	HookInput    = types.HookInput
+	HookOutput   = types.HookOutput
+	HookCallback = types.HookCallback
+	HookMatcher  = types.HookMatcher
 
 	// Messages
 	IncomingMessage            = types.IncomingMessage
@@ -72,12 +184,30 @@ type (
 	ToolCallEnvelope           = types.ToolCallEnvelope
 	Message                    = types.Message
 	ContentBlock               = types.ContentBlock
+	ContentBlockType           = types.ContentBlockType
+	TextBlock                  = types.TextBlock
+	ThinkingBlock              = types.ThinkingBlock
+	RedactedThinkingBlock      = types.RedactedThinkingBlock
+	ToolUseBlock               = types.ToolUseBlock
+	ToolResultBlock            = types.ToolResultBlock
+	ImageBlock                 = types.ImageBlock
+	ImageSource                = types.ImageSource
+	DocumentBlock              = types.DocumentBlock
+	DocumentSource             = types.DocumentSource
+	RawBlock                   = types.RawBlock
+	CompactMetadata            = types.CompactMetadata
+	SystemInitData             = types.SystemInitData
+	McpServerStatus            = types.McpServerStatus
+	McpConnectionStatus        = types.McpConnectionStatus
+	CompactTrigger             = types.CompactTrigger
 	Usage                      = types.Usage
 
 	// Tools
 	ToolDefinition       = types.ToolDefinition
 	ToolResult           = types.ToolResult
 	ToolHandler          = types.ToolHandler
+	ToolMiddleware       = types.ToolMiddleware
+	ToolCallInfo         = types.ToolCallInfo
 	ToolInputSchema      = types.ToolInputSchema
 	JsonSchemaProperty   = types.JsonSchemaProperty
 	TextToolContent      = types.TextToolContent
@@ -89,6 +219,7 @@ type (
 	McpStdioServerConfig = types.McpStdioServerConfig
 	McpSSEServerConfig   = types.McpSSEServerConfig
 	McpHTTPServerConfig  = types.McpHTTPServerConfig
+	AgentDefinition      = types.AgentDefinition
 
 	// Results
 	SessionResult = types.SessionResult
@@ -104,9 +235,15 @@ type (
 	DecodedToken       = types.DecodedToken
 	BudgetWindow       = types.BudgetWindow
 
+	// Sandbox files
+	FileInfo          = types.FileInfo
+	TransferProgress  = types.TransferProgress
+	TransferDirection = types.TransferDirection
+
 	// Errors
 	ChuckyError = types.ChuckyError
 	ErrorCode   = types.ErrorCode
+	FieldError  = types.FieldError
 )
 
 // Re-export constants
@@ -120,10 +257,52 @@ const (
 	PermissionModePlan              = types.PermissionModePlan
 	PermissionModeBypassPermissions = types.PermissionModeBypassPermissions
 
+	// Permission behaviors
+	PermissionBehaviorAllow = types.PermissionBehaviorAllow
+	PermissionBehaviorDeny  = types.PermissionBehaviorDeny
+
+	// Hook events
+	HookEventPreToolUse       = types.HookEventPreToolUse
+	HookEventPostToolUse      = types.HookEventPostToolUse
+	HookEventUserPromptSubmit = types.HookEventUserPromptSubmit
+	HookEventStop             = types.HookEventStop
+	HookEventPreCompact       = types.HookEventPreCompact
+
+	// Hook decisions
+	HookDecisionContinue = types.HookDecisionContinue
+	HookDecisionBlock    = types.HookDecisionBlock
+
+	// Content block types
+	ContentBlockTypeText             = types.ContentBlockTypeText
+	ContentBlockTypeImage            = types.ContentBlockTypeImage
+	ContentBlockTypeToolUse          = types.ContentBlockTypeToolUse
+	ContentBlockTypeToolResult       = types.ContentBlockTypeToolResult
+	ContentBlockTypeThinking         = types.ContentBlockTypeThinking
+	ContentBlockTypeRedactedThinking = types.ContentBlockTypeRedactedThinking
+	ContentBlockTypeDocument         = types.ContentBlockTypeDocument
+
+	// MCP server connection states
+	McpStatusConnected = types.McpStatusConnected
+	McpStatusFailed    = types.McpStatusFailed
+	McpStatusPending   = types.McpStatusPending
+	McpStatusNeedsAuth = types.McpStatusNeedsAuth
+
+	// Compaction triggers
+	CompactTriggerManual = types.CompactTriggerManual
+	CompactTriggerAuto   = types.CompactTriggerAuto
+
 	// Execute locations
 	ExecuteInServer  = types.ExecuteInServer
 	ExecuteInBrowser = types.ExecuteInBrowser
 
+	// Subagent models
+	AgentModelInherit = types.AgentModelInherit
+
+	// Setting sources
+	SettingSourceUser    = types.SettingSourceUser
+	SettingSourceProject = types.SettingSourceProject
+	SettingSourceLocal   = types.SettingSourceLocal
+
 	// Budget windows
 	BudgetWindowHour  = types.BudgetWindowHour
 	BudgetWindowDay   = types.BudgetWindowDay
@@ -164,6 +343,63 @@ var (
 	SimpleHandler = tools.SimpleHandler
 )
 
+// Permission helpers
+var (
+	// Allow allows a tool use.
+	Allow = types.Allow
+
+	// AllowWithInput allows a tool use with modified input.
+	AllowWithInput = types.AllowWithInput
+
+	// AlwaysAllow allows a tool use and remembers the decision.
+	AlwaysAllow = types.AlwaysAllow
+
+	// Deny denies a tool use with a message.
+	Deny = types.Deny
+
+	// NewPermissionRules creates an "always allow" rule set.
+	NewPermissionRules = types.NewPermissionRules
+)
+
+// Hook helpers
+var (
+	// ContinueHook lets the agent continue.
+	ContinueHook = types.ContinueHook
+
+	// BlockHook blocks the action with a reason.
+	BlockHook = types.BlockHook
+
+	// ContextHook continues and injects additional context.
+	ContextHook = types.ContextHook
+)
+
+// Tool middleware
+var (
+	// UseMiddleware returns a copy of a tool with middleware appended.
+	UseMiddleware = tools.UseMiddleware
+
+	// ChainToolMiddleware wraps a handler with middleware.
+	ChainToolMiddleware = types.ChainToolMiddleware
+
+	// ToolCallInfoFromContext returns the tool call info for a handler.
+	ToolCallInfoFromContext = types.ToolCallInfoFromContext
+
+	// RecoverMiddleware converts handler panics into error results.
+	RecoverMiddleware = tools.Recover
+
+	// LoggingMiddleware logs tool invocations.
+	LoggingMiddleware = tools.Logging
+
+	// RetryMiddleware retries transient tool errors with backoff.
+	RetryMiddleware = tools.Retry
+
+	// RateLimitMiddleware applies a per-tool token-bucket rate limit.
+	RateLimitMiddleware = tools.RateLimit
+
+	// ConcurrencyLimitMiddleware caps concurrent tool executions.
+	ConcurrencyLimitMiddleware = tools.ConcurrencyLimit
+)
+
 // MCP server helpers
 var (
 	// NewMcpServer creates a new MCP server builder.
@@ -178,6 +414,9 @@ var (
 	// StdioServerWithEnv creates an MCP stdio server with env vars.
 	StdioServerWithEnv = tools.StdioServerWithEnv
 
+	// LocalStdioServer creates an MCP stdio server that runs on the client.
+	LocalStdioServer = tools.LocalStdioServer
+
 	// SSEServer creates an MCP SSE server.
 	SSEServer = tools.SSEServer
 
@@ -185,6 +424,18 @@ var (
 	HTTPServer = tools.HTTPServer
 )
 
+// Subagent helpers
+var (
+	// NewAgent creates a new subagent builder.
+	NewAgent = tools.NewAgent
+
+	// Agent creates a subagent restricted to the given tools.
+	Agent = tools.Agent
+
+	// McpToolName returns the qualified name of an MCP tool.
+	McpToolName = types.McpToolName
+)
+
 // Token utilities
 var (
 	// CreateToken creates a new JWT token.
@@ -231,6 +482,9 @@ var (
 
 	// FromResultMessage converts an SDKResultMessage to SessionResult.
 	FromResultMessage = types.FromResultMessage
+
+	// ParseContentBlock decodes a single content block by its type.
+	ParseContentBlock = types.ParseContentBlock
 )
 
 // Error constructors
@@ -245,6 +499,65 @@ var (
 	TimeoutError         = types.TimeoutError
 	ValidationError      = types.ValidationError
 	ProtocolError        = types.ProtocolError
+	HookError            = types.HookError
+	InternalError        = types.InternalError
+)
+
+// Error codes
+const (
+	ErrCodeConnection       = types.ErrCodeConnection
+	ErrCodeAuthentication   = types.ErrCodeAuthentication
+	ErrCodeBudgetExceeded   = types.ErrCodeBudgetExceeded
+	ErrCodeConcurrencyLimit = types.ErrCodeConcurrencyLimit
+	ErrCodeRateLimit        = types.ErrCodeRateLimit
+	ErrCodeSession          = types.ErrCodeSession
+	ErrCodeToolExecution    = types.ErrCodeToolExecution
+	ErrCodeTimeout          = types.ErrCodeTimeout
+	ErrCodeValidation       = types.ErrCodeValidation
+	ErrCodeProtocol         = types.ErrCodeProtocol
+	ErrCodeHook             = types.ErrCodeHook
+	ErrCodeMaxTurns         = types.ErrCodeMaxTurns
+	ErrCodeExecution        = types.ErrCodeExecution
+	ErrCodeOverloaded       = types.ErrCodeOverloaded
+	ErrCodeModelUnavailable = types.ErrCodeModelUnavailable
+	ErrCodeInternal         = types.ErrCodeInternal
+	ErrCodeUnknown          = types.ErrCodeUnknown
+)
+
+// Sentinel errors for use with errors.Is
+var (
+	ErrConnection       = types.ErrConnection
+	ErrAuthentication   = types.ErrAuthentication
+	ErrBudgetExceeded   = types.ErrBudgetExceeded
+	ErrConcurrencyLimit = types.ErrConcurrencyLimit
+	ErrRateLimit        = types.ErrRateLimit
+	ErrSession          = types.ErrSession
+	ErrToolExecution    = types.ErrToolExecution
+	ErrTimeout          = types.ErrTimeout
+	ErrValidation       = types.ErrValidation
+	ErrProtocol         = types.ErrProtocol
+	ErrHook             = types.ErrHook
+	ErrMaxTurns         = types.ErrMaxTurns
+	ErrExecution        = types.ErrExecution
+	ErrOverloaded       = types.ErrOverloaded
+	ErrModelUnavailable = types.ErrModelUnavailable
+	ErrInternal         = types.ErrInternal
+	ErrUnknown          = types.ErrUnknown
+)
+
+// Error helpers
+var (
+	// IsRetryable reports whether an error is transient.
+	IsRetryable = types.IsRetryable
+
+	// RetryAfter returns the server's retry hint for an error.
+	RetryAfter = types.RetryAfter
+
+	// ErrorFromPayload maps a server error message to a ChuckyError.
+	ErrorFromPayload = types.ErrorFromPayload
+
+	// ErrorFromResult maps an error result to a ChuckyError.
+	ErrorFromResult = types.ErrorFromResult
 )
 
 // CreateToolOptions is the options for creating a tool.
@@ -255,3 +568,9 @@ type McpServerBuilder = tools.McpServerBuilder
 
 // SchemaBuilder helps build JSON schemas.
 type SchemaBuilder = tools.SchemaBuilder
+
+// AgentBuilder helps build subagent definitions.
+type AgentBuilder = tools.AgentBuilder
+
+// RetryOptions configures the retry tool middleware.
+type RetryOptions = tools.RetryOptions
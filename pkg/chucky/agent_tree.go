@@ -0,0 +1,334 @@
+package chucky
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"sync"
+	"time"
+
+	"github.com/chucky-cloud/chucky-sdk-go/pkg/types"
+)
+
+// AgentStatus is the state of an agent in an AgentTree.
+type AgentStatus string
+
+const (
+	AgentStatusRunning     AgentStatus = "running"
+	AgentStatusCompleted   AgentStatus = "completed"
+	AgentStatusFailed      AgentStatus = "failed"
+	AgentStatusInterrupted AgentStatus = "interrupted" // The turn ended before the subagent returned
+)
+
+// subagentTools are the tools that spawn a subagent.
+var subagentTools = map[string]bool{"Task": true, "Agent": true}
+
+// AgentNode is the main agent (the root) or a subagent spawned by a tool use.
+type AgentNode struct {
+	ToolUseID       string      `json:"toolUseId,omitempty"` // Empty for the root
+	ParentToolUseID string      `json:"parentToolUseId,omitempty"`
+	AgentType       string      `json:"agentType,omitempty"` // subagent_type of the Task input
+	Description     string      `json:"description,omitempty"`
+	Prompt          string      `json:"prompt,omitempty"`
+	Status          AgentStatus `json:"status"`
+	Result          string      `json:"result,omitempty"`
+	IsError         bool        `json:"isError,omitempty"`
+
+	// Usage sums the API usage of the agent's own assistant messages.
+	// CostUsd is set for the root from the result message, and for
+	// subagents when the server reports it.
+	Usage   types.Usage `json:"usage"`
+	CostUsd float64     `json:"costUsd,omitempty"`
+
+	StartedAt time.Time  `json:"startedAt"`
+	EndedAt   *time.Time `json:"endedAt,omitempty"`
+
+	Messages []types.IncomingMessage `json:"messages"`
+	Children []*AgentNode            `json:"children,omitempty"`
+
+	usageByID map[string]types.Usage
+}
+
+// AgentUpdate describes a change to an AgentTree.
+type AgentUpdate struct {
+	Node    AgentNode             // Copy of the changed node, without children
+	Message types.IncomingMessage // The message that caused the change
+}
+
+// AgentTree groups a session's messages under the agent that produced them,
+// using parent_tool_use_id to attribute subagent output to the Task tool use
+// that spawned it. It is safe for concurrent use.
+type AgentTree struct {
+	mu       sync.RWMutex
+	root     *AgentNode
+	nodes    map[string]*AgentNode // By spawning tool use ID
+	onUpdate func(update AgentUpdate)
+	now      func() time.Time
+}
+
+// NewAgentTree creates an empty tree. onUpdate, if set, is called after each
+// message is added, for live views.
+func NewAgentTree(onUpdate func(update AgentUpdate)) *AgentTree {
+	t := &AgentTree{
+		nodes:    make(map[string]*AgentNode),
+		onUpdate: onUpdate,
+		now:      time.Now,
+	}
+	t.root = t.newNode("", "")
+	return t
+}
+
+// TrackAgents builds an AgentTree from the session's messages until ctx is
+// done or the session closes.
+func (s *Session) TrackAgents(ctx context.Context, onUpdate func(update AgentUpdate)) *AgentTree {
+	tree := NewAgentTree(onUpdate)
+	messages := s.Subscribe(ctx, nil, &SubscribeOptions{Replay: true, BufferSize: 256})
+	s.goTracked(func() {
+		for msg := range messages {
+			tree.Add(msg)
+		}
+	})
+	return tree
+}
+
+// Add attributes a message to its agent. Messages without a parent tool use
+// belong to the root.
+func (t *AgentTree) Add(msg types.IncomingMessage) {
+	t.mu.Lock()
+	node := t.route(msg)
+	if node == nil {
+		t.mu.Unlock()
+		return
+	}
+	update := AgentUpdate{Node: node.shallowCopy(), Message: msg}
+	t.mu.Unlock()
+
+	if t.onUpdate != nil {
+		t.onUpdate(update)
+	}
+}
+
+// Root returns a deep copy of the tree.
+func (t *AgentTree) Root() AgentNode {
+	t.mu.RLock()
+	defer t.mu.RUnlock()
+	return t.root.deepCopy()
+}
+
+// Agent returns a deep copy of the subagent spawned by a tool use.
+func (t *AgentTree) Agent(toolUseID string) (AgentNode, bool) {
+	t.mu.RLock()
+	defer t.mu.RUnlock()
+	node, ok := t.nodes[toolUseID]
+	if !ok {
+		return AgentNode{}, false
+	}
+	return node.deepCopy(), true
+}
+
+// ExportJSON writes the tree, with each agent's messages nested under it.
+func (t *AgentTree) ExportJSON(w io.Writer) error {
+	root := t.Root()
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(root)
+}
+
+// route updates the tree for msg and returns the changed node. Callers must
+// hold mu.
+func (t *AgentTree) route(msg types.IncomingMessage) *AgentNode {
+	switch m := msg.(type) {
+	case *types.SDKAssistantMessage:
+		node := t.parent(m.ParentToolUseID)
+		node.Messages = append(node.Messages, m)
+		node.addUsage(m.Message)
+		for _, use := range m.Message.ToolUses() {
+			if subagentTools[use.Name] {
+				t.spawn(node, use)
+			}
+		}
+		return node
+
+	case *types.SDKUserMessage:
+		node := t.parent(m.ParentToolUseID)
+		node.Messages = append(node.Messages, m)
+		for _, result := range m.Message.ToolResults() {
+			if child, ok := t.nodes[result.ToolUseID]; ok {
+				t.finish(child, result, m.ToolUseResult)
+			}
+		}
+		return node
+
+	case *types.SDKPartialAssistantMessage:
+		// Partial events are reported but not kept
+		return t.parent(m.ParentToolUseID)
+
+	case *types.SDKResultMessage:
+		root := t.root
+		root.Messages = append(root.Messages, m)
+		root.Result = m.Result
+		root.IsError = m.IsError
+		root.CostUsd = m.TotalCostUsd
+		root.Usage = m.Usage
+		root.EndedAt = t.timestamp()
+		root.Status = AgentStatusCompleted
+		if m.IsError {
+			root.Status = AgentStatusFailed
+		}
+		for _, node := range t.nodes {
+			if node.Status == AgentStatusRunning {
+				node.Status = AgentStatusInterrupted
+				node.EndedAt = root.EndedAt
+			}
+		}
+		return root
+
+	case *types.SDKSystemMessage:
+		t.root.Messages = append(t.root.Messages, m)
+		return t.root
+	}
+	return nil
+}
+
+// parent returns the node for a parent tool use ID, creating a placeholder
+// if the spawning tool use has not been seen.
+func (t *AgentTree) parent(toolUseID *string) *AgentNode {
+	if toolUseID == nil || *toolUseID == "" {
+		return t.root
+	}
+	if node, ok := t.nodes[*toolUseID]; ok {
+		return node
+	}
+	node := t.newNode(*toolUseID, "")
+	t.root.Children = append(t.root.Children, node)
+	t.nodes[*toolUseID] = node
+	return node
+}
+
+func (t *AgentTree) spawn(parent *AgentNode, use *types.ToolUseBlock) {
+	var input struct {
+		Description  string `json:"description"`
+		Prompt       string `json:"prompt"`
+		SubagentType string `json:"subagent_type"`
+	}
+	_ = use.DecodeInput(&input)
+
+	node, ok := t.nodes[use.ID]
+	if !ok {
+		node = t.newNode(use.ID, parent.ToolUseID)
+		t.nodes[use.ID] = node
+		parent.Children = append(parent.Children, node)
+	} else if node.ParentToolUseID != parent.ToolUseID {
+		// A placeholder created before its tool use arrived; move it
+		t.root.Children = removeNode(t.root.Children, node)
+		node.ParentToolUseID = parent.ToolUseID
+		parent.Children = append(parent.Children, node)
+	}
+	node.Description = input.Description
+	node.Prompt = input.Prompt
+	node.AgentType = input.SubagentType
+}
+
+func (t *AgentTree) finish(node *AgentNode, result *types.ToolResultBlock, toolUseResult any) {
+	node.Result = result.Text()
+	node.IsError = result.IsError
+	node.EndedAt = t.timestamp()
+	node.Status = AgentStatusCompleted
+	if result.IsError {
+		node.Status = AgentStatusFailed
+	}
+
+	// Prefer server-reported totals, which include the subagent's tool calls
+	var totals subagentTotals
+	if toolUseResult == nil || decodeData(toolUseResult, &totals) != nil {
+		return
+	}
+	if totals.TotalCostUsd != nil {
+		node.CostUsd = *totals.TotalCostUsd
+	}
+	if totals.Usage != nil && *totals.Usage != (types.Usage{}) {
+		node.Usage = *totals.Usage
+	}
+}
+
+// subagentTotals is the tool_use_result of a Task tool use. Like the other
+// Task totals (totalDurationMs, totalTokens), the cost is camelCase.
+type subagentTotals struct {
+	TotalCostUsd *float64     `json:"totalCostUsd"`
+	Usage        *types.Usage `json:"usage"`
+}
+
+func (t *AgentTree) timestamp() *time.Time {
+	now := t.now()
+	return &now
+}
+
+func (t *AgentTree) newNode(toolUseID, parentToolUseID string) *AgentNode {
+	return &AgentNode{
+		ToolUseID:       toolUseID,
+		ParentToolUseID: parentToolUseID,
+		Status:          AgentStatusRunning,
+		StartedAt:       t.now(),
+		usageByID:       make(map[string]types.Usage),
+	}
+}
+
+// addUsage counts the usage of an API message once, even if it is split
+// into several assistant messages.
+func (n *AgentNode) addUsage(msg types.Message) {
+	if msg.Usage == nil {
+		return
+	}
+	if prev, ok := n.usageByID[msg.ID]; ok && msg.ID != "" {
+		n.Usage = subtractUsage(n.Usage, prev)
+	}
+	n.Usage = addUsage(n.Usage, *msg.Usage)
+	if msg.ID != "" {
+		n.usageByID[msg.ID] = *msg.Usage
+	}
+}
+
+func (n *AgentNode) shallowCopy() AgentNode {
+	c := *n
+	c.Messages = append([]types.IncomingMessage(nil), n.Messages...)
+	c.Children = nil
+	c.usageByID = nil
+	return c
+}
+
+func (n *AgentNode) deepCopy() AgentNode {
+	c := n.shallowCopy()
+	for _, child := range n.Children {
+		childCopy := child.deepCopy()
+		c.Children = append(c.Children, &childCopy)
+	}
+	return c
+}
+
+// removeNode returns a copy of nodes without node, leaving the backing
+// array of nodes untouched.
+func removeNode(nodes []*AgentNode, node *AgentNode) []*AgentNode {
+	out := make([]*AgentNode, 0, len(nodes))
+	for _, n := range nodes {
+		if n != node {
+			out = append(out, n)
+		}
+	}
+	return out
+}
+
+func addUsage(a, b types.Usage) types.Usage {
+	a.InputTokens += b.InputTokens
+	a.OutputTokens += b.OutputTokens
+	a.CacheCreationInputTokens += b.CacheCreationInputTokens
+	a.CacheReadInputTokens += b.CacheReadInputTokens
+	return a
+}
+
+func subtractUsage(a, b types.Usage) types.Usage {
+	a.InputTokens -= b.InputTokens
+	a.OutputTokens -= b.OutputTokens
+	a.CacheCreationInputTokens -= b.CacheCreationInputTokens
+	a.CacheReadInputTokens -= b.CacheReadInputTokens
+	return a
+}
@@ -0,0 +1,118 @@
+package chucky
+
+import (
+	"testing"
+
+	"github.com/chucky-cloud/chucky-sdk-go/pkg/types"
+)
+
+// addRaw parses and adds recorded wire messages to the tree.
+func addRaw(t *testing.T, tree *AgentTree, raws ...string) {
+	t.Helper()
+	for _, raw := range raws {
+		msg, err := types.ParseIncomingMessage([]byte(raw))
+		if err != nil {
+			t.Fatalf("parse %s: %v", raw, err)
+		}
+		tree.Add(msg)
+	}
+}
+
+const (
+	spawnTask = `{"type":"assistant","parent_tool_use_id":null,"message":{"id":"msg_1","role":"assistant","content":[{"type":"tool_use","id":"toolu_1","name":"Task","input":{"description":"Review pkg","prompt":"Review it","subagent_type":"reviewer"}}]}}`
+	subagent  = `{"type":"assistant","parent_tool_use_id":"toolu_1","message":{"id":"msg_2","role":"assistant","content":[{"type":"text","text":"Looks fine"}],"usage":{"input_tokens":10,"output_tokens":5}}}`
+)
+
+func taskResult(toolUseResult string) string {
+	return `{"type":"user","parent_tool_use_id":null,"message":{"role":"user","content":[{"type":"tool_result","tool_use_id":"toolu_1","content":"Looks fine"}]},"tool_use_result":` + toolUseResult + `}`
+}
+
+func TestAgentTreeSubagentTotals(t *testing.T) {
+	tests := []struct {
+		name          string
+		toolUseResult string
+		cost          float64
+		outputTokens  int
+	}{
+		{"camelCase totals", `{"totalCostUsd":0.42,"totalDurationMs":1200,"totalTokens":900,"usage":{"input_tokens":800,"output_tokens":100}}`, 0.42, 100},
+		{"snake_case cost is not read", `{"total_cost_usd":0.42}`, 0, 5},
+		{"no usage keeps message usage", `{"totalCostUsd":0.1}`, 0.1, 5},
+		{"string result", `"Looks fine"`, 0, 5},
+		{"no result", `null`, 0, 5},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tree := NewAgentTree(nil)
+			addRaw(t, tree, spawnTask, subagent, taskResult(tt.toolUseResult))
+
+			node, ok := tree.Agent("toolu_1")
+			if !ok {
+				t.Fatal("subagent not tracked")
+			}
+			if node.Status != AgentStatusCompleted || node.Result != "Looks fine" {
+				t.Errorf("status, result = %s, %q", node.Status, node.Result)
+			}
+			if node.CostUsd != tt.cost {
+				t.Errorf("CostUsd = %v, want %v", node.CostUsd, tt.cost)
+			}
+			if node.Usage.OutputTokens != tt.outputTokens {
+				t.Errorf("OutputTokens = %d, want %d", node.Usage.OutputTokens, tt.outputTokens)
+			}
+		})
+	}
+}
+
+func TestAgentTreeMovesPlaceholder(t *testing.T) {
+	tree := NewAgentTree(nil)
+	// Nested subagent output arrives before the tool use that spawned it
+	addRaw(t, tree,
+		`{"type":"assistant","parent_tool_use_id":"toolu_2","message":{"role":"assistant","content":[{"type":"text","text":"nested"}]}}`,
+		spawnTask,
+		`{"type":"assistant","parent_tool_use_id":"toolu_1","message":{"role":"assistant","content":[{"type":"tool_use","id":"toolu_2","name":"Task","input":{"description":"Nested"}}]}}`,
+	)
+
+	root := tree.Root()
+	if len(root.Children) != 1 || root.Children[0].ToolUseID != "toolu_1" {
+		t.Fatalf("root children = %+v, want only toolu_1", root.Children)
+	}
+	parent := root.Children[0]
+	if len(parent.Children) != 1 || parent.Children[0].ToolUseID != "toolu_2" || parent.Children[0].Description != "Nested" {
+		t.Fatalf("toolu_1 children = %+v, want toolu_2", parent.Children)
+	}
+	if got := len(parent.Children[0].Messages); got != 1 {
+		t.Errorf("placeholder kept %d messages, want 1", got)
+	}
+}
+
+func TestRemoveNodeCopies(t *testing.T) {
+	a, b, c := &AgentNode{ToolUseID: "a"}, &AgentNode{ToolUseID: "b"}, &AgentNode{ToolUseID: "c"}
+	tests := []struct {
+		name   string
+		remove *AgentNode
+		want   []string
+	}{
+		{"first", a, []string{"b", "c"}},
+		{"middle", b, []string{"a", "c"}},
+		{"last", c, []string{"a", "b"}},
+		{"missing", &AgentNode{}, []string{"a", "b", "c"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			nodes := []*AgentNode{a, b, c}
+			got := removeNode(nodes, tt.remove)
+
+			if len(got) != len(tt.want) {
+				t.Fatalf("len = %d, want %d", len(got), len(tt.want))
+			}
+			for i, id := range tt.want {
+				if got[i].ToolUseID != id {
+					t.Errorf("got[%d] = %s, want %s", i, got[i].ToolUseID, id)
+				}
+			}
+			// The input slice, which a snapshot may still share, is unchanged
+			if nodes[0] != a || nodes[1] != b || nodes[2] != c {
+				t.Errorf("input modified: %s %s %s", nodes[0].ToolUseID, nodes[1].ToolUseID, nodes[2].ToolUseID)
+			}
+		})
+	}
+}
@@ -0,0 +1,56 @@
+package chucky
+
+import (
+	"sort"
+	"strings"
+
+	"github.com/chucky-cloud/chucky-sdk-go/pkg/types"
+)
+
+// agentsToMap converts the subagent definitions into the init payload format.
+func (s *Session) agentsToMap() any {
+	if len(s.options.Agents) == 0 {
+		return nil
+	}
+
+	names := make([]string, 0, len(s.options.Agents))
+	for name := range s.options.Agents {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	agents := make(map[string]any, len(names))
+	for _, name := range names {
+		agent := s.options.Agents[name]
+		entry := map[string]any{
+			"description": agent.Description,
+			"prompt":      agent.Prompt,
+		}
+		if tools := agent.AllowedTools(); tools != nil {
+			entry["tools"] = tools
+		}
+		if agent.Model != "" {
+			entry["model"] = agent.Model
+		}
+		agents[name] = entry
+	}
+	return agents
+}
+
+// toolHandler returns the handler for a tool call. Calls from subagents may
+// use the qualified "mcp__server__tool" name; client tool names are unique
+// across servers (Validate checks the session's and agents' servers, AddTools
+// refuses names of other servers), so the bare name identifies the handler.
+// Callers must hold toolsMu.
+func (s *Session) toolHandler(name string) (types.ToolHandler, bool) {
+	if handler, ok := s.toolHandlers[name]; ok {
+		return handler, true
+	}
+	if qualified, ok := strings.CutPrefix(name, "mcp__"); ok {
+		if _, tool, ok := strings.Cut(qualified, "__"); ok {
+			handler, ok := s.toolHandlers[tool]
+			return handler, ok
+		}
+	}
+	return nil, false
+}
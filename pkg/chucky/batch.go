@@ -0,0 +1,443 @@
+package chucky
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"os"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/chucky-cloud/chucky-sdk-go/pkg/types"
+)
+
+// PromptRequest is one item of a batch.
+type PromptRequest struct {
+	// ID identifies the item in results and checkpoints. Defaults to its index.
+	ID      string
+	Message string
+	Options *types.SessionOptions
+}
+
+// BatchOptions configures Client.PromptBatch.
+type BatchOptions struct {
+	// Concurrency is the maximum number of prompts in flight. Default: 4.
+	Concurrency int
+
+	// MinConcurrency is the lower bound when backing off. Default: 1.
+	MinConcurrency int
+
+	// MaxRequeues is how often an item is re-queued after a concurrency
+	// limit error before it fails. Default: 5.
+	MaxRequeues int
+
+	// CheckpointFile is a JSON Lines file to which every completed item is
+	// appended. If it exists, items that already succeeded are skipped.
+	CheckpointFile string
+
+	// OnProgress is called after every item completes.
+	OnProgress func(progress BatchProgress)
+}
+
+// BatchItemResult is the outcome of one batch item.
+type BatchItemResult struct {
+	ID       string               `json:"id"`
+	Index    int                  `json:"index"`
+	Result   *types.SessionResult `json:"result,omitempty"`
+	Err      error                `json:"-"`
+	Error    string               `json:"error,omitempty"`
+	Resumed  bool                 `json:"-"` // Loaded from the checkpoint
+	Requeues int                  `json:"requeues,omitempty"`
+}
+
+// BatchProgress reports the progress of a batch.
+type BatchProgress struct {
+	Item         BatchItemResult
+	Completed    int
+	Failed       int
+	Total        int
+	Concurrency  int
+	TotalCostUsd float64
+}
+
+// BatchResult aggregates the outcome of a batch.
+type BatchResult struct {
+	Items        []BatchItemResult
+	Succeeded    int
+	Failed       int
+	Resumed      int
+	TotalCostUsd float64
+	Usage        types.Usage
+}
+
+// PromptBatch runs independent prompts with a bounded worker pool. The
+// number of prompts in flight adapts: it halves on concurrency limit errors
+// and grows again after successes. Results are returned in request order.
+func (c *Client) PromptBatch(ctx context.Context, requests []PromptRequest, opts BatchOptions) (*BatchResult, error) {
+	if opts.Concurrency <= 0 {
+		opts.Concurrency = 4
+	}
+	if opts.MinConcurrency <= 0 {
+		opts.MinConcurrency = 1
+	}
+	if opts.MinConcurrency > opts.Concurrency {
+		opts.MinConcurrency = opts.Concurrency
+	}
+	if opts.MaxRequeues <= 0 {
+		opts.MaxRequeues = 5
+	}
+
+	items := make([]BatchItemResult, len(requests))
+	seen := make(map[string]bool, len(requests))
+	for i, req := range requests {
+		id := req.ID
+		if id == "" {
+			id = strconv.Itoa(i)
+		}
+		if seen[id] {
+			return nil, types.ValidationError("duplicate batch item ID: " + id)
+		}
+		seen[id] = true
+		items[i] = BatchItemResult{ID: id, Index: i}
+	}
+
+	checkpoint, size, err := loadCheckpoint(opts.CheckpointFile)
+	if err != nil {
+		return nil, err
+	}
+	var checkpointFile *os.File
+	if opts.CheckpointFile != "" {
+		checkpointFile, err = openCheckpoint(opts.CheckpointFile, size)
+		if err != nil {
+			return nil, types.ValidationError("failed to open batch checkpoint").Wrap(err)
+		}
+		defer checkpointFile.Close()
+	}
+
+	b := &batchRun{
+		client:         c,
+		requests:       requests,
+		items:          items,
+		opts:           opts,
+		checkpointFile: checkpointFile,
+		limiter:        newAdaptiveLimiter(opts.MinConcurrency, opts.Concurrency),
+		queue:          make(chan int, len(requests)),
+	}
+
+	for i := range items {
+		if prev, ok := checkpoint[items[i].ID]; ok && prev.Error == "" && prev.Result != nil {
+			items[i].Result = prev.Result
+			items[i].Resumed = true
+			b.completed++
+			continue
+		}
+		b.pending++
+		b.queue <- i
+	}
+	if b.pending == 0 {
+		close(b.queue)
+	}
+
+	var wg sync.WaitGroup
+	for w := 0; w < opts.Concurrency; w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			b.worker(ctx)
+		}()
+	}
+	wg.Wait()
+
+	// Items that never ran because the context was cancelled
+	if ctxErr := ctx.Err(); ctxErr != nil {
+		for i := range items {
+			if !items[i].Resumed && items[i].Result == nil && items[i].Err == nil {
+				items[i].Err = ctxErr
+				items[i].Error = ctxErr.Error()
+			}
+		}
+	}
+
+	result := &BatchResult{Items: items}
+	for _, item := range items {
+		switch {
+		case item.Resumed:
+			result.Resumed++
+		case item.Err != nil || item.Error != "":
+			result.Failed++
+		case item.Result != nil:
+			result.Succeeded++
+		}
+		if item.Result != nil {
+			result.TotalCostUsd += item.Result.TotalCostUsd
+			result.Usage.InputTokens += item.Result.Usage.InputTokens
+			result.Usage.OutputTokens += item.Result.Usage.OutputTokens
+			result.Usage.CacheCreationInputTokens += item.Result.Usage.CacheCreationInputTokens
+			result.Usage.CacheReadInputTokens += item.Result.Usage.CacheReadInputTokens
+		}
+	}
+
+	if b.checkpointErr != nil {
+		return result, b.checkpointErr
+	}
+	if checkpointFile != nil {
+		if err := checkpointFile.Close(); err != nil {
+			return result, types.ValidationError("failed to write batch checkpoint").Wrap(err)
+		}
+	}
+	return result, ctx.Err()
+}
+
+// batchRun holds the state of one PromptBatch call.
+type batchRun struct {
+	client   *Client
+	requests []PromptRequest
+	items    []BatchItemResult
+	opts     BatchOptions
+	limiter  *adaptiveLimiter
+	queue    chan int
+
+	checkpointFile *os.File
+	checkpointMu   sync.Mutex // Serializes checkpoint writes, outside mu
+
+	mu            sync.Mutex
+	pending       int
+	completed     int
+	failed        int
+	costUsd       float64
+	checkpointErr error
+}
+
+func (b *batchRun) worker(ctx context.Context) {
+	for {
+		var idx int
+		var ok bool
+		select {
+		case idx, ok = <-b.queue:
+			if !ok {
+				return
+			}
+		case <-ctx.Done():
+			return
+		}
+
+		if err := b.limiter.acquire(ctx); err != nil {
+			return
+		}
+		req := b.requests[idx]
+		result, err := b.client.Prompt(ctx, req.Message, req.Options)
+		b.limiter.release()
+
+		if errors.Is(err, types.ErrConcurrencyLimit) && b.items[idx].Requeues < b.opts.MaxRequeues {
+			b.limiter.decrease()
+			b.items[idx].Requeues++
+
+			delay, ok := types.RetryAfter(err)
+			if !ok {
+				delay = time.Duration(b.items[idx].Requeues) * time.Second
+			}
+			select {
+			case <-time.After(delay):
+			case <-ctx.Done():
+				return
+			}
+			b.queue <- idx
+			continue
+		}
+
+		if err == nil {
+			b.limiter.increase()
+		}
+		b.finish(idx, result, err)
+	}
+}
+
+// finish records an item's outcome, appends it to the checkpoint and reports
+// progress.
+func (b *batchRun) finish(idx int, result *types.SessionResult, err error) {
+	b.mu.Lock()
+	item := &b.items[idx]
+	item.Result = result
+	item.Err = err
+	if err != nil {
+		item.Error = err.Error()
+		b.failed++
+	}
+	if result != nil {
+		b.costUsd += result.TotalCostUsd
+	}
+	b.completed++
+	b.pending--
+	if b.pending == 0 {
+		close(b.queue)
+	}
+
+	progress := BatchProgress{
+		Item:         *item,
+		Completed:    b.completed,
+		Failed:       b.failed,
+		Total:        len(b.items),
+		Concurrency:  b.limiter.limit(),
+		TotalCostUsd: b.costUsd,
+	}
+	b.mu.Unlock()
+
+	if b.checkpointFile != nil {
+		if cpErr := b.appendCheckpoint(progress.Item); cpErr != nil {
+			b.mu.Lock()
+			if b.checkpointErr == nil {
+				b.checkpointErr = types.ValidationError("failed to write batch checkpoint").Wrap(cpErr)
+			}
+			b.mu.Unlock()
+		}
+	}
+
+	if b.opts.OnProgress != nil {
+		b.opts.OnProgress(progress)
+	}
+}
+
+// appendCheckpoint writes item as one line of the checkpoint file.
+func (b *batchRun) appendCheckpoint(item BatchItemResult) error {
+	line, err := json.Marshal(item)
+	if err != nil {
+		return err
+	}
+	b.checkpointMu.Lock()
+	defer b.checkpointMu.Unlock()
+	_, err = b.checkpointFile.Write(append(line, '\n'))
+	return err
+}
+
+// loadCheckpoint reads the items of a checkpoint file and returns the size
+// of its complete lines. Later lines for the same ID replace earlier ones. A
+// truncated last line, as left by a crash during a write, is ignored.
+func loadCheckpoint(path string) (map[string]BatchItemResult, int64, error) {
+	items := make(map[string]BatchItemResult)
+	if path == "" {
+		return items, 0, nil
+	}
+
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return items, 0, nil
+	}
+	if err != nil {
+		return nil, 0, types.ValidationError("failed to read batch checkpoint").Wrap(err)
+	}
+
+	var size int64
+	for num := 1; len(data) > 0; num++ {
+		line, rest, complete := bytes.Cut(data, []byte("\n"))
+		if !complete {
+			break
+		}
+		data = rest
+		size += int64(len(line)) + 1
+		if len(bytes.TrimSpace(line)) == 0 {
+			continue
+		}
+
+		var item BatchItemResult
+		if err := json.Unmarshal(line, &item); err != nil {
+			return nil, 0, types.ValidationError("invalid batch checkpoint").
+				WithDetails(map[string]any{"line": num}).Wrap(err)
+		}
+		items[item.ID] = item
+	}
+	return items, size, nil
+}
+
+// openCheckpoint opens the checkpoint file for appending, dropping anything
+// after the first size bytes.
+func openCheckpoint(path string, size int64) (*os.File, error) {
+	f, err := os.OpenFile(path, os.O_WRONLY|os.O_APPEND|os.O_CREATE, 0o644)
+	if err != nil {
+		return nil, err
+	}
+	if info, err := f.Stat(); err == nil && info.Size() > size {
+		err = f.Truncate(size)
+		if err != nil {
+			f.Close()
+			return nil, err
+		}
+	}
+	return f, nil
+}
+
+// adaptiveLimiter bounds concurrency with additive increase and
+// multiplicative decrease.
+type adaptiveLimiter struct {
+	mu       sync.Mutex
+	cond     *sync.Cond
+	min      int
+	max      int
+	current  int
+	inFlight int
+	streak   int
+}
+
+func newAdaptiveLimiter(min, max int) *adaptiveLimiter {
+	l := &adaptiveLimiter{min: min, max: max, current: max}
+	l.cond = sync.NewCond(&l.mu)
+	return l
+}
+
+func (l *adaptiveLimiter) acquire(ctx context.Context) error {
+	// Wake waiters when the context is cancelled
+	stop := context.AfterFunc(ctx, func() {
+		l.mu.Lock()
+		l.cond.Broadcast()
+		l.mu.Unlock()
+	})
+	defer stop()
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	for l.inFlight >= l.current {
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+		l.cond.Wait()
+	}
+	l.inFlight++
+	return nil
+}
+
+func (l *adaptiveLimiter) release() {
+	l.mu.Lock()
+	l.inFlight--
+	l.cond.Broadcast()
+	l.mu.Unlock()
+}
+
+// decrease halves the limit after a concurrency limit error.
+func (l *adaptiveLimiter) decrease() {
+	l.mu.Lock()
+	l.current /= 2
+	if l.current < l.min {
+		l.current = l.min
+	}
+	l.streak = 0
+	l.mu.Unlock()
+}
+
+// increase raises the limit by one after current consecutive successes.
+func (l *adaptiveLimiter) increase() {
+	l.mu.Lock()
+	l.streak++
+	if l.streak >= l.current && l.current < l.max {
+		l.current++
+		l.streak = 0
+		l.cond.Broadcast()
+	}
+	l.mu.Unlock()
+}
+
+func (l *adaptiveLimiter) limit() int {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return l.current
+}
@@ -0,0 +1,225 @@
+package chucky
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/chucky-cloud/chucky-sdk-go/pkg/types"
+)
+
+func TestAdaptiveLimiter(t *testing.T) {
+	tests := []struct {
+		name     string
+		min, max int
+		ops      string // d: decrease, i: increase
+		want     int
+	}{
+		{"starts at max", 1, 8, "", 8},
+		{"halves", 1, 8, "d", 4},
+		{"floors at min", 3, 8, "ddd", 3},
+		{"grows after a streak of current successes", 1, 8, "dd" + "ii", 3},
+		{"streak shorter than limit", 1, 8, "d" + "iii", 4},
+		{"failure resets streak", 1, 8, "dd" + "i" + "d" + "i", 2},
+		{"capped at max", 1, 2, "iiiiii", 2},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			l := newAdaptiveLimiter(tt.min, tt.max)
+			for _, op := range tt.ops {
+				if op == 'd' {
+					l.decrease()
+				} else {
+					l.increase()
+				}
+			}
+			if got := l.limit(); got != tt.want {
+				t.Errorf("limit = %d, want %d", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestAdaptiveLimiterBlocksAtLimit(t *testing.T) {
+	l := newAdaptiveLimiter(1, 2)
+	ctx := context.Background()
+	for i := 0; i < 2; i++ {
+		if err := l.acquire(ctx); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	cancelled, cancel := context.WithTimeout(ctx, 20*time.Millisecond)
+	defer cancel()
+	if err := l.acquire(cancelled); !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("acquire at limit = %v, want deadline exceeded", err)
+	}
+
+	acquired := make(chan struct{})
+	go func() {
+		if err := l.acquire(ctx); err == nil {
+			close(acquired)
+		}
+	}()
+	select {
+	case <-acquired:
+		t.Fatal("acquired above the limit")
+	case <-time.After(20 * time.Millisecond):
+	}
+	l.release()
+	select {
+	case <-acquired:
+	case <-time.After(time.Second):
+		t.Fatal("release did not wake a waiter")
+	}
+}
+
+func TestLoadCheckpoint(t *testing.T) {
+	tests := []struct {
+		name     string
+		content  string
+		wantIDs  []string
+		wantSize int // -1: the whole content
+		wantErr  string
+	}{
+		{"missing", "", nil, 0, ""},
+		{"lines", `{"id":"a","index":0}` + "\n" + `{"id":"b","index":1,"error":"x"}` + "\n", []string{"a", "b"}, -1, ""},
+		{"later line wins", `{"id":"a","index":0,"error":"x"}` + "\n" + `{"id":"a","index":0}` + "\n", []string{"a"}, -1, ""},
+		{"torn last line", `{"id":"a","index":0}` + "\n" + `{"id":"b","ind`, []string{"a"}, 21, ""},
+		{"blank lines", "\n" + `{"id":"a","index":0}` + "\n\n", []string{"a"}, -1, ""},
+		{"corrupt line", `{"id":"a"}` + "\n" + "garbage\n" + `{"id":"b"}` + "\n", nil, 0, "invalid batch checkpoint"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), "cp.jsonl")
+			if tt.content != "" {
+				if err := os.WriteFile(path, []byte(tt.content), 0o644); err != nil {
+					t.Fatal(err)
+				}
+			}
+
+			items, size, err := loadCheckpoint(path)
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("error = %v, want %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if tt.wantSize < 0 {
+				tt.wantSize = len(tt.content)
+			}
+			var ids []string
+			for id := range items {
+				ids = append(ids, id)
+			}
+			sort.Strings(ids)
+			if strings.Join(ids, ",") != strings.Join(tt.wantIDs, ",") || size != int64(tt.wantSize) {
+				t.Errorf("ids = %v, size = %d; want %v, %d", ids, size, tt.wantIDs, tt.wantSize)
+			}
+		})
+	}
+}
+
+// servePrompts answers every prompt on f with a successful result and
+// records the prompts received.
+func servePrompts(t *testing.T, f *fakeServer) func() []string {
+	var mu sync.Mutex
+	var prompts []string
+	done := make(chan struct{})
+	t.Cleanup(func() { close(done) })
+
+	go func() {
+		for {
+			var c *fakeConn
+			select {
+			case c = <-f.conns:
+			case <-done:
+				return
+			}
+			go func() {
+				for msg := range c.in {
+					if msg["type"] != string(types.MessageTypeUser) {
+						continue
+					}
+					message, _ := msg["message"].(map[string]any)
+					prompt, _ := message["content"].(string)
+					mu.Lock()
+					prompts = append(prompts, prompt)
+					mu.Unlock()
+					c.send(types.SDKResultMessage{
+						Type:         types.MessageTypeResult,
+						Subtype:      types.ResultSubtypeSuccess,
+						SessionID:    "session-" + prompt,
+						NumTurns:     1,
+						Result:       "done " + prompt,
+						TotalCostUsd: 0.5,
+					})
+				}
+			}()
+		}
+	}()
+
+	return func() []string {
+		mu.Lock()
+		defer mu.Unlock()
+		got := append([]string(nil), prompts...)
+		sort.Strings(got)
+		return got
+	}
+}
+
+func TestPromptBatchResumesFromCheckpoint(t *testing.T) {
+	f := newFakeServer(t)
+	prompts := servePrompts(t, f)
+	client := f.client(types.ClientOptions{})
+
+	path := filepath.Join(t.TempDir(), "batch.jsonl")
+	previous := `{"id":"a","index":0,"result":{"result":"done a","total_cost_usd":0.5}}` + "\n" +
+		`{"id":"b","index":1,"error":"overloaded"}` + "\n" +
+		`{"id":"c","ind` // Torn by a crash
+	if err := os.WriteFile(path, []byte(previous), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	requests := []PromptRequest{{ID: "a", Message: "a"}, {ID: "b", Message: "b"}, {ID: "c", Message: "c"}}
+	result, err := client.PromptBatch(context.Background(), requests, BatchOptions{Concurrency: 2, CheckpointFile: path})
+	if err != nil {
+		t.Fatalf("PromptBatch: %v", err)
+	}
+	if result.Resumed != 1 || result.Succeeded != 2 || result.Failed != 0 || result.TotalCostUsd != 1.5 {
+		t.Errorf("result = %+v", result)
+	}
+	if got := prompts(); strings.Join(got, ",") != "b,c" {
+		t.Errorf("prompts sent = %v, want [b c]", got)
+	}
+	if !result.Items[0].Resumed || result.Items[0].Result.Result != "done a" {
+		t.Errorf("item a = %+v", result.Items[0])
+	}
+
+	// Every item now succeeded; a second run sends nothing
+	items, _, err := loadCheckpoint(path)
+	if err != nil {
+		t.Fatalf("loadCheckpoint: %v", err)
+	}
+	for _, id := range []string{"a", "b", "c"} {
+		if item := items[id]; item.Error != "" || item.Result == nil {
+			t.Errorf("checkpoint item %s = %+v", id, item)
+		}
+	}
+	result, err = client.PromptBatch(context.Background(), requests, BatchOptions{CheckpointFile: path})
+	if err != nil || result.Resumed != 3 {
+		t.Errorf("second run = %+v, %v", result, err)
+	}
+	if got := prompts(); len(got) != 2 {
+		t.Errorf("second run sent prompts: %v", got)
+	}
+}
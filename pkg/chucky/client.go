@@ -3,18 +3,33 @@ package chucky
 
 import (
 	"context"
-	"sync"
+	"errors"
+	"sync/atomic"
+	"time"
 
 	"github.com/chucky-cloud/chucky-sdk-go/pkg/transport"
 	"github.com/chucky-cloud/chucky-sdk-go/pkg/types"
+	"github.com/chucky-cloud/chucky-sdk-go/pkg/utils"
 )
 
 // Client is the main entry point for the Chucky SDK.
 type Client struct {
 	options    types.ClientOptions
-	sessions   map[string]*Session
-	sessionsMu sync.RWMutex
+	registry   *sessionRegistry
+	sessionSeq atomic.Uint64
 	handlers   ClientEventHandlers
+	shutdown   atomic.Bool // Set by Shutdown, refuses new connections
+
+	toolMiddleware []types.ToolMiddleware
+
+	// Session defaults from the token's sdkConfig claim
+	tokenDefaults types.SessionOptions
+
+	// Cost tracking
+	userID string // JWT sub of the client token
+	budget *types.TokenBudget
+	ledger *CostLedger
+	guard  *BudgetGuard
 }
 
 // ClientEventHandlers contains callbacks for client events.
@@ -27,34 +42,74 @@ type ClientEventHandlers struct {
 // NewClient creates a new Chucky client.
 func NewClient(opts types.ClientOptions) *Client {
 	merged := types.DefaultClientOptions().Merge(opts)
-	return &Client{
+	c := &Client{
 		options:  merged,
-		sessions: make(map[string]*Session),
+		registry: newSessionRegistry(),
+		ledger:   NewCostLedger(),
+	}
+
+	// Attribute spend to the user the token was minted for
+	token := merged.Token
+	if merged.TokenProvider != nil {
+		if provided, err := merged.TokenProvider(); err == nil {
+			token = provided
+		}
 	}
+	if decoded, err := utils.DecodeToken(token); err == nil {
+		c.userID = decoded.Payload.Subject
+		c.budget = &decoded.Payload.Budget
+		if cfg := decoded.Payload.SdkConfig; cfg != nil {
+			c.tokenDefaults.Model = types.Model(cfg.DefaultModel)
+			if cfg.SystemPrompt != "" {
+				c.tokenDefaults.SystemPrompt = cfg.SystemPrompt
+			}
+		}
+	}
+
+	return c
 }
 
-// CreateSession creates a new session with the given options.
+// CreateSession creates a new session with the given options, merged over
+// ClientOptions.DefaultSession and the token's sdkConfig defaults (see
+// SessionOptions.Merge). The merged options are validated with
+// SessionOptions.Validate; if they are invalid, Connect and Send return the
+// ValidationError.
 func (c *Client) CreateSession(opts *types.SessionOptions) *Session {
-	if opts == nil {
-		opts = &types.SessionOptions{}
+	return c.createSession(c.sessionOptions(opts))
+}
+
+// sessionOptions merges opts over the client's session defaults.
+func (c *Client) sessionOptions(opts *types.SessionOptions) types.SessionOptions {
+	merged := c.tokenDefaults
+	if c.options.DefaultSession != nil {
+		merged = merged.Merge(*c.options.DefaultSession)
 	}
+	if opts != nil {
+		merged = merged.Merge(*opts)
+	}
+	return merged
+}
 
-	// Create transport
-	t := transport.NewWebSocketTransport(transport.WebSocketTransportOptions{
+// createSession creates a session from already merged options.
+func (c *Client) createSession(opts types.SessionOptions) *Session {
+	session := newSession(c, c.newTransport(), opts)
+	session.optionsErr = opts.Validate()
+	session.seq = c.sessionSeq.Add(1)
+	c.registry.add(session)
+
+	return session
+}
+
+// newTransport creates a transport for a new connection.
+func (c *Client) newTransport() transport.Transport {
+	return transport.NewWebSocketTransport(transport.WebSocketTransportOptions{
 		BaseURL:           c.options.BaseURL,
 		Token:             c.options.Token,
+		TokenProvider:     c.options.TokenProvider,
 		Timeout:           c.options.Timeout,
 		KeepAliveInterval: c.options.KeepAliveInterval,
 		Debug:             c.options.Debug,
 	})
-
-	session := newSession(c, t, *opts)
-
-	c.sessionsMu.Lock()
-	c.sessions[session.ID()] = session
-	c.sessionsMu.Unlock()
-
-	return session
 }
 
 // ResumeSession resumes an existing session by ID.
@@ -69,38 +124,81 @@ func (c *Client) ResumeSession(sessionID string, opts *types.SessionOptions) *Se
 }
 
 // Prompt sends a one-shot prompt and returns the result.
+// Error results (e.g. error_max_turns) are returned together with a
+// ChuckyError describing them, so cost and usage remain available.
+//
+// With a RetryPolicy, prompts that fail before the agent produced any output
+// or called a tool are retried, and an overloaded or unavailable model is
+// replaced by SessionOptions.FallbackModel.
 func (c *Client) Prompt(ctx context.Context, message string, opts *types.SessionOptions) (*types.SessionResult, error) {
-	session := c.CreateSession(opts)
+	attemptOpts := c.sessionOptions(opts)
+	policy := c.options.RetryPolicy
+
+	for attempt := 1; ; attempt++ {
+		result, idempotent, err := c.promptOnce(ctx, message, &attemptOpts)
+		if err == nil || !idempotent || ctx.Err() != nil {
+			return result, err
+		}
+
+		fallback := types.Model(attemptOpts.FallbackModel)
+		if fallback != "" && attemptOpts.Model != fallback && policy.ShouldFallback(err) {
+			attemptOpts.Model = fallback
+			continue
+		}
+
+		if !policy.ShouldRetry(attempt, err) {
+			return result, err
+		}
+
+		select {
+		case <-time.After(policy.Delay(attempt, err)):
+		case <-ctx.Done():
+			return result, err
+		}
+	}
+}
+
+// promptOnce runs a single prompt attempt. idempotent reports whether the
+// attempt failed before any assistant output or tool call, so it is safe to retry.
+func (c *Client) promptOnce(ctx context.Context, message string, opts *types.SessionOptions) (result *types.SessionResult, idempotent bool, err error) {
+	session := c.createSession(*opts)
 	defer session.Close()
 
 	if err := session.Send(ctx, message); err != nil {
-		return nil, err
+		return nil, true, err
 	}
 
-	var result *types.SessionResult
+	var resultErr, serverErr error
+	sawOutput := false
 	for msg := range session.Stream(ctx) {
-		if resultMsg, ok := msg.(*types.SDKResultMessage); ok {
-			result = types.FromResultMessage(resultMsg)
+		switch m := msg.(type) {
+		case *types.SDKAssistantMessage:
+			sawOutput = true
+		case *types.SDKResultMessage:
+			result = types.FromResultMessage(m)
+			resultErr = types.ErrorFromResult(m)
+		case *types.ErrorEnvelope:
+			serverErr = types.ErrorFromPayload(m.Payload)
 		}
 	}
+	idempotent = !sawOutput && session.toolCallCount() == 0
 
 	if result == nil {
-		return nil, types.SessionError("no result received")
+		if serverErr != nil {
+			return nil, idempotent, serverErr
+		}
+		if ctx.Err() != nil {
+			return nil, idempotent, types.TimeoutError("no result received").Wrap(ctx.Err())
+		}
+		return nil, idempotent, types.SessionError("no result received")
 	}
 
-	return result, nil
+	return result, idempotent, resultErr
 }
 
 // Close closes all sessions and the client.
 func (c *Client) Close() {
-	c.sessionsMu.Lock()
-	sessions := make([]*Session, 0, len(c.sessions))
-	for _, s := range c.sessions {
-		sessions = append(sessions, s)
-	}
-	c.sessionsMu.Unlock()
-
-	for _, s := range sessions {
+	for _, s := range c.Sessions() {
 		s.Close()
 	}
 }
@@ -111,12 +209,146 @@ func (c *Client) On(handlers ClientEventHandlers) *Client {
 	return c
 }
 
-func (c *Client) removeSession(sessionID string) {
-	c.sessionsMu.Lock()
-	delete(c.sessions, sessionID)
-	c.sessionsMu.Unlock()
+// Use adds tool middleware that wraps the tool handlers of every session
+// created afterwards. Client middleware runs outside server and tool middleware.
+func (c *Client) Use(middleware ...types.ToolMiddleware) *Client {
+	c.toolMiddleware = append(c.toolMiddleware, middleware...)
+	return c
+}
+
+// Ledger returns the client's cost ledger.
+func (c *Client) Ledger() *CostLedger {
+	return c.ledger
+}
+
+// SetLedger replaces the client's cost ledger, e.g. to share one ledger
+// between clients minted for different users.
+func (c *Client) SetLedger(ledger *CostLedger) *Client {
+	c.ledger = ledger
+	if c.guard != nil {
+		c.guard.setLedger(ledger)
+	}
+	return c
+}
+
+// UserID returns the user the client token was minted for (the JWT sub).
+func (c *Client) UserID() string {
+	return c.userID
+}
+
+// SetBudgetGuard enforces limits locally: new sessions are refused and running
+// sessions are interrupted once a limit is reached. The window defaults to
+// the token's budget window.
+func (c *Client) SetBudgetGuard(limits BudgetLimits) *Client {
+	if c.budget != nil {
+		if limits.Window == "" {
+			limits.Window = c.budget.Window
+		}
+		if limits.WindowStart.IsZero() {
+			if start, err := time.Parse(time.RFC3339, c.budget.WindowStart); err == nil {
+				limits.WindowStart = start
+			}
+		}
+	}
+	c.guard = NewBudgetGuard(c.ledger, limits)
+	return c
+}
+
+// checkBudget returns an error if the budget guard refuses new sessions.
+func (c *Client) checkBudget() error {
+	if c.guard == nil {
+		return nil
+	}
+	return c.guard.Check(c.userID, "")
+}
+
+// recordResult adds a result to the ledger and enforces the budget guard.
+func (c *Client) recordResult(s *Session, msg *types.SDKResultMessage) {
+	sessionID := msg.SessionID
+	if sessionID == "" {
+		sessionID = s.ID()
+	}
+
+	cost, turns := s.resultDelta(c.ledger, sessionID, msg)
+	c.ledger.Record(CostEntry{
+		Time:         time.Now(),
+		SessionID:    sessionID,
+		UserID:       c.userID,
+		Model:        s.Model(),
+		TotalCostUsd: cost,
+		Usage:        msg.Usage,
+		NumTurns:     turns,
+		IsError:      msg.IsError,
+	})
+
+	if c.guard == nil {
+		return
+	}
+
+	err := c.guard.Check(c.userID, sessionID)
+	if err == nil {
+		return
+	}
+	c.notifyError(err)
 
-	if c.handlers.OnSessionEnd != nil {
+	if sessionScoped(err) {
+		_ = s.Interrupt()
+		return
+	}
+
+	for _, session := range c.Sessions() {
+		switch session.State() {
+		case SessionStateProcessing, SessionStateWaitingTool:
+			_ = session.Interrupt()
+		}
+	}
+}
+
+// resultDelta returns the cost and turns a result adds to the session.
+// TotalCostUsd and NumTurns are running session totals, so the previous
+// result's totals are subtracted; a resumed session starts from what the
+// ledger already holds for its ID. Totals that went down mean the server
+// started counting afresh.
+func (s *Session) resultDelta(ledger *CostLedger, sessionID string, msg *types.SDKResultMessage) (cost float64, turns int) {
+	s.costMu.Lock()
+	defer s.costMu.Unlock()
+
+	if !s.costSeen {
+		s.costSeen = true
+		if s.options.Continue && s.options.SessionID != "" {
+			prior := ledger.Summary(CostFilter{SessionID: sessionID})
+			s.lastCostUsd, s.lastNumTurns = prior.TotalCostUsd, prior.NumTurns
+		}
+	}
+
+	cost, turns = msg.TotalCostUsd, msg.NumTurns
+	if msg.TotalCostUsd >= s.lastCostUsd {
+		cost -= s.lastCostUsd
+	}
+	if msg.NumTurns >= s.lastNumTurns {
+		turns -= s.lastNumTurns
+	}
+	s.lastCostUsd, s.lastNumTurns = msg.TotalCostUsd, msg.NumTurns
+	return cost, turns
+}
+
+// sessionScoped reports whether a budget guard error concerns only the
+// session that exceeded its limit, so only that session is interrupted.
+func sessionScoped(err error) bool {
+	var chuckyErr *types.ChuckyError
+	return errors.As(err, &chuckyErr) && chuckyErr.Details["scope"] == "session"
+}
+
+// removeSession unregisters a closed session. OnSessionEnd only fires for
+// sessions that received a server ID, matching OnSessionStart.
+func (c *Client) removeSession(s *Session) {
+	c.registry.remove(s)
+
+	sessionID := s.ID()
+	if sessionID != "" {
+		c.ledger.forgetSession(sessionID)
+	}
+	if sessionID != "" && c.handlers.OnSessionEnd != nil {
 		c.handlers.OnSessionEnd(sessionID)
 	}
 }
@@ -0,0 +1,44 @@
+package chucky
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync/atomic"
+	"testing"
+
+	"github.com/chucky-cloud/chucky-sdk-go/pkg/types"
+)
+
+func TestTokenProviderCalledPerConnection(t *testing.T) {
+	f := newFakeServer(t)
+	var calls atomic.Int32
+	client := f.client(types.ClientOptions{TokenProvider: func() (string, error) {
+		return fmt.Sprintf("token-%d", calls.Add(1)), nil
+	}})
+	if calls.Load() != 1 {
+		t.Fatalf("NewClient called the provider %d times, want 1", calls.Load())
+	}
+
+	for _, want := range []string{"token-2", "token-3"} {
+		_, conn := connectSession(t, f, client, nil)
+		if conn.token != want {
+			t.Errorf("connected with %q, want %q", conn.token, want)
+		}
+	}
+}
+
+func TestTokenProviderError(t *testing.T) {
+	f := newFakeServer(t)
+	errExpired := errors.New("secret revoked")
+	client := f.client(types.ClientOptions{TokenProvider: func() (string, error) {
+		return "", errExpired
+	}})
+
+	session := client.CreateSession(nil)
+	defer session.Close()
+	err := session.Connect(context.Background())
+	if !errors.Is(err, errExpired) {
+		t.Fatalf("Connect error = %v, want %v", err, errExpired)
+	}
+}
@@ -0,0 +1,62 @@
+package chucky
+
+import (
+	"context"
+	"strings"
+
+	"github.com/chucky-cloud/chucky-sdk-go/pkg/types"
+)
+
+// Compact asks the agent to compact the conversation context now, optionally
+// with instructions for the summary. It returns the compaction metadata once
+// the compact boundary arrives. Compact starts a turn, so it must not be
+// called while another turn is running.
+func (s *Session) Compact(ctx context.Context, instructions string) (*types.CompactMetadata, error) {
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	// Subscribe before sending so the boundary cannot be missed
+	events := s.Subscribe(ctx, func(msg types.IncomingMessage) bool {
+		switch m := msg.(type) {
+		case *types.SDKSystemMessage:
+			return m.Subtype == types.SystemSubtypeCompactBoundary
+		case *types.SDKResultMessage, *types.ErrorEnvelope:
+			return true
+		}
+		return false
+	}, &SubscribeOptions{Overflow: types.OverflowDropOldest})
+
+	command := "/compact"
+	if instructions = strings.TrimSpace(instructions); instructions != "" {
+		command += " " + instructions
+	}
+	if err := s.Send(ctx, command); err != nil {
+		return nil, err
+	}
+
+	for {
+		select {
+		case msg, ok := <-events:
+			if !ok {
+				if ctx.Err() != nil {
+					return nil, types.TimeoutError("compaction did not complete").Wrap(ctx.Err())
+				}
+				return nil, types.SessionError("session closed during compaction")
+			}
+			switch m := msg.(type) {
+			case *types.SDKSystemMessage:
+				meta, _ := m.CompactBoundary()
+				return meta, nil
+			case *types.SDKResultMessage:
+				if err := types.ErrorFromResult(m); err != nil {
+					return nil, err
+				}
+				return nil, types.SessionError("turn completed without compaction")
+			case *types.ErrorEnvelope:
+				return nil, types.ErrorFromPayload(m.Payload)
+			}
+		case <-ctx.Done():
+			return nil, types.TimeoutError("compaction did not complete").Wrap(ctx.Err())
+		}
+	}
+}
@@ -0,0 +1,154 @@
+package chucky
+
+import (
+	"context"
+
+	"github.com/google/uuid"
+
+	"github.com/chucky-cloud/chucky-sdk-go/pkg/types"
+)
+
+// controlRequest sends a control action and waits for the server's
+// control_response. The request ID is added to data under "requestId".
+func (s *Session) controlRequest(ctx context.Context, action types.ControlAction, data map[string]any) (*types.ControlResponse, error) {
+	requestID := uuid.New().String()
+	if data == nil {
+		data = make(map[string]any)
+	}
+	data["requestId"] = requestID
+
+	respCh := make(chan *types.ControlResponse, 1) // Buffered so delivery never blocks
+	s.pendingMu.Lock()
+	s.pending[requestID] = respCh
+	s.pendingMu.Unlock()
+
+	// Drop the entry on timeout or close so a late response is ignored
+	defer func() {
+		s.pendingMu.Lock()
+		delete(s.pending, requestID)
+		s.pendingMu.Unlock()
+	}()
+
+	if err := s.sendControl(action, data); err != nil {
+		return nil, err
+	}
+
+	select {
+	case resp := <-respCh:
+		if !resp.Success {
+			msg := resp.Error
+			if msg == "" {
+				msg = "control request rejected"
+			}
+			return resp, types.SessionError(msg).WithDetails(map[string]any{
+				"action": string(action),
+			})
+		}
+		return resp, nil
+	case <-ctx.Done():
+		return nil, types.TimeoutError("control request " + string(action) + " not acknowledged").Wrap(ctx.Err())
+	case <-s.closeCh:
+		return nil, types.SessionError("session closed")
+	}
+}
+
+// handleControlResponse delivers a control_response to its waiting request.
+// It never blocks the read loop: the entry is removed on delivery, so
+// duplicate responses and responses to requests that already timed out are
+// dropped.
+func (s *Session) handleControlResponse(data any) {
+	var resp types.ControlResponse
+	if err := decodeData(data, &resp); err != nil {
+		s.handleError(types.ProtocolError("invalid control_response").Wrap(err))
+		return
+	}
+
+	s.pendingMu.Lock()
+	respCh, ok := s.pending[resp.RequestID]
+	delete(s.pending, resp.RequestID)
+	s.pendingMu.Unlock()
+
+	if !ok {
+		return
+	}
+	select {
+	case respCh <- &resp:
+	default:
+	}
+}
+
+// updateOption applies a runtime setting. Before init it is applied locally
+// and carried by the init message; afterwards it is applied only once the
+// server acknowledges the control request.
+func (s *Session) updateOption(ctx context.Context, action types.ControlAction, data map[string]any, apply func(opts *types.SessionOptions)) error {
+	s.toolsMu.Lock()
+	if !s.initSent {
+		apply(&s.options)
+		s.toolsMu.Unlock()
+		return nil
+	}
+	s.toolsMu.Unlock()
+
+	if _, err := s.controlRequest(ctx, action, data); err != nil {
+		return err
+	}
+
+	s.toolsMu.Lock()
+	apply(&s.options)
+	s.toolsMu.Unlock()
+	return nil
+}
+
+// SetModel switches the model for subsequent turns. Once the session is
+// initialized, the local state is only updated after the server acknowledges.
+func (s *Session) SetModel(ctx context.Context, model types.Model) error {
+	return s.updateOption(ctx, types.ControlActionSetModel, map[string]any{
+		"model": model,
+	}, func(opts *types.SessionOptions) {
+		opts.Model = model
+	})
+}
+
+// SetPermissionMode switches the permission mode, e.g. from plan to default
+// after a plan is approved.
+func (s *Session) SetPermissionMode(ctx context.Context, mode types.PermissionMode) error {
+	return s.updateOption(ctx, types.ControlActionSetPermissionMode, map[string]any{
+		"permissionMode": mode,
+	}, func(opts *types.SessionOptions) {
+		opts.PermissionMode = mode
+	})
+}
+
+// SetMaxThinkingTokens changes the thinking token limit for subsequent turns.
+func (s *Session) SetMaxThinkingTokens(ctx context.Context, tokens int) error {
+	if tokens < 0 {
+		return types.ValidationError("maxThinkingTokens must not be negative")
+	}
+
+	return s.updateOption(ctx, types.ControlActionSetMaxThinkingTokens, map[string]any{
+		"maxThinkingTokens": tokens,
+	}, func(opts *types.SessionOptions) {
+		opts.MaxThinkingTokens = tokens
+	})
+}
+
+// Model returns the current model.
+func (s *Session) Model() types.Model {
+	s.toolsMu.RLock()
+	defer s.toolsMu.RUnlock()
+	return s.options.Model
+}
+
+// PermissionMode returns the current permission mode.
+func (s *Session) PermissionMode() types.PermissionMode {
+	s.toolsMu.RLock()
+	defer s.toolsMu.RUnlock()
+	return s.options.PermissionMode
+}
+
+// MaxThinkingTokens returns the current thinking token limit.
+func (s *Session) MaxThinkingTokens() int {
+	s.toolsMu.RLock()
+	defer s.toolsMu.RUnlock()
+	return s.options.MaxThinkingTokens
+}
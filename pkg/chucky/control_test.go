@@ -0,0 +1,64 @@
+package chucky
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/chucky-cloud/chucky-sdk-go/pkg/types"
+)
+
+func TestControlResponsesNeverBlockReadLoop(t *testing.T) {
+	f := newFakeServer(t)
+	session, conn := connectSession(t, f, f.client(types.ClientOptions{}), nil)
+
+	// A request that times out leaves no pending entry behind
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	err := session.SetModel(ctx, types.ModelClaudeOpus)
+	if !errors.Is(err, types.ErrTimeout) {
+		t.Fatalf("SetModel without response = %v, want timeout", err)
+	}
+	timedOut := conn.recvControl(types.ControlActionSetModel)
+	session.pendingMu.Lock()
+	pending := len(session.pending)
+	session.pendingMu.Unlock()
+	if pending != 0 {
+		t.Errorf("%d pending requests after timeout", pending)
+	}
+	if session.Model() == types.ModelClaudeOpus {
+		t.Error("model applied without acknowledgement")
+	}
+
+	// Late and duplicate responses are dropped
+	conn.respond(timedOut, nil)
+	conn.respond(timedOut, nil)
+
+	done := make(chan error, 1)
+	go func() { done <- session.SetModel(context.Background(), types.ModelClaudeSonnet) }()
+	request := conn.recvControl(types.ControlActionSetModel)
+	for i := 0; i < 3; i++ {
+		conn.respond(request, nil)
+	}
+
+	select {
+	case err := <-done:
+		if err != nil {
+			t.Fatalf("SetModel: %v", err)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("SetModel not acknowledged")
+	}
+
+	// The read loop still delivers messages
+	conn.sendRaw(assistantText("still reading"))
+	streamCtx, stop := context.WithTimeout(context.Background(), 5*time.Second)
+	defer stop()
+	for msg := range session.Stream(streamCtx) {
+		if _, ok := msg.(*types.SDKAssistantMessage); ok {
+			return
+		}
+	}
+	t.Fatal("no message after duplicate control responses")
+}
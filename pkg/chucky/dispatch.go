@@ -0,0 +1,163 @@
+package chucky
+
+import (
+	"sync"
+
+	"github.com/chucky-cloud/chucky-sdk-go/pkg/types"
+)
+
+// contentQueue decouples content frames from the transport read loop. The
+// read loop handles control-plane frames (tool calls, control messages,
+// pongs, errors) itself and only enqueues content, so a slow or absent
+// Stream reader never delays tool calls or keep-alives. The queue holds
+// limit messages before the session's backpressure policy applies. Pushing
+// never blocks: under OverflowBlock the queue grows past limit, and only
+// dispatchContent waits for slow consumers.
+type contentQueue struct {
+	mu     sync.Mutex
+	items  []types.IncomingMessage
+	limit  int
+	notify chan struct{} // Signalled when the queue grows
+}
+
+func newContentQueue(limit int) *contentQueue {
+	return &contentQueue{
+		limit:  limit,
+		notify: make(chan struct{}, 1),
+	}
+}
+
+// push adds msg to the queue. When the queue is full, OverflowDropOldest
+// discards the oldest message, OverflowError rejects msg and returns false,
+// and OverflowBlock keeps msg beyond the limit.
+func (q *contentQueue) push(msg types.IncomingMessage, policy types.OverflowPolicy) bool {
+	q.mu.Lock()
+	switch {
+	case len(q.items) < q.limit || policy == types.OverflowBlock || policy == "":
+		q.items = append(q.items, msg)
+	case policy == types.OverflowDropOldest:
+		q.items[0] = nil
+		q.items = append(q.items[1:], msg)
+	default:
+		q.mu.Unlock()
+		return false
+	}
+	q.mu.Unlock()
+
+	select {
+	case q.notify <- struct{}{}:
+	default:
+	}
+	return true
+}
+
+func (q *contentQueue) pop() (types.IncomingMessage, bool) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	if len(q.items) == 0 {
+		return nil, false
+	}
+	msg := q.items[0]
+	q.items[0] = nil
+	q.items = q.items[1:]
+	return msg, true
+}
+
+// enqueueContent hands a content frame to dispatchContent.
+func (s *Session) enqueueContent(msg types.IncomingMessage) {
+	if !s.content.push(msg, s.options.Backpressure) {
+		s.handleError(types.SessionError("message queue full, message dropped").WithDetails(map[string]any{
+			"bufferSize":  s.content.limit,
+			"messageType": string(msg.GetType()),
+		}))
+	}
+}
+
+// dispatchContent delivers queued content frames to subscribers, the Stream
+// buffer and OnMessage, in arrival order, until the session closes.
+func (s *Session) dispatchContent() {
+	for {
+		msg, ok := s.content.pop()
+		if !ok {
+			select {
+			case <-s.content.notify:
+				continue
+			case <-s.closeCh:
+				return
+			}
+		}
+
+		// Fan out to subscribers
+		for _, sub := range s.hub.publish(msg) {
+			s.handleError(types.SessionError("subscriber buffer overflow").WithDetails(map[string]any{
+				"bufferSize": sub.opts.BufferSize,
+			}))
+		}
+
+		s.deliver(msg)
+
+		if m, ok := msg.(*types.SDKSystemMessage); ok && s.handlers.OnCompact != nil {
+			if meta, ok := m.CompactBoundary(); ok {
+				s.handlers.OnCompact(*meta)
+			}
+		}
+
+		if s.handlers.OnMessage != nil {
+			s.handlers.OnMessage(msg)
+		}
+	}
+}
+
+// deliver puts msg into the Stream buffer. While a Stream reader is active
+// the session's backpressure policy applies; otherwise the buffer keeps the
+// latest messages for the next Stream call, so an unread Stream never stalls
+// subscribers or OnMessage.
+func (s *Session) deliver(msg types.IncomingMessage) {
+	policy := s.options.Backpressure
+	if s.streamReaders.Load() == 0 {
+		policy = types.OverflowDropOldest
+	}
+
+	switch policy {
+	case types.OverflowDropOldest:
+		s.deliverDropOldest(msg)
+	case types.OverflowError:
+		select {
+		case s.msgCh <- msg:
+		default:
+			s.handleError(types.SessionError("message buffer full, message dropped").WithDetails(map[string]any{
+				"bufferSize":  cap(s.msgCh),
+				"messageType": string(msg.GetType()),
+			}))
+		}
+	default:
+		for {
+			select {
+			case s.msgCh <- msg:
+				return
+			case <-s.closeCh:
+				return
+			case <-s.streamLeft:
+				// Stop waiting once the last reader has gone
+				if s.streamReaders.Load() == 0 {
+					s.deliverDropOldest(msg)
+					return
+				}
+			}
+		}
+	}
+}
+
+func (s *Session) deliverDropOldest(msg types.IncomingMessage) {
+	for {
+		select {
+		case s.msgCh <- msg:
+			return
+		default:
+		}
+		select {
+		case <-s.msgCh:
+		default:
+		}
+	}
+}
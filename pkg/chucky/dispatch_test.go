@@ -0,0 +1,130 @@
+package chucky
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/chucky-cloud/chucky-sdk-go/pkg/types"
+)
+
+func textOf(t *testing.T, msg types.IncomingMessage) string {
+	t.Helper()
+	m, ok := msg.(*types.SDKAssistantMessage)
+	if !ok {
+		return string(msg.GetType())
+	}
+	return m.Message.Text()
+}
+
+func TestContentQueueOverflow(t *testing.T) {
+	msg := func(i int) types.IncomingMessage {
+		return &types.GenericMessage{RawType: types.MessageType(fmt.Sprint(i))}
+	}
+
+	tests := []struct {
+		policy   types.OverflowPolicy
+		accepted []bool
+		want     []string
+	}{
+		{types.OverflowDropOldest, []bool{true, true, true}, []string{"1", "2"}},
+		{types.OverflowError, []bool{true, true, false}, []string{"0", "1"}},
+		{types.OverflowBlock, []bool{true, true, true}, []string{"0", "1", "2"}},
+	}
+	for _, tt := range tests {
+		t.Run(string(tt.policy), func(t *testing.T) {
+			q := newContentQueue(2)
+			for i, want := range tt.accepted {
+				if got := q.push(msg(i), tt.policy); got != want {
+					t.Errorf("push(%d) = %v, want %v", i, got, want)
+				}
+			}
+			var got []string
+			for m, ok := q.pop(); ok; m, ok = q.pop() {
+				got = append(got, string(m.GetType()))
+			}
+			if fmt.Sprint(got) != fmt.Sprint(tt.want) {
+				t.Errorf("queue = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestStalledSubscriberDoesNotBlockToolCalls(t *testing.T) {
+	const messages = 50
+
+	ran := make(chan struct{}, 1)
+	echo := types.ToolDefinition{
+		Name:        "echo",
+		InputSchema: types.ToolInputSchema{Type: "object"},
+		Handler: func(ctx context.Context, input map[string]any) (*types.ToolResult, error) {
+			ran <- struct{}{}
+			return &types.ToolResult{Content: []any{types.TextToolContent{Type: "text", Text: "ok"}}}, nil
+		},
+	}
+
+	f := newFakeServer(t)
+	session, conn := connectSession(t, f, f.client(types.ClientOptions{}), &types.SessionOptions{
+		BaseOptions: types.BaseOptions{
+			McpServers: []types.McpServerDefinition{types.McpClientToolsServer{Name: "tools", Tools: []types.ToolDefinition{echo}}},
+		},
+		MessageBufferSize: 4,
+	})
+
+	// A subscriber that never reads, with the default OverflowBlock
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	_ = session.Subscribe(ctx, nil, &SubscribeOptions{BufferSize: 1})
+
+	for i := 0; i < messages; i++ {
+		conn.sendRaw(assistantText(fmt.Sprint(i)))
+	}
+	conn.send(types.ToolCallEnvelope{
+		Type:    types.MessageTypeToolCall,
+		Payload: types.ToolCallPayload{CallID: "call_1", ToolName: "echo", Input: map[string]any{}},
+	})
+
+	select {
+	case <-ran:
+	case <-time.After(5 * time.Second):
+		t.Fatal("tool call did not run behind a stalled subscriber")
+	}
+	for {
+		msg := conn.recv()
+		if msg["type"] == string(types.MessageTypeToolResult) {
+			break
+		}
+	}
+}
+
+func TestStreamReceivesLatestMessagesWithoutReader(t *testing.T) {
+	const sent, buffer = 30, 10
+
+	f := newFakeServer(t)
+	session, conn := connectSession(t, f, f.client(types.ClientOptions{}), &types.SessionOptions{MessageBufferSize: buffer})
+
+	// The subscriber tells when everything has been dispatched
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	sub := session.Subscribe(ctx, nil, &SubscribeOptions{BufferSize: sent + 1})
+
+	for i := 0; i < sent; i++ {
+		conn.sendRaw(assistantText(fmt.Sprint(i)))
+	}
+	conn.sendRaw(`{"type":"result","subtype":"success"}`)
+	for i := 0; i <= sent; i++ {
+		if _, ok := <-sub; !ok {
+			t.Fatalf("subscription ended after %d messages", i)
+		}
+	}
+
+	var got []string
+	for msg := range session.Stream(ctx) {
+		got = append(got, textOf(t, msg))
+	}
+	want := []string{"21", "22", "23", "24", "25", "26", "27", "28", "29", "result"}
+	if fmt.Sprint(got) != fmt.Sprint(want) {
+		t.Errorf("Stream = %v, want %v", got, want)
+	}
+}
@@ -0,0 +1,161 @@
+package chucky
+
+import (
+	"fmt"
+
+	"github.com/chucky-cloud/chucky-sdk-go/pkg/types"
+)
+
+// AddTools registers client-side tools on the named MCP server, creating the
+// server if needed. Tools with the same name are replaced; a name already
+// defined by another server is a ValidationError. If the session is
+// connected the server is notified of the new tool list. It is safe to call
+// while a turn is in progress.
+func (s *Session) AddTools(server string, tools ...types.ToolDefinition) error {
+	if server == "" {
+		return types.ValidationError("server name is required")
+	}
+	for _, tool := range tools {
+		if tool.Name == "" {
+			return types.ValidationError("tool name is required")
+		}
+	}
+
+	// Keep updates in the order of the changes they describe
+	s.updateMu.Lock()
+	defer s.updateMu.Unlock()
+
+	s.toolsMu.Lock()
+	for _, tool := range tools {
+		if owner := s.toolOwner(tool.Name); owner != "" && owner != server {
+			s.toolsMu.Unlock()
+			return types.ValidationError(fmt.Sprintf("tool %q is already defined in server %q", tool.Name, owner))
+		}
+	}
+	idx := s.findClientToolsServer(server)
+	var srv types.McpClientToolsServer
+	if idx >= 0 {
+		srv = s.options.McpServers[idx].(types.McpClientToolsServer)
+	} else {
+		srv = types.McpClientToolsServer{Name: server, Version: "1.0.0"}
+	}
+
+	updated := make([]types.ToolDefinition, 0, len(srv.Tools)+len(tools))
+	for _, existing := range srv.Tools {
+		if !containsTool(tools, existing.Name) {
+			updated = append(updated, existing)
+		}
+	}
+	updated = append(updated, tools...)
+	srv.Tools = updated
+
+	if idx >= 0 {
+		s.options.McpServers[idx] = srv
+	} else {
+		s.options.McpServers = append(s.options.McpServers, srv)
+	}
+
+	for _, tool := range tools {
+		if tool.Handler != nil {
+			s.toolHandlers[tool.Name] = s.wrapToolHandler(srv, tool)
+		} else {
+			delete(s.toolHandlers, tool.Name)
+		}
+	}
+	serverMap := s.mcpServerToMap(srv)
+	initSent := s.initSent
+	s.toolsMu.Unlock()
+
+	if !initSent {
+		return nil
+	}
+	return s.notifyMcpServerUpdate(serverMap)
+}
+
+// RemoveTool removes a client-side tool by name. If the session is connected
+// the server is notified of the new tool list. It is safe to call while a
+// turn is in progress; calls that arrive after removal get a not-found result.
+func (s *Session) RemoveTool(name string) error {
+	s.updateMu.Lock()
+	defer s.updateMu.Unlock()
+
+	s.toolsMu.Lock()
+	var serverMap map[string]any
+	found := false
+	for i, server := range s.options.McpServers {
+		srv, ok := server.(types.McpClientToolsServer)
+		if !ok || !containsTool(srv.Tools, name) {
+			continue
+		}
+
+		remaining := make([]types.ToolDefinition, 0, len(srv.Tools)-1)
+		for _, tool := range srv.Tools {
+			if tool.Name != name {
+				remaining = append(remaining, tool)
+			}
+		}
+		srv.Tools = remaining
+		s.options.McpServers[i] = srv
+		serverMap = s.mcpServerToMap(srv)
+		found = true
+		break
+	}
+	delete(s.toolHandlers, name)
+	initSent := s.initSent
+	s.toolsMu.Unlock()
+
+	if !found {
+		return types.ValidationError("tool not found: " + name)
+	}
+	if !initSent {
+		return nil
+	}
+	return s.notifyMcpServerUpdate(serverMap)
+}
+
+// notifyMcpServerUpdate sends the updated server definition. It is only
+// needed once the init has been sent; before that sendInit picks it up.
+// Callers hold updateMu from the change until the update is sent.
+func (s *Session) notifyMcpServerUpdate(serverMap map[string]any) error {
+	return s.sendControl(types.ControlActionUpdateMcpServer, map[string]any{
+		"server": serverMap,
+	})
+}
+
+// findClientToolsServer returns the index of the named client tools server,
+// or -1. Callers must hold toolsMu.
+func (s *Session) findClientToolsServer(name string) int {
+	for i, server := range s.options.McpServers {
+		if srv, ok := server.(types.McpClientToolsServer); ok && srv.Name == name {
+			return i
+		}
+	}
+	return -1
+}
+
+// toolOwner returns the name of the client tools or local MCP server that
+// defines a tool, or "". Callers must hold toolsMu.
+func (s *Session) toolOwner(name string) string {
+	for _, server := range s.options.McpServers {
+		if srv, ok := server.(types.McpClientToolsServer); ok && containsTool(srv.Tools, name) {
+			return srv.Name
+		}
+	}
+	for serverName, local := range s.localMcpServers {
+		for _, tool := range local.toolList() {
+			if tool.Name == name {
+				return serverName
+			}
+		}
+	}
+	return ""
+}
+
+func containsTool(tools []types.ToolDefinition, name string) bool {
+	for _, tool := range tools {
+		if tool.Name == name {
+			return true
+		}
+	}
+	return false
+}
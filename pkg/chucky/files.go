@@ -0,0 +1,489 @@
+package chucky
+
+import (
+	"archive/tar"
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"io"
+	"io/fs"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+
+	"github.com/chucky-cloud/chucky-sdk-go/pkg/types"
+)
+
+// DefaultChunkSize is the default number of file bytes per control message.
+const DefaultChunkSize = 256 << 10
+
+// TransferOptions configures a file transfer.
+type TransferOptions struct {
+	// ChunkSize is the number of bytes sent per control message, before
+	// base64 encoding. Default: DefaultChunkSize.
+	ChunkSize int
+
+	// Mode is the permission of uploaded files. Default: 0644.
+	Mode fs.FileMode
+
+	// OnProgress is called after each chunk.
+	OnProgress func(progress types.TransferProgress)
+}
+
+func (o *TransferOptions) withDefaults() TransferOptions {
+	opts := TransferOptions{}
+	if o != nil {
+		opts = *o
+	}
+	if opts.ChunkSize <= 0 {
+		opts.ChunkSize = DefaultChunkSize
+	}
+	if opts.Mode == 0 {
+		opts.Mode = 0o644
+	}
+	return opts
+}
+
+// UploadFile writes the content of r to remotePath in the session sandbox,
+// connecting first if needed. The content is sent in chunks over the control
+// channel; each chunk and the whole file carry a SHA-256 checksum that the
+// server verifies.
+func (s *Session) UploadFile(ctx context.Context, remotePath string, r io.Reader, opts *TransferOptions) (*types.FileInfo, error) {
+	o := opts.withDefaults()
+	return s.upload(ctx, map[string]any{
+		"path": remotePath,
+		"mode": uint32(o.Mode.Perm()),
+	}, r, readerSize(r), o)
+}
+
+// UploadDir uploads the files under localDir to remoteDir as a tar stream,
+// which the server extracts. Files matched by .gitignore files in localDir
+// and its subdirectories are skipped, as is the .git directory. Progress
+// reports count tar bytes.
+func (s *Session) UploadDir(ctx context.Context, localDir, remoteDir string, opts *TransferOptions) (*types.FileInfo, error) {
+	info, err := os.Stat(localDir)
+	if err != nil {
+		return nil, types.ValidationError("cannot read upload directory").Wrap(err)
+	}
+	if !info.IsDir() {
+		return nil, types.ValidationError("not a directory: " + localDir)
+	}
+
+	pr, pw := io.Pipe()
+	s.goTracked(func() {
+		pw.CloseWithError(writeTar(pw, localDir))
+	})
+	defer pr.Close()
+
+	return s.upload(ctx, map[string]any{
+		"path":   remoteDir,
+		"format": "tar",
+	}, pr, -1, opts.withDefaults())
+}
+
+// upload runs the chunked upload protocol: start, chunks, then a commit
+// carrying the total size and checksum. The upload is aborted on failure.
+func (s *Session) upload(ctx context.Context, start map[string]any, r io.Reader, total int64, o TransferOptions) (*types.FileInfo, error) {
+	if err := s.ensureConnected(ctx); err != nil {
+		return nil, err
+	}
+
+	remotePath, _ := start["path"].(string)
+	start["size"] = total
+	start["chunkSize"] = o.ChunkSize
+	resp, err := s.controlRequest(ctx, types.ControlActionFileUploadStart, start)
+	if err != nil {
+		return nil, err
+	}
+	var started struct {
+		UploadID string `json:"uploadId"`
+	}
+	if err := decodeData(resp.Data, &started); err != nil || started.UploadID == "" {
+		return nil, types.ProtocolError("invalid file_upload_start response")
+	}
+
+	abort := func(err error) error {
+		_ = s.sendControl(types.ControlActionFileUploadAbort, map[string]any{"uploadId": started.UploadID})
+		return err
+	}
+
+	sum := sha256.New()
+	buf := make([]byte, o.ChunkSize)
+	progress := types.TransferProgress{Direction: types.TransferUpload, Path: remotePath, Total: total}
+	for {
+		n, readErr := io.ReadFull(r, buf)
+		if n > 0 {
+			chunk := buf[:n]
+			sum.Write(chunk)
+			_, err := s.controlRequest(ctx, types.ControlActionFileUploadChunk, map[string]any{
+				"uploadId": started.UploadID,
+				"index":    progress.Chunks,
+				"offset":   progress.Bytes,
+				"data":     base64.StdEncoding.EncodeToString(chunk),
+				"sha256":   hexDigest(sha256.Sum256(chunk)),
+			})
+			if err != nil {
+				return nil, abort(err)
+			}
+			progress.Bytes += int64(n)
+			progress.Chunks++
+			if o.OnProgress != nil {
+				o.OnProgress(progress)
+			}
+		}
+		if readErr == io.EOF || readErr == io.ErrUnexpectedEOF {
+			break
+		}
+		if readErr != nil {
+			return nil, abort(types.SessionError("upload of " + remotePath + " failed reading the source").Wrap(readErr))
+		}
+	}
+
+	digest := hex.EncodeToString(sum.Sum(nil))
+	resp, err = s.controlRequest(ctx, types.ControlActionFileUploadCommit, map[string]any{
+		"uploadId": started.UploadID,
+		"size":     progress.Bytes,
+		"sha256":   digest,
+	})
+	if err != nil {
+		return nil, abort(err)
+	}
+
+	info := types.FileInfo{Path: remotePath, Size: progress.Bytes, SHA256: digest}
+	if resp.Data != nil {
+		if err := decodeData(resp.Data, &info); err != nil {
+			return nil, types.ProtocolError("invalid file_upload_commit response").Wrap(err)
+		}
+	}
+	if info.SHA256 != digest {
+		return nil, checksumError(remotePath, digest, info.SHA256)
+	}
+	return &info, nil
+}
+
+// DownloadFile copies remotePath from the session sandbox to w, connecting
+// first if needed. Chunks are requested one at a time; each chunk and the
+// whole file are verified against the server's SHA-256 checksums.
+func (s *Session) DownloadFile(ctx context.Context, remotePath string, w io.Writer, opts *TransferOptions) (*types.FileInfo, error) {
+	o := opts.withDefaults()
+	if err := s.ensureConnected(ctx); err != nil {
+		return nil, err
+	}
+
+	resp, err := s.controlRequest(ctx, types.ControlActionFileDownloadStart, map[string]any{
+		"path":      remotePath,
+		"chunkSize": o.ChunkSize,
+	})
+	if err != nil {
+		return nil, err
+	}
+	var started struct {
+		DownloadID string `json:"downloadId"`
+		types.FileInfo
+	}
+	if err := decodeData(resp.Data, &started); err != nil || started.DownloadID == "" {
+		return nil, types.ProtocolError("invalid file_download_start response")
+	}
+	if started.Path == "" {
+		started.Path = remotePath
+	}
+
+	sum := sha256.New()
+	progress := types.TransferProgress{Direction: types.TransferDownload, Path: remotePath, Total: started.Size}
+	for {
+		resp, err := s.controlRequest(ctx, types.ControlActionFileDownloadChunk, map[string]any{
+			"downloadId": started.DownloadID,
+			"index":      progress.Chunks,
+		})
+		if err != nil {
+			return nil, err
+		}
+		var chunk struct {
+			Data   string `json:"data"`
+			SHA256 string `json:"sha256"`
+			EOF    bool   `json:"eof"`
+		}
+		if err := decodeData(resp.Data, &chunk); err != nil {
+			return nil, types.ProtocolError("invalid file_download_chunk response").Wrap(err)
+		}
+		data, err := base64.StdEncoding.DecodeString(chunk.Data)
+		if err != nil {
+			return nil, types.ProtocolError("invalid file_download_chunk data").Wrap(err)
+		}
+		if chunk.SHA256 != "" {
+			if got := hexDigest(sha256.Sum256(data)); got != chunk.SHA256 {
+				return nil, checksumError(remotePath, got, chunk.SHA256).WithDetails(map[string]any{"chunk": progress.Chunks})
+			}
+		}
+
+		if len(data) > 0 {
+			sum.Write(data)
+			if _, err := w.Write(data); err != nil {
+				return nil, types.SessionError("download of " + remotePath + " failed writing the destination").Wrap(err)
+			}
+			progress.Bytes += int64(len(data))
+			progress.Chunks++
+			if o.OnProgress != nil {
+				o.OnProgress(progress)
+			}
+		}
+		if chunk.EOF {
+			break
+		}
+		if len(data) == 0 {
+			return nil, types.ProtocolError("empty file_download_chunk before end of file")
+		}
+	}
+
+	info := started.FileInfo
+	digest := hex.EncodeToString(sum.Sum(nil))
+	if info.SHA256 != "" && info.SHA256 != digest {
+		return nil, checksumError(remotePath, digest, info.SHA256)
+	}
+	info.SHA256 = digest
+	info.Size = progress.Bytes
+	return &info, nil
+}
+
+// ListFiles lists the entries of dir in the session sandbox, connecting
+// first if needed.
+func (s *Session) ListFiles(ctx context.Context, dir string) ([]types.FileInfo, error) {
+	if err := s.ensureConnected(ctx); err != nil {
+		return nil, err
+	}
+	resp, err := s.controlRequest(ctx, types.ControlActionListFiles, map[string]any{"path": dir})
+	if err != nil {
+		return nil, err
+	}
+	var listing struct {
+		Files []types.FileInfo `json:"files"`
+	}
+	if err := decodeData(resp.Data, &listing); err != nil {
+		return nil, types.ProtocolError("invalid list_files response").Wrap(err)
+	}
+	return listing.Files, nil
+}
+
+// ensureConnected connects the session if it is not connected yet.
+func (s *Session) ensureConnected(ctx context.Context) error {
+	s.connectedMu.RLock()
+	connected := s.connected
+	s.connectedMu.RUnlock()
+	if connected {
+		return nil
+	}
+	return s.Connect(ctx)
+}
+
+// readerSize returns the number of bytes left in r, or -1 if unknown.
+func readerSize(r io.Reader) int64 {
+	switch v := r.(type) {
+	case *bytes.Reader:
+		return int64(v.Len())
+	case *bytes.Buffer:
+		return int64(v.Len())
+	case *strings.Reader:
+		return int64(v.Len())
+	case *os.File:
+		info, err := v.Stat()
+		if err != nil || !info.Mode().IsRegular() {
+			return -1
+		}
+		offset, err := v.Seek(0, io.SeekCurrent)
+		if err != nil {
+			return -1
+		}
+		return info.Size() - offset
+	}
+	return -1
+}
+
+func hexDigest(sum [sha256.Size]byte) string {
+	return hex.EncodeToString(sum[:])
+}
+
+func checksumError(remotePath, got, want string) *types.ChuckyError {
+	return types.ProtocolError("checksum mismatch for " + remotePath).WithDetails(map[string]any{
+		"sha256":         got,
+		"expectedSha256": want,
+	})
+}
+
+// writeTar writes the files under dir to w as a tar archive, skipping
+// .git and paths matched by .gitignore files.
+func writeTar(w io.Writer, dir string) error {
+	tw := tar.NewWriter(w)
+	ignore := &gitignore{}
+
+	err := filepath.WalkDir(dir, func(p string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		rel, err := filepath.Rel(dir, p)
+		if err != nil {
+			return err
+		}
+		rel = filepath.ToSlash(rel)
+		if rel == "." {
+			return ignore.load(dir, "")
+		}
+
+		if d.Name() == ".git" || ignore.match(rel, d.IsDir()) {
+			if d.IsDir() {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+
+		info, err := d.Info()
+		if err != nil {
+			return err
+		}
+		link := ""
+		if info.Mode()&fs.ModeSymlink != 0 {
+			if link, err = os.Readlink(p); err != nil {
+				return err
+			}
+		} else if !info.Mode().IsRegular() && !info.IsDir() {
+			return nil // Sockets, devices, pipes
+		}
+
+		header, err := tar.FileInfoHeader(info, link)
+		if err != nil {
+			return err
+		}
+		header.Name = rel
+		if info.IsDir() {
+			header.Name += "/"
+		}
+		if err := tw.WriteHeader(header); err != nil {
+			return err
+		}
+
+		if info.IsDir() {
+			return ignore.load(p, rel)
+		}
+		if !info.Mode().IsRegular() {
+			return nil
+		}
+		f, err := os.Open(p)
+		if err != nil {
+			return err
+		}
+		defer f.Close()
+		_, err = io.Copy(tw, f)
+		return err
+	})
+	if err != nil {
+		return err
+	}
+	return tw.Close()
+}
+
+// gitignore matches paths against the .gitignore files found so far. It
+// supports comments, negation (!), directory-only patterns (trailing /),
+// anchored patterns (containing /), and the *, ? and ** wildcards.
+type gitignore struct {
+	rules []ignoreRule
+}
+
+type ignoreRule struct {
+	base     string // Directory of the .gitignore, relative to the root
+	pattern  string
+	negate   bool
+	dirOnly  bool
+	anchored bool
+}
+
+// load reads the .gitignore in dir, whose path relative to the root is rel.
+func (g *gitignore) load(dir, rel string) error {
+	data, err := os.ReadFile(filepath.Join(dir, ".gitignore"))
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+
+	for _, line := range strings.Split(string(data), "\n") {
+		line = strings.TrimRight(line, "\r")
+		if !strings.HasSuffix(line, `\ `) {
+			line = strings.TrimRight(line, " ")
+		}
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+
+		rule := ignoreRule{base: rel}
+		if strings.HasPrefix(line, "!") {
+			rule.negate = true
+			line = line[1:]
+		}
+		line = strings.TrimPrefix(line, `\`)
+		if strings.HasSuffix(line, "/") {
+			rule.dirOnly = true
+			line = strings.TrimSuffix(line, "/")
+		}
+		rule.anchored = strings.Contains(line, "/")
+		rule.pattern = strings.TrimPrefix(line, "/")
+		if rule.pattern != "" {
+			g.rules = append(g.rules, rule)
+		}
+	}
+	return nil
+}
+
+// match reports whether rel is ignored. The last matching rule wins.
+func (g *gitignore) match(rel string, isDir bool) bool {
+	ignored := false
+	for _, rule := range g.rules {
+		if rule.dirOnly && !isDir {
+			continue
+		}
+		p := rel
+		if rule.base != "" {
+			if !strings.HasPrefix(rel, rule.base+"/") {
+				continue
+			}
+			p = strings.TrimPrefix(rel, rule.base+"/")
+		}
+
+		var matched bool
+		if rule.anchored {
+			matched = globMatch(strings.Split(rule.pattern, "/"), strings.Split(p, "/"))
+		} else {
+			matched, _ = path.Match(rule.pattern, path.Base(p))
+		}
+		if matched {
+			ignored = !rule.negate
+		}
+	}
+	return ignored
+}
+
+// globMatch matches path segments against pattern segments, where "**"
+// matches any number of segments.
+func globMatch(pattern, segments []string) bool {
+	if len(pattern) == 0 {
+		return len(segments) == 0
+	}
+	if pattern[0] == "**" {
+		for i := 0; i <= len(segments); i++ {
+			if globMatch(pattern[1:], segments[i:]) {
+				return true
+			}
+		}
+		return false
+	}
+	if len(segments) == 0 {
+		return false
+	}
+	if ok, _ := path.Match(pattern[0], segments[0]); !ok {
+		return false
+	}
+	return globMatch(pattern[1:], segments[1:])
+}
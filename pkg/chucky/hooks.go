@@ -0,0 +1,198 @@
+package chucky
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/chucky-cloud/chucky-sdk-go/pkg/types"
+)
+
+// hookRegistration is a HookMatcher registered under a callback ID.
+type hookRegistration struct {
+	event   types.HookEvent
+	matcher types.HookMatcher
+}
+
+// registerHooks assigns callback IDs to the configured hook matchers.
+func (s *Session) registerHooks() {
+	s.hooks = make(map[string]hookRegistration)
+
+	events := make([]string, 0, len(s.options.Hooks))
+	for event := range s.options.Hooks {
+		events = append(events, string(event))
+	}
+	sort.Strings(events)
+
+	for _, event := range events {
+		for i, matcher := range s.options.Hooks[types.HookEvent(event)] {
+			if len(matcher.Hooks) == 0 {
+				continue
+			}
+			id := fmt.Sprintf("hook_%s_%d", event, i)
+			s.hooks[id] = hookRegistration{
+				event:   types.HookEvent(event),
+				matcher: matcher,
+			}
+		}
+	}
+}
+
+// hooksToMap converts the registered hooks into the init payload format.
+func (s *Session) hooksToMap() any {
+	if len(s.hooks) == 0 {
+		return nil
+	}
+
+	ids := make([]string, 0, len(s.hooks))
+	for id := range s.hooks {
+		ids = append(ids, id)
+	}
+	sort.Strings(ids)
+
+	byEvent := make(map[string]any)
+	for _, id := range ids {
+		reg := s.hooks[id]
+		entry := map[string]any{
+			"matcher":         reg.matcher.Matcher,
+			"hookCallbackIds": []string{id},
+			"timeout":         int(hookTimeout(reg.matcher).Seconds()),
+		}
+		list, _ := byEvent[string(reg.event)].([]map[string]any)
+		byEvent[string(reg.event)] = append(list, entry)
+	}
+	return byEvent
+}
+
+// handleHookCallback runs a hook requested by the server and sends back its output.
+func (s *Session) handleHookCallback(data any) {
+	var req types.HookCallbackRequest
+	if err := decodeData(data, &req); err != nil {
+		s.handleError(types.ProtocolError("invalid hook_callback request").Wrap(err))
+		return
+	}
+
+	resp := types.HookCallbackResponse{RequestID: req.RequestID}
+
+	output, err := s.runHook(req)
+	if err != nil {
+		s.handleError(err)
+		resp.Error = err.Error()
+	} else {
+		resp.Output = &output
+	}
+
+	if err := s.sendControl(types.ControlActionHookResponse, resp); err != nil {
+		s.handleError(err)
+	}
+}
+
+func (s *Session) runHook(req types.HookCallbackRequest) (types.HookOutput, error) {
+	reg, ok := s.hooks[req.CallbackID]
+	if !ok {
+		return types.HookOutput{}, types.HookError(req.Input.Event, "unknown hook callback: "+req.CallbackID)
+	}
+	if req.Input.Event == "" {
+		req.Input.Event = reg.event
+	}
+	if req.Input.SessionID == "" {
+		req.Input.SessionID = s.ID()
+	}
+
+	parent, cancel := s.contextUntilClose()
+	defer cancel()
+	ctx, cancelTimeout := context.WithTimeout(parent, hookTimeout(reg.matcher))
+	defer cancelTimeout()
+
+	type hookResult struct {
+		output types.HookOutput
+		err    error
+	}
+	// The hooks run on a tracked goroutine so Close waits for them, and
+	// receive ctx, which is cancelled on timeout and close. A result that
+	// arrives after the timeout is dropped; done is buffered so the
+	// goroutine never blocks.
+	done := make(chan hookResult, 1)
+
+	s.goTracked(func() {
+		defer func() {
+			if r := recover(); r != nil {
+				done <- hookResult{err: fmt.Errorf("panic: %v", r)}
+			}
+		}()
+		output, err := mergeHookOutputs(ctx, reg.matcher.Hooks, req.Input)
+		done <- hookResult{output: output, err: err}
+	})
+
+	select {
+	case res := <-done:
+		if res.err != nil {
+			if errors.Is(res.err, context.DeadlineExceeded) {
+				return types.HookOutput{}, hookTimeoutError(reg, req)
+			}
+			return types.HookOutput{}, types.HookError(reg.event, "hook failed").
+				WithDetails(hookErrorDetails(reg, req)).Wrap(res.err)
+		}
+		return res.output, nil
+	case <-ctx.Done():
+		if errors.Is(ctx.Err(), context.DeadlineExceeded) {
+			return types.HookOutput{}, hookTimeoutError(reg, req)
+		}
+		return types.HookOutput{}, types.HookError(reg.event, "hook cancelled").
+			WithDetails(hookErrorDetails(reg, req)).Wrap(ctx.Err())
+	}
+}
+
+// mergeHookOutputs runs hooks in order. The first block wins and additional
+// context from every hook that ran is concatenated.
+func mergeHookOutputs(ctx context.Context, hooks []types.HookCallback, input types.HookInput) (types.HookOutput, error) {
+	merged := types.ContinueHook()
+	var contexts []string
+
+	for _, hook := range hooks {
+		output, err := hook(ctx, input)
+		if err != nil {
+			return types.HookOutput{}, err
+		}
+		if output.AdditionalContext != "" {
+			contexts = append(contexts, output.AdditionalContext)
+		}
+		if output.UpdatedInput != nil {
+			merged.UpdatedInput = output.UpdatedInput
+			input.ToolInput = output.UpdatedInput
+		}
+		if output.Decision == types.HookDecisionBlock {
+			merged.Decision = types.HookDecisionBlock
+			merged.Reason = output.Reason
+			break
+		}
+	}
+
+	merged.AdditionalContext = strings.Join(contexts, "\n")
+	return merged, nil
+}
+
+func hookTimeout(matcher types.HookMatcher) time.Duration {
+	if matcher.Timeout > 0 {
+		return matcher.Timeout
+	}
+	return types.DefaultHookTimeout
+}
+
+func hookTimeoutError(reg hookRegistration, req types.HookCallbackRequest) *types.ChuckyError {
+	details := hookErrorDetails(reg, req)
+	details["timeout"] = hookTimeout(reg.matcher).String()
+	return types.TimeoutError("hook timed out").WithDetails(details).Wrap(context.DeadlineExceeded)
+}
+
+func hookErrorDetails(reg hookRegistration, req types.HookCallbackRequest) map[string]any {
+	return map[string]any{
+		"event":      string(reg.event),
+		"matcher":    reg.matcher.Matcher,
+		"callbackId": req.CallbackID,
+		"toolName":   req.Input.ToolName,
+	}
+}
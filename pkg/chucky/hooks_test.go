@@ -0,0 +1,65 @@
+package chucky
+
+import (
+	"context"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/chucky-cloud/chucky-sdk-go/pkg/types"
+)
+
+func TestHookTimeoutCancelsAndTracksCallback(t *testing.T) {
+	release := make(chan struct{})
+	var cancelled, finished atomic.Bool
+	slow := func(ctx context.Context, input types.HookInput) (types.HookOutput, error) {
+		<-ctx.Done()
+		cancelled.Store(true)
+		<-release // Ignores cancellation for a while
+		finished.Store(true)
+		return types.ContinueHook(), nil
+	}
+
+	f := newFakeServer(t)
+	session, conn := connectSession(t, f, f.client(types.ClientOptions{}), &types.SessionOptions{
+		Hooks: map[types.HookEvent][]types.HookMatcher{
+			types.HookEventPreToolUse: {{Hooks: []types.HookCallback{slow}, Timeout: 50 * time.Millisecond}},
+		},
+	})
+
+	conn.send(types.ControlEnvelope{
+		Type: types.MessageTypeControl,
+		Payload: types.ControlPayload{
+			Action: types.ControlActionHookCallback,
+			Data:   map[string]any{"requestId": "hook-1", "callbackId": "hook_PreToolUse_0", "input": map[string]any{}},
+		},
+	})
+	resp := conn.recvControl(types.ControlActionHookResponse)
+	if resp["requestId"] != "hook-1" || resp["error"] == nil || resp["output"] != nil {
+		t.Fatalf("response = %v, want a timeout error", resp)
+	}
+	if !cancelled.Load() {
+		t.Error("hook context not cancelled on timeout")
+	}
+
+	closed := make(chan struct{})
+	go func() {
+		_ = session.CloseGracefully(context.Background())
+		close(closed)
+	}()
+	select {
+	case <-closed:
+		t.Fatal("CloseGracefully returned while a hook was running")
+	case <-time.After(50 * time.Millisecond):
+	}
+
+	close(release)
+	select {
+	case <-closed:
+	case <-time.After(5 * time.Second):
+		t.Fatal("CloseGracefully did not return after the hook finished")
+	}
+	if !finished.Load() {
+		t.Error("session closed before the hook returned")
+	}
+}
@@ -0,0 +1,51 @@
+package chucky
+
+import (
+	"context"
+
+	"github.com/chucky-cloud/chucky-sdk-go/pkg/types"
+)
+
+// Info returns the capabilities the server reported in system:init: tools,
+// MCP server status, slash commands, agents, model and working directory.
+// It returns nil until system:init arrives, which may be after the first
+// message is sent.
+func (s *Session) Info() *types.SystemInitData {
+	s.idMu.RLock()
+	defer s.idMu.RUnlock()
+	return s.info
+}
+
+// WaitInfo blocks until system:init has arrived and returns its data.
+func (s *Session) WaitInfo(ctx context.Context) (*types.SystemInitData, error) {
+	select {
+	case <-s.infoCh:
+		return s.Info(), nil
+	case <-ctx.Done():
+		return nil, types.TimeoutError("system:init not received").Wrap(ctx.Err())
+	case <-s.closeCh:
+		return nil, types.SessionError("session closed before system:init")
+	}
+}
+
+// RequireCapabilities waits for system:init and returns an error listing the
+// tools that are missing and the MCP servers that did not connect. Call it
+// before relying on those tools so a broken setup fails fast.
+func (s *Session) RequireCapabilities(ctx context.Context, tools []string, mcpServers []string) error {
+	info, err := s.WaitInfo(ctx)
+	if err != nil {
+		return err
+	}
+	return info.Require(tools, mcpServers)
+}
+
+func (s *Session) setInfo(info *types.SystemInitData) {
+	s.idMu.Lock()
+	first := s.info == nil
+	s.info = info
+	s.idMu.Unlock()
+
+	if first {
+		close(s.infoCh)
+	}
+}
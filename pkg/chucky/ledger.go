@@ -0,0 +1,449 @@
+package chucky
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"io"
+	"sort"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/chucky-cloud/chucky-sdk-go/pkg/types"
+)
+
+// CostEntry records the cost and usage of one completed turn. TotalCostUsd
+// and NumTurns are what the turn added to the session's running totals.
+type CostEntry struct {
+	Time         time.Time   `json:"time"`
+	SessionID    string      `json:"sessionId"`
+	UserID       string      `json:"userId,omitempty"`
+	Model        types.Model `json:"model,omitempty"`
+	TotalCostUsd float64     `json:"totalCostUsd"`
+	Usage        types.Usage `json:"usage"`
+	NumTurns     int         `json:"numTurns"`
+	IsError      bool        `json:"isError,omitempty"`
+}
+
+// CostFilter selects ledger entries. Zero fields match everything.
+type CostFilter struct {
+	SessionID string
+	UserID    string
+	Model     types.Model
+	Since     time.Time // Inclusive
+	Until     time.Time // Exclusive
+}
+
+// CostSummary aggregates a set of ledger entries.
+type CostSummary struct {
+	TotalCostUsd float64     `json:"totalCostUsd"`
+	Usage        types.Usage `json:"usage"`
+	NumTurns     int         `json:"numTurns"`
+	Entries      int         `json:"entries"`
+}
+
+// CostGroupBy selects the dimension used by CostLedger.SummarizeBy.
+type CostGroupBy string
+
+const (
+	CostGroupBySession CostGroupBy = "session"
+	CostGroupByUser    CostGroupBy = "user"
+	CostGroupByModel   CostGroupBy = "model"
+)
+
+// DefaultLedgerRetention is the number of entries a new CostLedger retains.
+const DefaultLedgerRetention = 10000
+
+// CostLedger aggregates cost and token usage across sessions and users.
+// It is safe for concurrent use.
+//
+// The most recent entries are retained for Entries, the summaries and the
+// exports; older ones are dropped (see SetRetention). Budget guards use
+// running totals, which are exact regardless of retention.
+type CostLedger struct {
+	mu         sync.RWMutex
+	entries    []CostEntry // Ring buffer of retained entries
+	head       int         // Index of the oldest entry once the buffer is full
+	maxEntries int         // Negative: unlimited
+
+	sessions map[string]float64 // Spend per open session
+	windows  []*windowTotals    // Spend per budget window, one per guard window
+}
+
+// windowTotals is the running spend of the current budget window.
+type windowTotals struct {
+	window types.BudgetWindow
+	anchor time.Time
+	start  time.Time
+	total  float64
+	users  map[string]float64
+}
+
+// NewCostLedger creates an empty ledger retaining DefaultLedgerRetention
+// entries.
+func NewCostLedger() *CostLedger {
+	return &CostLedger{
+		maxEntries: DefaultLedgerRetention,
+		sessions:   make(map[string]float64),
+	}
+}
+
+// SetRetention sets how many of the most recent entries are retained. Zero
+// turns retention off so that only running totals are kept; a negative
+// value retains every entry. Entries beyond the new limit are dropped.
+func (l *CostLedger) SetRetention(maxEntries int) *CostLedger {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	entries := l.ordered()
+	if maxEntries >= 0 && len(entries) > maxEntries {
+		entries = entries[len(entries)-maxEntries:]
+	}
+	l.entries = append([]CostEntry(nil), entries...)
+	l.head = 0
+	l.maxEntries = maxEntries
+	return l
+}
+
+// Record adds an entry to the ledger.
+func (l *CostLedger) Record(entry CostEntry) {
+	if entry.Time.IsZero() {
+		entry.Time = time.Now()
+	}
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	switch {
+	case l.maxEntries < 0 || len(l.entries) < l.maxEntries:
+		l.entries = append(l.entries, entry)
+	case l.maxEntries > 0:
+		l.entries[l.head] = entry
+		l.head = (l.head + 1) % l.maxEntries
+	}
+
+	if entry.SessionID != "" {
+		l.sessions[entry.SessionID] += entry.TotalCostUsd
+	}
+	for _, w := range l.windows {
+		w.add(entry)
+	}
+}
+
+// Entries returns the retained entries matching filter in recording order.
+func (l *CostLedger) Entries(filter CostFilter) []CostEntry {
+	l.mu.RLock()
+	defer l.mu.RUnlock()
+
+	entries := make([]CostEntry, 0)
+	for _, e := range l.ordered() {
+		if filter.matches(e) {
+			entries = append(entries, e)
+		}
+	}
+	return entries
+}
+
+// ordered returns the retained entries oldest first. The caller holds l.mu.
+func (l *CostLedger) ordered() []CostEntry {
+	if l.head == 0 {
+		return l.entries
+	}
+	return append(append([]CostEntry(nil), l.entries[l.head:]...), l.entries[:l.head]...)
+}
+
+// Summary aggregates the retained entries matching filter.
+func (l *CostLedger) Summary(filter CostFilter) CostSummary {
+	var summary CostSummary
+	for _, e := range l.Entries(filter) {
+		summary.add(e)
+	}
+	return summary
+}
+
+// SummarizeBy aggregates the retained entries matching filter by session,
+// user or model.
+func (l *CostLedger) SummarizeBy(groupBy CostGroupBy, filter CostFilter) map[string]CostSummary {
+	result := make(map[string]CostSummary)
+	for _, e := range l.Entries(filter) {
+		var key string
+		switch groupBy {
+		case CostGroupBySession:
+			key = e.SessionID
+		case CostGroupByUser:
+			key = e.UserID
+		case CostGroupByModel:
+			key = string(e.Model)
+		}
+		summary := result[key]
+		summary.add(e)
+		result[key] = summary
+	}
+	return result
+}
+
+// WindowSummary aggregates the retained entries of the budget window
+// containing now.
+// See types.BudgetWindow.Bounds for how anchor aligns the window.
+func (l *CostLedger) WindowSummary(window types.BudgetWindow, anchor, now time.Time, filter CostFilter) CostSummary {
+	filter.Since, filter.Until = window.Bounds(anchor, now)
+	return l.Summary(filter)
+}
+
+// Reset removes all entries and running totals.
+func (l *CostLedger) Reset() {
+	l.mu.Lock()
+	l.entries = nil
+	l.head = 0
+	l.sessions = make(map[string]float64)
+	for _, w := range l.windows {
+		w.reset(w.start)
+	}
+	l.mu.Unlock()
+}
+
+// forgetSession drops the running total of a closed session.
+func (l *CostLedger) forgetSession(sessionID string) {
+	l.mu.Lock()
+	delete(l.sessions, sessionID)
+	l.mu.Unlock()
+}
+
+// trackWindow returns the running totals of the budget window, starting
+// them from the retained entries the first time the window is tracked.
+func (l *CostLedger) trackWindow(window types.BudgetWindow, anchor time.Time) *windowTotals {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	for _, w := range l.windows {
+		if w.window == window && w.anchor.Equal(anchor) {
+			return w
+		}
+	}
+	w := &windowTotals{window: window, anchor: anchor}
+	w.reset(time.Time{}) // The first entry opens its window
+	for _, e := range l.ordered() {
+		w.add(e)
+	}
+	l.windows = append(l.windows, w)
+	return w
+}
+
+// windowSpent returns the spend of all users and of userID in the window of
+// w containing now.
+func (l *CostLedger) windowSpent(w *windowTotals, userID string, now time.Time) (total, user float64) {
+	l.mu.RLock()
+	defer l.mu.RUnlock()
+
+	if start, _ := w.window.Bounds(w.anchor, now); !start.Equal(w.start) {
+		return 0, 0 // No entries yet in the window containing now
+	}
+	return w.total, w.users[userID]
+}
+
+// sessionSpent returns the spend of an open session.
+func (l *CostLedger) sessionSpent(sessionID string) float64 {
+	l.mu.RLock()
+	defer l.mu.RUnlock()
+	return l.sessions[sessionID]
+}
+
+// add counts e if it falls in the current window, moving to a later window
+// when e starts one. Entries of earlier windows are ignored.
+func (w *windowTotals) add(e CostEntry) {
+	start, _ := w.window.Bounds(w.anchor, e.Time)
+	if start.Before(w.start) {
+		return
+	}
+	if start.After(w.start) {
+		w.reset(start)
+	}
+	w.total += e.TotalCostUsd
+	w.users[e.UserID] += e.TotalCostUsd
+}
+
+func (w *windowTotals) reset(start time.Time) {
+	w.start = start
+	w.total = 0
+	w.users = make(map[string]float64)
+}
+
+// ExportJSON writes the entries matching filter as a JSON array.
+func (l *CostLedger) ExportJSON(w io.Writer, filter CostFilter) error {
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(l.Entries(filter))
+}
+
+// ExportCSV writes the entries matching filter as CSV with a header row.
+func (l *CostLedger) ExportCSV(w io.Writer, filter CostFilter) error {
+	cw := csv.NewWriter(w)
+	header := []string{
+		"time", "session_id", "user_id", "model", "total_cost_usd",
+		"input_tokens", "output_tokens", "cache_creation_input_tokens",
+		"cache_read_input_tokens", "num_turns", "is_error",
+	}
+	if err := cw.Write(header); err != nil {
+		return err
+	}
+
+	for _, e := range l.Entries(filter) {
+		record := []string{
+			e.Time.UTC().Format(time.RFC3339Nano),
+			e.SessionID,
+			e.UserID,
+			string(e.Model),
+			strconv.FormatFloat(e.TotalCostUsd, 'f', -1, 64),
+			strconv.Itoa(e.Usage.InputTokens),
+			strconv.Itoa(e.Usage.OutputTokens),
+			strconv.Itoa(e.Usage.CacheCreationInputTokens),
+			strconv.Itoa(e.Usage.CacheReadInputTokens),
+			strconv.Itoa(e.NumTurns),
+			strconv.FormatBool(e.IsError),
+		}
+		if err := cw.Write(record); err != nil {
+			return err
+		}
+	}
+
+	cw.Flush()
+	return cw.Error()
+}
+
+// Users returns the distinct user IDs of the retained entries.
+func (l *CostLedger) Users() []string {
+	l.mu.RLock()
+	defer l.mu.RUnlock()
+
+	seen := make(map[string]struct{})
+	users := make([]string, 0)
+	for _, e := range l.entries {
+		if _, ok := seen[e.UserID]; !ok {
+			seen[e.UserID] = struct{}{}
+			users = append(users, e.UserID)
+		}
+	}
+	sort.Strings(users)
+	return users
+}
+
+func (f CostFilter) matches(e CostEntry) bool {
+	if f.SessionID != "" && e.SessionID != f.SessionID {
+		return false
+	}
+	if f.UserID != "" && e.UserID != f.UserID {
+		return false
+	}
+	if f.Model != "" && e.Model != f.Model {
+		return false
+	}
+	if !f.Since.IsZero() && e.Time.Before(f.Since) {
+		return false
+	}
+	if !f.Until.IsZero() && !e.Time.Before(f.Until) {
+		return false
+	}
+	return true
+}
+
+func (s *CostSummary) add(e CostEntry) {
+	s.TotalCostUsd += e.TotalCostUsd
+	s.Usage.InputTokens += e.Usage.InputTokens
+	s.Usage.OutputTokens += e.Usage.OutputTokens
+	s.Usage.CacheCreationInputTokens += e.Usage.CacheCreationInputTokens
+	s.Usage.CacheReadInputTokens += e.Usage.CacheReadInputTokens
+	s.NumTurns += e.NumTurns
+	s.Entries++
+}
+
+// BudgetLimits configures a BudgetGuard. Zero limits are unlimited.
+type BudgetLimits struct {
+	// Window for the per-user and total limits. Defaults to the token's
+	// budget window, or BudgetWindowDay.
+	Window types.BudgetWindow
+
+	// WindowStart aligns the window. Defaults to the token's window start.
+	WindowStart time.Time
+
+	// MaxCostPerUser caps the spend of one user per window.
+	MaxCostPerUser float64
+
+	// MaxCostTotal caps the spend of all users per window.
+	MaxCostTotal float64
+
+	// MaxCostPerSession caps the spend of a single open session.
+	MaxCostPerSession float64
+}
+
+// BudgetGuard enforces BudgetLimits locally using the running totals of a
+// CostLedger.
+type BudgetGuard struct {
+	limits BudgetLimits
+	ledger *CostLedger
+	totals *windowTotals
+	now    func() time.Time
+}
+
+// NewBudgetGuard creates a guard that checks limits against ledger.
+func NewBudgetGuard(ledger *CostLedger, limits BudgetLimits) *BudgetGuard {
+	if limits.Window == "" {
+		limits.Window = types.BudgetWindowDay
+	}
+	g := &BudgetGuard{limits: limits, now: time.Now}
+	g.setLedger(ledger)
+	return g
+}
+
+func (g *BudgetGuard) setLedger(ledger *CostLedger) {
+	g.ledger = ledger
+	g.totals = ledger.trackWindow(g.limits.Window, g.limits.WindowStart)
+}
+
+// Limits returns the guard's limits.
+func (g *BudgetGuard) Limits() BudgetLimits {
+	return g.limits
+}
+
+// Check returns a budget exceeded error if the user or the whole client has
+// reached its window limit, or the session has reached its own limit.
+// An empty sessionID skips the session check.
+func (g *BudgetGuard) Check(userID, sessionID string) error {
+	now := g.now()
+	_, until := g.limits.Window.Bounds(g.limits.WindowStart, now)
+	total, user := g.ledger.windowSpent(g.totals, userID, now)
+
+	if g.limits.MaxCostTotal > 0 {
+		if spent := total; spent >= g.limits.MaxCostTotal {
+			return budgetError("total budget exceeded", "total", spent, g.limits.MaxCostTotal, until)
+		}
+	}
+
+	if g.limits.MaxCostPerUser > 0 {
+		if spent := user; spent >= g.limits.MaxCostPerUser {
+			err := budgetError("user budget exceeded", "user", spent, g.limits.MaxCostPerUser, until)
+			err.Details["userId"] = userID
+			return err
+		}
+	}
+
+	if g.limits.MaxCostPerSession > 0 && sessionID != "" {
+		if spent := g.ledger.sessionSpent(sessionID); spent >= g.limits.MaxCostPerSession {
+			return budgetError("session budget exceeded", "session", spent, g.limits.MaxCostPerSession, time.Time{})
+		}
+	}
+
+	return nil
+}
+
+func budgetError(message, scope string, spent, limit float64, windowEnd time.Time) *types.ChuckyError {
+	details := map[string]any{
+		"scope":                     scope,
+		"spentUsd":                  spent,
+		"limitUsd":                  limit,
+		types.DetailRemainingBudget: 0.0,
+		types.DetailRetryable:       false,
+	}
+	if !windowEnd.IsZero() {
+		details["windowEnd"] = windowEnd
+	}
+	return types.BudgetExceededError(message).WithDetails(details)
+}
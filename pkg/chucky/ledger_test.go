@@ -0,0 +1,225 @@
+package chucky
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/chucky-cloud/chucky-sdk-go/pkg/types"
+)
+
+func TestLedgerRetention(t *testing.T) {
+	tests := []struct {
+		name      string
+		retention int
+		record    int
+		want      []string // Retained session IDs
+	}{
+		{"under limit", 5, 3, []string{"s0", "s1", "s2"}},
+		{"rotates", 3, 7, []string{"s4", "s5", "s6"}},
+		{"off", 0, 4, []string{}},
+		{"unlimited", -1, 4, []string{"s0", "s1", "s2", "s3"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			l := NewCostLedger().SetRetention(tt.retention)
+			for i := 0; i < tt.record; i++ {
+				l.Record(CostEntry{SessionID: fmt.Sprintf("s%d", i), TotalCostUsd: 1})
+			}
+			got := make([]string, 0)
+			for _, e := range l.Entries(CostFilter{}) {
+				got = append(got, e.SessionID)
+			}
+			if fmt.Sprint(got) != fmt.Sprint(tt.want) {
+				t.Errorf("entries = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestLedgerShrinkRetention(t *testing.T) {
+	l := NewCostLedger().SetRetention(3)
+	for i := 0; i < 5; i++ {
+		l.Record(CostEntry{SessionID: fmt.Sprintf("s%d", i)})
+	}
+	l.SetRetention(2)
+	l.Record(CostEntry{SessionID: "s5"})
+	entries := l.Entries(CostFilter{})
+	if len(entries) != 2 || entries[0].SessionID != "s4" || entries[1].SessionID != "s5" {
+		t.Errorf("entries = %+v", entries)
+	}
+}
+
+func TestBudgetGuardUsesRunningTotals(t *testing.T) {
+	anchor := time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+	day := func(d int, h int) time.Time { return anchor.AddDate(0, 0, d).Add(time.Duration(h) * time.Hour) }
+
+	tests := []struct {
+		name    string
+		limits  BudgetLimits
+		entries []CostEntry
+		now     time.Time
+		user    string
+		session string
+		scope   string // Empty: allowed
+	}{
+		{"total", BudgetLimits{MaxCostTotal: 3},
+			[]CostEntry{{Time: day(0, 1), UserID: "a", TotalCostUsd: 2}, {Time: day(0, 2), UserID: "b", TotalCostUsd: 1}},
+			day(0, 3), "c", "", "total"},
+		{"user", BudgetLimits{MaxCostPerUser: 2},
+			[]CostEntry{{Time: day(0, 1), UserID: "a", TotalCostUsd: 2}, {Time: day(0, 2), UserID: "b", TotalCostUsd: 1}},
+			day(0, 3), "a", "", "user"},
+		{"other user", BudgetLimits{MaxCostPerUser: 2},
+			[]CostEntry{{Time: day(0, 1), UserID: "a", TotalCostUsd: 2}},
+			day(0, 3), "b", "", ""},
+		{"window rolled over", BudgetLimits{MaxCostTotal: 1},
+			[]CostEntry{{Time: day(0, 1), TotalCostUsd: 5}},
+			day(1, 1), "a", "", ""},
+		{"new window", BudgetLimits{MaxCostTotal: 3},
+			[]CostEntry{{Time: day(0, 1), TotalCostUsd: 5}, {Time: day(1, 1), TotalCostUsd: 1}},
+			day(1, 2), "a", "", ""},
+		{"session", BudgetLimits{MaxCostPerSession: 1},
+			[]CostEntry{{Time: day(0, 1), SessionID: "s", TotalCostUsd: 0.5}, {Time: day(0, 2), SessionID: "s", TotalCostUsd: 0.5}},
+			day(0, 3), "a", "s", "session"},
+		{"hourly window", BudgetLimits{Window: types.BudgetWindowHour, MaxCostTotal: 1},
+			[]CostEntry{{Time: day(0, 1), TotalCostUsd: 1}},
+			day(0, 1).Add(30 * time.Minute), "a", "", "total"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.limits.WindowStart = anchor
+			// Retention off: the guard must not depend on retained entries
+			l := NewCostLedger().SetRetention(0)
+			g := NewBudgetGuard(l, tt.limits)
+			g.now = func() time.Time { return tt.now }
+			for _, e := range tt.entries {
+				l.Record(e)
+			}
+
+			err := g.Check(tt.user, tt.session)
+			var ce *types.ChuckyError
+			switch {
+			case tt.scope == "" && err != nil:
+				t.Errorf("Check = %v, want nil", err)
+			case tt.scope != "" && (!errors.As(err, &ce) || ce.Details["scope"] != tt.scope):
+				t.Errorf("Check = %v, want %s budget exceeded", err, tt.scope)
+			}
+		})
+	}
+}
+
+func TestBudgetGuardCountsRetainedEntries(t *testing.T) {
+	l := NewCostLedger()
+	l.Record(CostEntry{UserID: "a", TotalCostUsd: 2})
+	g := NewBudgetGuard(l, BudgetLimits{MaxCostPerUser: 2})
+	if err := g.Check("a", ""); !errors.Is(err, types.ErrBudgetExceeded) {
+		t.Errorf("Check = %v, want budget exceeded", err)
+	}
+
+	l.Reset()
+	if err := g.Check("a", ""); err != nil {
+		t.Errorf("Check after Reset = %v", err)
+	}
+}
+
+func TestLedgerConcurrentRecord(t *testing.T) {
+	l := NewCostLedger().SetRetention(10)
+	g := NewBudgetGuard(l, BudgetLimits{MaxCostTotal: 1000})
+	var wg sync.WaitGroup
+	for i := 0; i < 8; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			for j := 0; j < 100; j++ {
+				l.Record(CostEntry{UserID: fmt.Sprint(i), TotalCostUsd: 1})
+				_ = g.Check(fmt.Sprint(i), "")
+				_ = l.Summary(CostFilter{})
+			}
+		}(i)
+	}
+	wg.Wait()
+
+	total, _ := l.windowSpent(g.totals, "", time.Now())
+	if total != 800 {
+		t.Errorf("total = %v, want 800", total)
+	}
+	if n := len(l.Entries(CostFilter{})); n != 10 {
+		t.Errorf("retained %d entries, want 10", n)
+	}
+}
+
+func TestLedgerRecordsTurnShareOfSessionTotals(t *testing.T) {
+	f := newFakeServer(t)
+	client := f.client(types.ClientOptions{})
+	result := func(conn *fakeConn, cost float64, turns int) {
+		conn.send(types.SDKResultMessage{
+			Type:         types.MessageTypeResult,
+			Subtype:      types.ResultSubtypeSuccess,
+			SessionID:    "s1",
+			NumTurns:     turns,
+			TotalCostUsd: cost,
+		})
+	}
+	waitEntries := func(n int) {
+		t.Helper()
+		deadline := time.Now().Add(5 * time.Second)
+		for len(client.Ledger().Entries(CostFilter{})) < n {
+			if time.Now().After(deadline) {
+				t.Fatalf("ledger has %d entries, want %d", len(client.Ledger().Entries(CostFilter{})), n)
+			}
+			time.Sleep(time.Millisecond)
+		}
+	}
+	near := func(a, b float64) bool { return a-b < 1e-9 && b-a < 1e-9 }
+
+	// Three turns costing 0.01, 0.02 and 0.03, reported as running totals
+	_, conn := connectSession(t, f, client, nil)
+	for i, total := range []float64{0.01, 0.03, 0.06} {
+		result(conn, total, i+1)
+	}
+	waitEntries(3)
+	summary := client.Ledger().Summary(CostFilter{SessionID: "s1"})
+	if !near(summary.TotalCostUsd, 0.06) || summary.NumTurns != 3 {
+		t.Errorf("session total = %v over %d turns, want 0.06 over 3", summary.TotalCostUsd, summary.NumTurns)
+	}
+	for i, want := range []float64{0.01, 0.02, 0.03} {
+		if got := client.Ledger().Entries(CostFilter{})[i].TotalCostUsd; !near(got, want) {
+			t.Errorf("entry %d = %v, want %v", i, got, want)
+		}
+	}
+
+	// A resumed session continues the server's totals
+	resumed := client.ResumeSession("s1", nil)
+	t.Cleanup(resumed.Close)
+	go func() { _ = resumed.Connect(context.Background()) }()
+	result(f.accept(), 0.10, 4)
+	waitEntries(4)
+	summary = client.Ledger().Summary(CostFilter{SessionID: "s1"})
+	if !near(summary.TotalCostUsd, 0.10) || summary.NumTurns != 4 {
+		t.Errorf("resumed total = %v over %d turns, want 0.10 over 4", summary.TotalCostUsd, summary.NumTurns)
+	}
+}
+
+func TestSessionScopedBudgetErrors(t *testing.T) {
+	sessionErr := budgetError("session budget exceeded", "session", 2, 1, time.Time{})
+	tests := []struct {
+		name string
+		err  error
+		want bool
+	}{
+		{"session", sessionErr, true},
+		{"wrapped session", fmt.Errorf("guard: %w", sessionErr), true},
+		{"user", budgetError("user budget exceeded", "user", 2, 1, time.Time{}), false},
+		{"other error", errors.New("boom"), false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := sessionScoped(tt.err); got != tt.want {
+				t.Errorf("sessionScoped = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
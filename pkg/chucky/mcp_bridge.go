@@ -0,0 +1,626 @@
+package chucky
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log/slog"
+	"os"
+	"os/exec"
+	"reflect"
+	"sort"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/chucky-cloud/chucky-sdk-go/pkg/types"
+)
+
+// mcpProtocolVersion is the MCP revision requested from local servers.
+const mcpProtocolVersion = "2024-11-05"
+
+// mcpTool is a tool as listed by an MCP server.
+type mcpTool struct {
+	Name        string          `json:"name"`
+	Description string          `json:"description,omitempty"`
+	InputSchema json.RawMessage `json:"inputSchema,omitempty"`
+}
+
+// localMcpServer bridges a local stdio MCP server to the session. The
+// process is started on connect and restarted by the next tool call after
+// it exits; a restarted process is asked for its tools again.
+type localMcpServer struct {
+	config types.McpStdioServerConfig
+	logger *slog.Logger
+
+	// onToolsChanged is called, without mu held, when a restarted process
+	// lists different tools than before.
+	onToolsChanged func(previous, tools []mcpTool)
+
+	mu     sync.Mutex // Guards proc, closed and tools
+	proc   *mcpProcess
+	closed bool
+	tools  []mcpTool
+}
+
+func newLocalMcpServer(config types.McpStdioServerConfig) *localMcpServer {
+	logger := config.Logger
+	if logger == nil {
+		logger = slog.Default()
+	}
+	return &localMcpServer{
+		config: config,
+		logger: logger.With(slog.String("mcp_server", config.Name)),
+	}
+}
+
+// start spawns the process and lists its tools.
+func (m *localMcpServer) start(ctx context.Context) error {
+	proc, _, err := m.process(ctx)
+	if err != nil {
+		return err
+	}
+	tools, err := m.listTools(ctx, proc)
+	if err != nil {
+		return err
+	}
+	m.setTools(tools)
+	return nil
+}
+
+// refreshTools lists the tools of a restarted process and reports a
+// changed list to onToolsChanged.
+func (m *localMcpServer) refreshTools(ctx context.Context, proc *mcpProcess) {
+	tools, err := m.listTools(ctx, proc)
+	if err != nil {
+		m.logger.Warn("listing tools after restart failed", slog.Any("error", err))
+		return
+	}
+	previous := m.setTools(tools)
+	if !reflect.DeepEqual(previous, tools) && m.onToolsChanged != nil {
+		m.onToolsChanged(previous, tools)
+	}
+}
+
+// listTools pages through tools/list.
+func (m *localMcpServer) listTools(ctx context.Context, proc *mcpProcess) ([]mcpTool, error) {
+	var tools []mcpTool
+	cursor := ""
+	for {
+		params := map[string]any{}
+		if cursor != "" {
+			params["cursor"] = cursor
+		}
+		var page struct {
+			Tools      []mcpTool `json:"tools"`
+			NextCursor string    `json:"nextCursor"`
+		}
+		if err := proc.call(ctx, "tools/list", params, &page); err != nil {
+			return nil, m.error("listing tools failed", err)
+		}
+		tools = append(tools, page.Tools...)
+		if page.NextCursor == "" {
+			break
+		}
+		cursor = page.NextCursor
+	}
+	return tools, nil
+}
+
+// setTools replaces the tool list and returns the previous one.
+func (m *localMcpServer) setTools(tools []mcpTool) []mcpTool {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	previous := m.tools
+	m.tools = tools
+	return previous
+}
+
+// toolList returns the current tool list.
+func (m *localMcpServer) toolList() []mcpTool {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return m.tools
+}
+
+// process returns the running process, starting it if needed. restarted
+// reports whether it replaced a process that exited.
+func (m *localMcpServer) process(ctx context.Context) (proc *mcpProcess, restarted bool, err error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if m.closed {
+		return nil, false, types.SessionError("local MCP server " + m.config.Name + " is closed")
+	}
+	if m.proc != nil {
+		select {
+		case <-m.proc.done:
+			m.logger.Warn("restarting local MCP server", slog.Any("error", m.proc.err))
+		default:
+			return m.proc, false, nil
+		}
+	}
+
+	proc, err = startMcpProcess(m.config, m.logger)
+	if err != nil {
+		return nil, false, m.error("starting the process failed", err)
+	}
+	var initialized struct {
+		ServerInfo struct {
+			Version string `json:"version"`
+		} `json:"serverInfo"`
+	}
+	err = proc.call(ctx, "initialize", map[string]any{
+		"protocolVersion": mcpProtocolVersion,
+		"capabilities":    map[string]any{},
+		"clientInfo": map[string]any{
+			"name":    "chucky-sdk-go",
+			"version": "1.0.0",
+		},
+	}, &initialized)
+	if err == nil {
+		err = proc.notify("notifications/initialized", nil)
+	}
+	if err != nil {
+		proc.kill()
+		return nil, false, m.error("initialize failed", err)
+	}
+
+	proc.version = initialized.ServerInfo.Version
+	restarted = m.proc != nil
+	m.proc = proc
+	return proc, restarted, nil
+}
+
+// callTool proxies a tool call to the process.
+func (m *localMcpServer) callTool(ctx context.Context, name string, input map[string]any) (*types.ToolResult, error) {
+	proc, restarted, err := m.process(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if restarted {
+		m.refreshTools(ctx, proc)
+	}
+	if input == nil {
+		input = map[string]any{}
+	}
+
+	var result struct {
+		Content []any `json:"content"`
+		IsError bool  `json:"isError"`
+	}
+	err = proc.call(ctx, "tools/call", map[string]any{
+		"name":      name,
+		"arguments": input,
+	}, &result)
+	if err != nil {
+		return nil, err
+	}
+	return &types.ToolResult{Content: result.Content, IsError: result.IsError}, nil
+}
+
+// close stops the process.
+func (m *localMcpServer) close() {
+	m.mu.Lock()
+	m.closed = true
+	proc := m.proc
+	m.mu.Unlock()
+	if proc != nil {
+		proc.kill()
+	}
+}
+
+func (m *localMcpServer) error(message string, err error) *types.ChuckyError {
+	return types.SessionError("local MCP server " + m.config.Name + ": " + message).Wrap(err)
+}
+
+// toMap converts the server into the init payload format of a client tools
+// server.
+func (m *localMcpServer) toMap() map[string]any {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	tools := make([]map[string]any, 0, len(m.tools))
+	for _, tool := range m.tools {
+		schema := any(types.ToolInputSchema{Type: "object"})
+		if len(tool.InputSchema) > 0 {
+			schema = tool.InputSchema
+		}
+		tools = append(tools, map[string]any{
+			"name":        tool.Name,
+			"description": tool.Description,
+			"inputSchema": schema,
+			"executeIn":   "client",
+		})
+	}
+	version := ""
+	if m.proc != nil {
+		version = m.proc.version // Set before proc is stored
+	}
+	return map[string]any{
+		"name":    m.config.Name,
+		"version": version,
+		"tools":   tools,
+	}
+}
+
+// startLocalMcpServers starts the session's local stdio servers and
+// registers handlers for their tools. It runs once; later connects reuse
+// the running servers.
+func (s *Session) startLocalMcpServers(ctx context.Context) error {
+	s.toolsMu.RLock()
+	started := s.localMcpServers != nil
+	var configs []types.McpStdioServerConfig
+	for _, server := range s.options.McpServers {
+		if srv, ok := server.(types.McpStdioServerConfig); ok && srv.Local {
+			configs = append(configs, srv)
+		}
+	}
+	s.toolsMu.RUnlock()
+	if started || len(configs) == 0 {
+		return nil
+	}
+
+	servers := make(map[string]*localMcpServer, len(configs))
+	for _, config := range configs {
+		server := newLocalMcpServer(config)
+		if err := server.start(ctx); err != nil {
+			server.close()
+			for _, started := range servers {
+				started.close()
+			}
+			return err
+		}
+		servers[config.Name] = server
+	}
+
+	s.toolsMu.Lock()
+	defer s.toolsMu.Unlock()
+	stopAll := func() {
+		for _, server := range servers {
+			server.close()
+		}
+	}
+	select {
+	case <-s.closeCh:
+		stopAll()
+		return types.SessionError("session closed")
+	default:
+	}
+	if s.localMcpServers != nil {
+		// Started by a concurrent Connect
+		stopAll()
+		return nil
+	}
+
+	names := sortedServerNames(servers)
+	seen := make(map[string]bool)
+	for _, name := range names {
+		for _, tool := range servers[name].toolList() {
+			if _, ok := s.toolHandlers[tool.Name]; ok || seen[tool.Name] {
+				stopAll()
+				return types.ValidationError(fmt.Sprintf("local MCP server %s: tool %q conflicts with another client tool", name, tool.Name))
+			}
+			seen[tool.Name] = true
+		}
+	}
+
+	for _, name := range names {
+		server := servers[name]
+		for _, tool := range server.toolList() {
+			s.registerLocalMcpTool(name, server, tool.Name)
+		}
+		server.onToolsChanged = func(previous, tools []mcpTool) {
+			s.updateLocalMcpServer(name, server, previous, tools)
+		}
+	}
+	s.localMcpServers = servers
+	return nil
+}
+
+// registerLocalMcpTool registers the handler proxying a tool to a local
+// server. Callers must hold toolsMu.
+func (s *Session) registerLocalMcpTool(name string, server *localMcpServer, toolName string) {
+	def := types.ToolDefinition{
+		Name: toolName,
+		Handler: func(ctx context.Context, input map[string]any) (*types.ToolResult, error) {
+			return server.callTool(ctx, toolName, input)
+		},
+	}
+	s.toolHandlers[toolName] = s.wrapToolHandler(types.McpClientToolsServer{Name: name}, def)
+}
+
+// updateLocalMcpServer re-registers the tools of a restarted local server
+// and sends the server's new tool list. Tools that now conflict with another
+// client tool are left out and reported through OnError.
+func (s *Session) updateLocalMcpServer(name string, server *localMcpServer, previous, tools []mcpTool) {
+	s.updateMu.Lock()
+	defer s.updateMu.Unlock()
+
+	s.toolsMu.Lock()
+	if s.localMcpServers[name] != server {
+		s.toolsMu.Unlock()
+		return
+	}
+	for _, tool := range previous {
+		delete(s.toolHandlers, tool.Name)
+	}
+	var conflicts []string
+	registered := make([]mcpTool, 0, len(tools))
+	for _, tool := range tools {
+		if _, ok := s.toolHandlers[tool.Name]; ok {
+			conflicts = append(conflicts, tool.Name)
+			continue
+		}
+		s.registerLocalMcpTool(name, server, tool.Name)
+		registered = append(registered, tool)
+	}
+	if len(conflicts) > 0 {
+		server.setTools(registered)
+	}
+	serverMap := server.toMap()
+	initSent := s.initSent
+	s.toolsMu.Unlock()
+
+	for _, tool := range conflicts {
+		s.handleError(types.ValidationError(fmt.Sprintf("local MCP server %s: tool %q conflicts with another client tool", name, tool)))
+	}
+	if !initSent {
+		return
+	}
+	if err := s.notifyMcpServerUpdate(serverMap); err != nil {
+		s.handleError(err)
+	}
+}
+
+// stopLocalMcpServers stops the session's local stdio servers.
+func (s *Session) stopLocalMcpServers() {
+	s.toolsMu.RLock()
+	servers := s.localMcpServers
+	s.toolsMu.RUnlock()
+	for _, server := range servers {
+		server.close()
+	}
+}
+
+func sortedServerNames(servers map[string]*localMcpServer) []string {
+	names := make([]string, 0, len(servers))
+	for name := range servers {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// mcpProcess is a running MCP server speaking newline-delimited JSON-RPC
+// over stdio.
+type mcpProcess struct {
+	cmd     *exec.Cmd
+	stdin   io.WriteCloser
+	logger  *slog.Logger
+	version string // Reported by the server on initialize
+
+	writeMu   sync.Mutex
+	nextID    atomic.Int64
+	pending   map[int64]chan rpcResponse
+	pendingMu sync.Mutex
+
+	done chan struct{} // Closed when the process exits
+	err  error         // Set before done is closed
+}
+
+type rpcError struct {
+	Code    int    `json:"code"`
+	Message string `json:"message"`
+	Data    any    `json:"data,omitempty"`
+}
+
+func (e *rpcError) Error() string {
+	return fmt.Sprintf("%s (code %d)", e.Message, e.Code)
+}
+
+type rpcResponse struct {
+	Result json.RawMessage
+	Err    error
+}
+
+type rpcMessage struct {
+	JSONRPC string          `json:"jsonrpc"`
+	ID      json.RawMessage `json:"id,omitempty"`
+	Method  string          `json:"method,omitempty"`
+	Params  any             `json:"params,omitempty"`
+	Result  json.RawMessage `json:"result,omitempty"`
+	Error   *rpcError       `json:"error,omitempty"`
+}
+
+func startMcpProcess(config types.McpStdioServerConfig, logger *slog.Logger) (*mcpProcess, error) {
+	cmd := exec.Command(config.Command, config.Args...)
+	cmd.Dir = config.Dir
+	cmd.Env = os.Environ()
+	for k, v := range config.Env {
+		cmd.Env = append(cmd.Env, k+"="+v)
+	}
+
+	stdin, err := cmd.StdinPipe()
+	if err != nil {
+		return nil, err
+	}
+	stdout, err := cmd.StdoutPipe()
+	if err != nil {
+		return nil, err
+	}
+	stderr, err := cmd.StderrPipe()
+	if err != nil {
+		return nil, err
+	}
+	if err := cmd.Start(); err != nil {
+		return nil, err
+	}
+
+	p := &mcpProcess{
+		cmd:     cmd,
+		stdin:   stdin,
+		logger:  logger,
+		pending: make(map[int64]chan rpcResponse),
+		done:    make(chan struct{}),
+	}
+
+	var output sync.WaitGroup
+	output.Add(2)
+	go func() {
+		defer output.Done()
+		p.readStderr(stderr)
+	}()
+	go func() {
+		defer output.Done()
+		p.readStdout(stdout)
+	}()
+	go func() {
+		// Wait closes the pipes, so the readers must finish first
+		output.Wait()
+		err := cmd.Wait()
+		if err == nil {
+			err = io.EOF
+		}
+		p.err = err
+		close(p.done)
+
+		p.pendingMu.Lock()
+		for id, ch := range p.pending {
+			ch <- rpcResponse{Err: types.SessionError("local MCP server exited").Wrap(err)}
+			delete(p.pending, id)
+		}
+		p.pendingMu.Unlock()
+	}()
+	return p, nil
+}
+
+func (p *mcpProcess) readStderr(r io.Reader) {
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 64<<10), 1<<20)
+	for scanner.Scan() {
+		p.logger.Info("local MCP server stderr", slog.String("line", scanner.Text()))
+	}
+}
+
+func (p *mcpProcess) readStdout(r io.Reader) {
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 64<<10), 64<<20)
+	for scanner.Scan() {
+		var msg rpcMessage
+		if err := json.Unmarshal(scanner.Bytes(), &msg); err != nil {
+			p.logger.Warn("invalid message from local MCP server", slog.Any("error", err))
+			continue
+		}
+
+		switch {
+		case msg.Method != "" && len(msg.ID) > 0:
+			p.handleRequest(msg)
+		case msg.Method != "":
+			// Notifications (logging, progress, list changes) are not used
+		default:
+			var id int64
+			if err := json.Unmarshal(msg.ID, &id); err != nil {
+				continue
+			}
+			resp := rpcResponse{Result: msg.Result}
+			if msg.Error != nil {
+				resp.Err = msg.Error
+			}
+			p.pendingMu.Lock()
+			ch, ok := p.pending[id]
+			delete(p.pending, id)
+			p.pendingMu.Unlock()
+			if ok {
+				ch <- resp
+			}
+		}
+	}
+	// Drain stdout so a child still writing after a scanner error can exit
+	_, _ = io.Copy(io.Discard, r)
+}
+
+// handleRequest answers requests from the server. Only ping is supported.
+func (p *mcpProcess) handleRequest(msg rpcMessage) {
+	reply := rpcMessage{JSONRPC: "2.0", ID: msg.ID}
+	if msg.Method == "ping" {
+		reply.Result = json.RawMessage("{}")
+	} else {
+		reply.Error = &rpcError{Code: -32601, Message: "method not found: " + msg.Method}
+	}
+	if err := p.write(reply); err != nil {
+		p.logger.Warn("replying to local MCP server failed", slog.Any("error", err))
+	}
+}
+
+// call sends a request and decodes its result into dst.
+func (p *mcpProcess) call(ctx context.Context, method string, params any, dst any) error {
+	id := p.nextID.Add(1)
+	ch := make(chan rpcResponse, 1)
+	p.pendingMu.Lock()
+	select {
+	case <-p.done:
+		p.pendingMu.Unlock()
+		return types.SessionError("local MCP server exited").Wrap(p.err)
+	default:
+	}
+	p.pending[id] = ch
+	p.pendingMu.Unlock()
+
+	rawID, _ := json.Marshal(id)
+	if err := p.write(rpcMessage{JSONRPC: "2.0", ID: rawID, Method: method, Params: params}); err != nil {
+		p.pendingMu.Lock()
+		delete(p.pending, id)
+		p.pendingMu.Unlock()
+		return err
+	}
+
+	select {
+	case resp := <-ch:
+		if resp.Err != nil {
+			return resp.Err
+		}
+		if dst == nil {
+			return nil
+		}
+		return json.Unmarshal(resp.Result, dst)
+	case <-ctx.Done():
+		p.pendingMu.Lock()
+		delete(p.pending, id)
+		p.pendingMu.Unlock()
+		_ = p.notify("notifications/cancelled", map[string]any{
+			"requestId": id,
+			"reason":    ctx.Err().Error(),
+		})
+		return ctx.Err()
+	}
+}
+
+// notify sends a notification.
+func (p *mcpProcess) notify(method string, params any) error {
+	return p.write(rpcMessage{JSONRPC: "2.0", Method: method, Params: params})
+}
+
+func (p *mcpProcess) write(msg rpcMessage) error {
+	data, err := json.Marshal(msg)
+	if err != nil {
+		return err
+	}
+	p.writeMu.Lock()
+	defer p.writeMu.Unlock()
+	_, err = p.stdin.Write(append(data, '\n'))
+	return err
+}
+
+// kill closes stdin, which asks the server to exit, and kills the process
+// if it is still running after a grace period.
+func (p *mcpProcess) kill() {
+	_ = p.stdin.Close()
+	go func() {
+		select {
+		case <-p.done:
+		case <-time.After(2 * time.Second):
+			_ = p.cmd.Process.Kill()
+		}
+	}()
+}
@@ -0,0 +1,198 @@
+package chucky
+
+import (
+	"bufio"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"testing"
+
+	"github.com/chucky-cloud/chucky-sdk-go/pkg/types"
+)
+
+// fakeMcpEnv makes the test binary run as a stdio MCP server. Its value is
+// a file counting the server's starts.
+const fakeMcpEnv = "CHUCKY_FAKE_MCP_SERVER"
+
+func TestMain(m *testing.M) {
+	if path := os.Getenv(fakeMcpEnv); path != "" {
+		runFakeMcpServer(path)
+		os.Exit(0)
+	}
+	os.Exit(m.Run())
+}
+
+// runFakeMcpServer serves echo and crash on its first start, and echo and
+// reverse once restarted. crash exits without answering.
+func runFakeMcpServer(countFile string) {
+	data, _ := os.ReadFile(countFile)
+	starts, _ := strconv.Atoi(string(data))
+	starts++
+	_ = os.WriteFile(countFile, []byte(strconv.Itoa(starts)), 0o600)
+
+	tools := []string{"echo", "crash"}
+	if starts > 1 {
+		tools = []string{"echo", "reverse"}
+	}
+
+	out := json.NewEncoder(os.Stdout)
+	scanner := bufio.NewScanner(os.Stdin)
+	for scanner.Scan() {
+		var req struct {
+			ID     json.RawMessage `json:"id"`
+			Method string          `json:"method"`
+			Params struct {
+				Name      string         `json:"name"`
+				Arguments map[string]any `json:"arguments"`
+			} `json:"params"`
+		}
+		if json.Unmarshal(scanner.Bytes(), &req) != nil || len(req.ID) == 0 {
+			continue
+		}
+
+		var result any
+		switch req.Method {
+		case "initialize":
+			result = map[string]any{
+				"protocolVersion": mcpProtocolVersion,
+				"capabilities":    map[string]any{"tools": map[string]any{}},
+				"serverInfo":      map[string]any{"name": "fake", "version": strconv.Itoa(starts)},
+			}
+		case "tools/list":
+			list := make([]map[string]any, 0, len(tools))
+			for _, name := range tools {
+				list = append(list, map[string]any{"name": name, "inputSchema": map[string]any{"type": "object"}})
+			}
+			result = map[string]any{"tools": list}
+		case "tools/call":
+			text, _ := req.Params.Arguments["text"].(string)
+			switch req.Params.Name {
+			case "crash":
+				os.Exit(1)
+			case "reverse":
+				runes := []rune(text)
+				for i, j := 0, len(runes)-1; i < j; i, j = i+1, j-1 {
+					runes[i], runes[j] = runes[j], runes[i]
+				}
+				text = string(runes)
+			}
+			result = map[string]any{"content": []any{map[string]any{"type": "text", "text": text}}}
+		}
+		_ = out.Encode(map[string]any{"jsonrpc": "2.0", "id": req.ID, "result": result})
+	}
+}
+
+// serverTools returns the sorted tool names of a server definition.
+func serverTools(server map[string]any) []string {
+	var names []string
+	tools, _ := server["tools"].([]any)
+	for _, tool := range tools {
+		if tool, ok := tool.(map[string]any); ok {
+			name, _ := tool["name"].(string)
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names)
+	return names
+}
+
+// callTool sends a tool call and returns its result payload.
+func callTool(t *testing.T, conn *fakeConn, callID, tool string, input map[string]any) map[string]any {
+	t.Helper()
+	conn.send(types.ToolCallEnvelope{
+		Type:    types.MessageTypeToolCall,
+		Payload: types.ToolCallPayload{CallID: callID, ToolName: tool, Input: input},
+	})
+	for {
+		msg := conn.recv()
+		payload, _ := msg["payload"].(map[string]any)
+		if msg["type"] == string(types.MessageTypeToolResult) && payload["callId"] == callID {
+			return payload
+		}
+	}
+}
+
+func resultText(t *testing.T, payload map[string]any) string {
+	t.Helper()
+	result, _ := payload["result"].(map[string]any)
+	content, _ := result["content"].([]any)
+	if len(content) == 0 {
+		t.Fatalf("tool result without content: %v", payload)
+	}
+	block, _ := content[0].(map[string]any)
+	text, _ := block["text"].(string)
+	return text
+}
+
+func TestLocalMcpServerRestartUpdatesTools(t *testing.T) {
+	f := newFakeServer(t)
+	_, conn := connectSession(t, f, f.client(types.ClientOptions{}), &types.SessionOptions{
+		BaseOptions: types.BaseOptions{
+			McpServers: []types.McpServerDefinition{types.McpStdioServerConfig{
+				Name:    "local",
+				Command: os.Args[0],
+				Env:     map[string]string{fakeMcpEnv: filepath.Join(t.TempDir(), "starts")},
+				Local:   true,
+			}},
+		},
+	})
+
+	payload, _ := conn.init["payload"].(map[string]any)
+	servers, _ := payload["mcpServers"].([]any)
+	if len(servers) != 1 {
+		t.Fatalf("init lists %d MCP servers, want 1", len(servers))
+	}
+	if got := serverTools(servers[0].(map[string]any)); !equalStrings(got, []string{"crash", "echo"}) {
+		t.Fatalf("init tools = %v", got)
+	}
+
+	if got := resultText(t, callTool(t, conn, "call_1", "echo", map[string]any{"text": "hi"})); got != "hi" {
+		t.Errorf("echo = %q", got)
+	}
+
+	// The process exits mid-call; the next call restarts it and sends the
+	// new tool list before its result
+	callTool(t, conn, "call_2", "crash", nil)
+	conn.send(types.ToolCallEnvelope{
+		Type:    types.MessageTypeToolCall,
+		Payload: types.ToolCallPayload{CallID: "call_3", ToolName: "echo", Input: map[string]any{"text": "again"}},
+	})
+	data := conn.recvControl(types.ControlActionUpdateMcpServer)
+	msg := conn.recv()
+	if msg["type"] != string(types.MessageTypeToolResult) {
+		t.Fatalf("got %v after the update, want the tool result", msg["type"])
+	}
+	if got := resultText(t, msg["payload"].(map[string]any)); got != "again" {
+		t.Errorf("echo after restart = %q", got)
+	}
+
+	server, _ := data["server"].(map[string]any)
+	if server["name"] != "local" || server["version"] != "2" {
+		t.Errorf("update for %v version %v, want local version 2", server["name"], server["version"])
+	}
+	if got := serverTools(server); !equalStrings(got, []string{"echo", "reverse"}) {
+		t.Errorf("updated tools = %v", got)
+	}
+
+	if got := resultText(t, callTool(t, conn, "call_4", "reverse", map[string]any{"text": "abc"})); got != "cba" {
+		t.Errorf("reverse = %q", got)
+	}
+	result, _ := callTool(t, conn, "call_5", "crash", nil)["result"].(map[string]any)
+	if result["isError"] != true {
+		t.Errorf("crash after restart = %v, want tool not found", result)
+	}
+}
+
+func equalStrings(a, b []string) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
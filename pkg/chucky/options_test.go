@@ -0,0 +1,161 @@
+package chucky
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"reflect"
+	"testing"
+
+	"github.com/chucky-cloud/chucky-sdk-go/pkg/types"
+)
+
+// clientOnlyOptions are SessionOptions fields that are used by the SDK and
+// intentionally not sent in the init payload.
+var clientOnlyOptions = map[string]bool{
+	"PermissionRules":   true,
+	"MessageBufferSize": true,
+	"Backpressure":      true,
+}
+
+func TestInitPayloadCarriesEveryOption(t *testing.T) {
+	allow := func(ctx context.Context, input types.HookInput) (types.HookOutput, error) {
+		return types.ContinueHook(), nil
+	}
+
+	tests := []struct {
+		field string
+		set   func(o *types.SessionOptions)
+		key   string
+		want  string // JSON of payload[key]
+	}{
+		{"Model", func(o *types.SessionOptions) { o.Model = types.ModelClaudeOpus }, "model", `"claude-opus-4-5-20251101"`},
+		{"FallbackModel", func(o *types.SessionOptions) { o.FallbackModel = "sonnet" }, "fallbackModel", `"sonnet"`},
+		{"SystemPrompt", func(o *types.SessionOptions) {
+			o.SystemPrompt = types.SystemPromptPreset{Type: "preset", Preset: "claude_code", Append: "Be brief."}
+		}, "systemPrompt", `{"type":"preset","preset":"claude_code","append":"Be brief."}`},
+		{"MaxTurns", func(o *types.SessionOptions) { o.MaxTurns = 7 }, "maxTurns", `7`},
+		{"MaxBudgetUsd", func(o *types.SessionOptions) { o.MaxBudgetUsd = 1.5 }, "maxBudgetUsd", `1.5`},
+		{"MaxThinkingTokens", func(o *types.SessionOptions) { o.MaxThinkingTokens = 2048 }, "maxThinkingTokens", `2048`},
+		{"Tools", func(o *types.SessionOptions) { o.Tools = []string{"Read", "Bash"} }, "tools", `["Read","Bash"]`},
+		{"McpServers", func(o *types.SessionOptions) {
+			o.McpServers = []types.McpServerDefinition{
+				types.McpClientToolsServer{Name: "local", Version: "1.0.0", Tools: []types.ToolDefinition{
+					{Name: "lookup", Description: "d", InputSchema: types.ToolInputSchema{Type: "object"}, ExecuteIn: types.ExecuteInBrowser},
+				}},
+				types.McpHTTPServerConfig{Name: "remote", URL: "https://mcp.example.com"},
+			}
+		}, "mcpServers", `[{"name":"local","tools":[{"description":"d","executeIn":"browser","inputSchema":{"type":"object"},"name":"lookup"}],"version":"1.0.0"},` +
+			`{"headers":null,"name":"remote","type":"http","url":"https://mcp.example.com"}]`},
+		{"Agents", func(o *types.SessionOptions) {
+			o.Agents = map[string]types.AgentDefinition{
+				"reviewer": {Description: "Reviews code", Prompt: "You review code.", Tools: []string{"Read"}, Model: "haiku",
+					McpServers: []types.McpServerDefinition{types.McpClientToolsServer{Name: "lint", Tools: []types.ToolDefinition{{Name: "run_lint"}}}}},
+			}
+		}, "agents", `{"reviewer":{"description":"Reviews code","model":"haiku","prompt":"You review code.","tools":["Read","mcp__lint__run_lint"]}}`},
+		{"PermissionMode", func(o *types.SessionOptions) { o.PermissionMode = types.PermissionModePlan }, "permissionMode", `"plan"`},
+		{"OutputFormat", func(o *types.SessionOptions) {
+			o.OutputFormat = &types.OutputFormat{Type: "json_schema", Schema: map[string]any{"type": "object"}}
+		}, "outputFormat", `{"type":"json_schema","schema":{"type":"object"}}`},
+		{"IncludePartialMessages", func(o *types.SessionOptions) { o.IncludePartialMessages = true }, "includePartialMessages", `true`},
+		{"Env", func(o *types.SessionOptions) { o.Env = map[string]string{"DEBUG": "1"} }, "env", `{"DEBUG":"1"}`},
+		{"SessionID", func(o *types.SessionOptions) { o.SessionID = "sess_1" }, "sessionId", `"sess_1"`},
+		{"ForkSession", func(o *types.SessionOptions) { o.SessionID = "sess_1"; o.ForkSession = true }, "forkSession", `true`},
+		{"ResumeSessionAt", func(o *types.SessionOptions) { o.SessionID = "sess_1"; o.ResumeSessionAt = "msg_1" }, "resumeSessionAt", `"msg_1"`},
+		{"Continue", func(o *types.SessionOptions) { o.Continue = true }, "continue", `true`},
+		{"SettingSources", func(o *types.SessionOptions) { o.SettingSources = []string{"project"} }, "settingSources", `["project"]`},
+		{"CanUseTool", func(o *types.SessionOptions) {
+			o.CanUseTool = func(ctx context.Context, toolName string, input map[string]any) (types.PermissionDecision, error) {
+				return types.PermissionDecision{}, nil
+			}
+		}, "canUseTool", `true`},
+		{"Hooks", func(o *types.SessionOptions) {
+			o.Hooks = map[types.HookEvent][]types.HookMatcher{
+				types.HookEventPreToolUse: {{Matcher: "Bash", Hooks: []types.HookCallback{allow}}},
+			}
+		}, "hooks", `{"PreToolUse":[{"hookCallbackIds":["hook_PreToolUse_0"],"matcher":"Bash","timeout":60}]}`},
+	}
+
+	covered := make(map[string]bool)
+	for _, tt := range tests {
+		covered[tt.field] = true
+		t.Run(tt.field, func(t *testing.T) {
+			var opts types.SessionOptions
+			tt.set(&opts)
+			if err := opts.Validate(); err != nil {
+				t.Fatalf("Validate: %v", err)
+			}
+
+			s := NewClient(types.ClientOptions{}).CreateSession(&opts)
+			defer s.Close()
+
+			data, err := json.Marshal(s.initPayload())
+			if err != nil {
+				t.Fatalf("marshal: %v", err)
+			}
+			var payload map[string]json.RawMessage
+			if err := json.Unmarshal(data, &payload); err != nil {
+				t.Fatalf("unmarshal: %v", err)
+			}
+			if got := string(payload[tt.key]); got != tt.want {
+				t.Errorf("payload[%q] = %s, want %s", tt.key, got, tt.want)
+			}
+		})
+	}
+
+	// Every option must be sent or be explicitly client-only
+	for _, field := range optionFields(reflect.TypeOf(types.SessionOptions{})) {
+		if !covered[field] && !clientOnlyOptions[field] {
+			t.Errorf("SessionOptions.%s is neither covered by this test nor client-only", field)
+		}
+	}
+}
+
+func TestValidateAggregatesErrors(t *testing.T) {
+	opts := types.SessionOptions{
+		BaseOptions: types.BaseOptions{
+			Model:        "gpt-4",
+			MaxBudgetUsd: -1,
+			McpServers: []types.McpServerDefinition{
+				types.McpStdioServerConfig{Name: "fs", Command: "mcp-fs"},
+				types.McpHTTPServerConfig{Name: "fs"},
+			},
+		},
+	}
+
+	err := opts.Validate()
+	if !errors.Is(err, types.ErrValidation) {
+		t.Fatalf("Validate() = %v, want a validation error", err)
+	}
+
+	var ce *types.ChuckyError
+	errors.As(err, &ce)
+	var fields []string
+	for _, fe := range ce.FieldErrors() {
+		fields = append(fields, fe.Field)
+	}
+	want := []string{"Model", "MaxBudgetUsd", "McpServers[1].Name", "McpServers[1].URL"}
+	if !reflect.DeepEqual(fields, want) {
+		t.Errorf("fields = %v, want %v", fields, want)
+	}
+
+	s := NewClient(types.ClientOptions{}).CreateSession(&opts)
+	defer s.Close()
+	if err := s.Connect(context.Background()); !errors.Is(err, types.ErrValidation) {
+		t.Errorf("Connect() = %v, want the validation error", err)
+	}
+}
+
+// optionFields returns the names of the fields of t, flattening embedded structs.
+func optionFields(t reflect.Type) []string {
+	var fields []string
+	for i := 0; i < t.NumField(); i++ {
+		f := t.Field(i)
+		if f.Anonymous {
+			fields = append(fields, optionFields(f.Type)...)
+			continue
+		}
+		fields = append(fields, f.Name)
+	}
+	return fields
+}
@@ -0,0 +1,78 @@
+package chucky
+
+import (
+	"context"
+	"fmt"
+	"runtime/debug"
+
+	"github.com/chucky-cloud/chucky-sdk-go/pkg/types"
+)
+
+// PermissionRules returns the session's "always allow" rule set.
+func (s *Session) PermissionRules() *types.PermissionRules {
+	return s.permissionRules
+}
+
+// handleCanUseTool answers a permission request from the server. It runs on
+// its own goroutine because the callback may wait for a human.
+func (s *Session) handleCanUseTool(data any) {
+	var req types.CanUseToolRequest
+	if err := decodeData(data, &req); err != nil {
+		s.handleError(types.ProtocolError("invalid can_use_tool request").Wrap(err))
+		return
+	}
+
+	decision := s.decidePermission(req)
+
+	resp := types.PermissionResponse{
+		RequestID:          req.RequestID,
+		PermissionDecision: decision,
+	}
+	if err := s.sendControl(types.ControlActionPermissionResponse, resp); err != nil {
+		s.handleError(err)
+	}
+}
+
+func (s *Session) decidePermission(req types.CanUseToolRequest) types.PermissionDecision {
+	if s.permissionRules.IsAllowed(req.ToolName) {
+		return types.Allow()
+	}
+	if s.options.CanUseTool == nil {
+		return types.Deny("no permission callback configured")
+	}
+
+	ctx, cancel := s.contextUntilClose()
+	defer cancel()
+
+	decision, err := s.callCanUseTool(ctx, req)
+	if err != nil {
+		return types.Deny("permission check failed: " + err.Error())
+	}
+
+	switch decision.Behavior {
+	case types.PermissionBehaviorAllow:
+		if decision.AlwaysAllow {
+			s.permissionRules.Allow(req.ToolName)
+		}
+	case types.PermissionBehaviorDeny:
+	default:
+		return types.Deny("invalid permission decision: " + string(decision.Behavior))
+	}
+	return decision
+}
+
+// callCanUseTool runs the permission callback, converting a panic into an
+// error that is also reported to the error handlers.
+func (s *Session) callCanUseTool(ctx context.Context, req types.CanUseToolRequest) (decision types.PermissionDecision, err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			err = types.InternalError(fmt.Sprintf("permission callback panicked: %v", r)).
+				WithDetails(map[string]any{
+					"toolName": req.ToolName,
+					"stack":    string(debug.Stack()),
+				})
+			s.handleError(err)
+		}
+	}()
+	return s.options.CanUseTool(ctx, req.ToolName, req.Input)
+}
@@ -0,0 +1,78 @@
+package chucky
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/chucky-cloud/chucky-sdk-go/pkg/types"
+)
+
+func TestCanUseToolDecisions(t *testing.T) {
+	tests := []struct {
+		name        string
+		callback    types.CanUseToolFunc
+		want        types.PermissionBehavior
+		wantMessage string
+		wantErr     bool // Reported to OnError
+	}{
+		{"allow", func(ctx context.Context, tool string, input map[string]any) (types.PermissionDecision, error) {
+			return types.Allow(), nil
+		}, types.PermissionBehaviorAllow, "", false},
+		{"error", func(ctx context.Context, tool string, input map[string]any) (types.PermissionDecision, error) {
+			return types.PermissionDecision{}, errors.New("backend down")
+		}, types.PermissionBehaviorDeny, "backend down", false},
+		{"invalid", func(ctx context.Context, tool string, input map[string]any) (types.PermissionDecision, error) {
+			return types.PermissionDecision{Behavior: "maybe"}, nil
+		}, types.PermissionBehaviorDeny, "invalid permission decision", false},
+		{"panic", func(ctx context.Context, tool string, input map[string]any) (types.PermissionDecision, error) {
+			var m map[string]bool
+			m[tool] = true
+			return types.Allow(), nil
+		}, types.PermissionBehaviorDeny, "panicked", true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			f := newFakeServer(t)
+			opts := &types.SessionOptions{CanUseTool: tt.callback}
+			session, conn := connectSession(t, f, f.client(types.ClientOptions{}), opts)
+			errCh := make(chan error, 1)
+			session.On(SessionEventHandlers{OnError: func(err error) {
+				select {
+				case errCh <- err:
+				default:
+				}
+			}})
+
+			conn.send(types.ControlEnvelope{
+				Type: types.MessageTypeControl,
+				Payload: types.ControlPayload{
+					Action: types.ControlActionCanUseTool,
+					Data:   map[string]any{"requestId": "perm-1", "toolName": "Bash", "input": map[string]any{}},
+				},
+			})
+			resp := conn.recvControl(types.ControlActionPermissionResponse)
+			if resp["requestId"] != "perm-1" || resp["behavior"] != string(tt.want) {
+				t.Fatalf("response = %v, want %s", resp, tt.want)
+			}
+			if msg, _ := resp["message"].(string); !strings.Contains(msg, tt.wantMessage) {
+				t.Errorf("message = %q, want %q", msg, tt.wantMessage)
+			}
+
+			select {
+			case err := <-errCh:
+				if !tt.wantErr {
+					t.Errorf("unexpected error: %v", err)
+				} else if !errors.Is(err, types.ErrInternal) {
+					t.Errorf("error = %v, want internal error", err)
+				}
+			case <-time.After(100 * time.Millisecond):
+				if tt.wantErr {
+					t.Error("panic not reported")
+				}
+			}
+		})
+	}
+}
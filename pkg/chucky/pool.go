@@ -0,0 +1,346 @@
+package chucky
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/chucky-cloud/chucky-sdk-go/pkg/types"
+)
+
+// SessionPoolOptions configures a SessionPool.
+type SessionPoolOptions struct {
+	// Session is the options profile used for every pooled session.
+	Session *types.SessionOptions
+
+	// Size is the number of warm sessions to keep ready. Default: 2.
+	Size int
+
+	// MaxSize caps the sessions the pool has open, idle and handed out.
+	// Acquire waits when it is reached. Default: 4 * Size.
+	MaxSize int
+
+	// IdleTTL closes warm sessions that were not acquired in time.
+	// Default: 5 minutes.
+	IdleTTL time.Duration
+
+	// ConnectTimeout bounds each background connect. Default: the client timeout.
+	ConnectTimeout time.Duration
+}
+
+// PoolMetrics is a snapshot of a pool's state and counters.
+type PoolMetrics struct {
+	Idle       int
+	Connecting int
+	InUse      int
+
+	Created      int64 // Sessions connected successfully
+	ConnectFails int64
+	Acquired     int64
+	Evicted      int64 // Idle sessions closed for TTL or health
+
+	AcquireWait time.Duration // Total time spent waiting in Acquire
+}
+
+// SessionPool keeps pre-connected sessions so Acquire can skip the
+// connection and init round trips. Acquired sessions belong to the caller,
+// who must Close them; the pool replaces them in the background.
+type SessionPool struct {
+	client *Client
+	opts   SessionPoolOptions
+
+	mu         sync.Mutex
+	idle       []pooledSession
+	connecting int
+	inUse      map[*Session]struct{}
+	changed    chan struct{} // Closed and replaced on every state change
+	closed     bool
+	failures   int
+	lastErr    error
+	retryAt    time.Time // No new connects before this after failures
+	metrics    PoolMetrics
+
+	stopCh chan struct{}
+	wg     sync.WaitGroup
+}
+
+type pooledSession struct {
+	session *Session
+	readyAt time.Time
+}
+
+// NewSessionPool creates a pool and starts warming sessions.
+func (c *Client) NewSessionPool(opts SessionPoolOptions) *SessionPool {
+	if opts.Session == nil {
+		opts.Session = &types.SessionOptions{}
+	}
+	if opts.Size <= 0 {
+		opts.Size = 2
+	}
+	if opts.MaxSize <= 0 {
+		opts.MaxSize = 4 * opts.Size
+	}
+	if opts.MaxSize < opts.Size {
+		opts.MaxSize = opts.Size
+	}
+	if opts.IdleTTL <= 0 {
+		opts.IdleTTL = 5 * time.Minute
+	}
+	if opts.ConnectTimeout <= 0 {
+		opts.ConnectTimeout = c.options.Timeout
+	}
+
+	p := &SessionPool{
+		client:  c,
+		opts:    opts,
+		inUse:   make(map[*Session]struct{}),
+		changed: make(chan struct{}),
+		stopCh:  make(chan struct{}),
+	}
+
+	p.mu.Lock()
+	p.fillLocked()
+	p.mu.Unlock()
+
+	p.wg.Add(1)
+	go p.maintain()
+
+	return p
+}
+
+// Acquire returns a connected session, waiting for one if none is idle.
+func (p *SessionPool) Acquire(ctx context.Context) (*Session, error) {
+	start := time.Now()
+	for {
+		p.mu.Lock()
+		if p.closed {
+			p.mu.Unlock()
+			return nil, types.SessionError("session pool closed")
+		}
+
+		for len(p.idle) > 0 {
+			ps := p.idle[0]
+			p.idle = p.idle[1:]
+			if !p.healthy(ps) {
+				p.evictLocked(ps)
+				continue
+			}
+
+			p.inUse[ps.session] = struct{}{}
+			p.metrics.Acquired++
+			p.metrics.AcquireWait += time.Since(start)
+			p.fillLocked()
+			p.notifyLocked()
+			p.mu.Unlock()
+
+			p.wg.Add(1)
+			go p.watch(ps.session)
+			return ps.session, nil
+		}
+
+		p.fillLocked()
+		changed := p.changed
+		p.mu.Unlock()
+
+		select {
+		case <-changed:
+		case <-ctx.Done():
+			p.mu.Lock()
+			p.metrics.AcquireWait += time.Since(start)
+			lastErr := p.lastErr
+			p.mu.Unlock()
+			if lastErr == nil {
+				lastErr = ctx.Err()
+			}
+			return nil, types.TimeoutError("no pooled session available").Wrap(lastErr)
+		}
+	}
+}
+
+// Metrics returns a snapshot of the pool metrics.
+func (p *SessionPool) Metrics() PoolMetrics {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	m := p.metrics
+	m.Idle = len(p.idle)
+	m.Connecting = p.connecting
+	m.InUse = len(p.inUse)
+	return m
+}
+
+// Close closes idle sessions and stops warming new ones. Acquired sessions
+// are left to their owners.
+func (p *SessionPool) Close() {
+	p.mu.Lock()
+	if p.closed {
+		p.mu.Unlock()
+		return
+	}
+	p.closed = true
+	idle := p.idle
+	p.idle = nil
+	close(p.stopCh)
+	p.notifyLocked()
+	p.mu.Unlock()
+
+	for _, ps := range idle {
+		ps.session.Close()
+	}
+	p.wg.Wait()
+}
+
+// fillLocked starts connecting sessions until Size are idle or connecting,
+// within MaxSize. Callers must hold mu.
+func (p *SessionPool) fillLocked() {
+	if p.closed || time.Now().Before(p.retryAt) {
+		return
+	}
+	for len(p.idle)+p.connecting < p.opts.Size &&
+		len(p.idle)+p.connecting+len(p.inUse) < p.opts.MaxSize {
+		p.connecting++
+		p.wg.Add(1)
+		go p.connect()
+	}
+}
+
+func (p *SessionPool) connect() {
+	defer p.wg.Done()
+
+	opts := *p.opts.Session
+	session := p.client.CreateSession(&opts)
+
+	ctx, cancel := context.WithTimeout(context.Background(), p.opts.ConnectTimeout)
+	go func() {
+		select {
+		case <-p.stopCh:
+			cancel()
+		case <-ctx.Done():
+		}
+	}()
+	err := session.Connect(ctx)
+	cancel()
+
+	p.mu.Lock()
+	p.connecting--
+	if p.closed {
+		p.mu.Unlock()
+		session.Close()
+		return
+	}
+	if err != nil {
+		p.metrics.ConnectFails++
+		p.failures++
+		p.lastErr = err
+		delay := p.retryDelay(err)
+		p.retryAt = time.Now().Add(delay)
+		p.notifyLocked()
+		p.mu.Unlock()
+
+		session.Close()
+		p.retryLater(delay)
+		return
+	}
+
+	p.failures = 0
+	p.lastErr = nil
+	p.metrics.Created++
+	p.idle = append(p.idle, pooledSession{session: session, readyAt: time.Now()})
+	p.notifyLocked()
+	p.mu.Unlock()
+}
+
+// retryDelay returns the backoff after consecutive connect failures.
+// Callers must hold mu.
+func (p *SessionPool) retryDelay(err error) time.Duration {
+	delay := p.client.options.RetryPolicy.Delay(p.failures, err)
+	if delay <= 0 {
+		delay = time.Second << min(p.failures-1, 5)
+	}
+	return delay
+}
+
+// retryLater refills the pool once the backoff has passed.
+func (p *SessionPool) retryLater(delay time.Duration) {
+	select {
+	case <-time.After(delay):
+	case <-p.stopCh:
+		return
+	}
+
+	p.mu.Lock()
+	p.fillLocked()
+	p.mu.Unlock()
+}
+
+// watch releases the in-use slot when an acquired session closes.
+func (p *SessionPool) watch(s *Session) {
+	defer p.wg.Done()
+	select {
+	case <-s.closeCh:
+	case <-p.stopCh:
+		return
+	}
+
+	p.mu.Lock()
+	delete(p.inUse, s)
+	p.fillLocked()
+	p.notifyLocked()
+	p.mu.Unlock()
+}
+
+// maintain evicts expired or unhealthy idle sessions and refills the pool.
+func (p *SessionPool) maintain() {
+	defer p.wg.Done()
+
+	interval := p.opts.IdleTTL / 2
+	if interval > 30*time.Second {
+		interval = 30 * time.Second
+	}
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-p.stopCh:
+			return
+		case <-ticker.C:
+		}
+
+		p.mu.Lock()
+		kept := p.idle[:0]
+		for _, ps := range p.idle {
+			if p.healthy(ps) {
+				kept = append(kept, ps)
+			} else {
+				p.evictLocked(ps)
+			}
+		}
+		p.idle = kept
+		p.fillLocked()
+		p.mu.Unlock()
+	}
+}
+
+func (p *SessionPool) healthy(ps pooledSession) bool {
+	if time.Since(ps.readyAt) > p.opts.IdleTTL {
+		return false
+	}
+	select {
+	case <-ps.session.closeCh:
+		return false
+	default:
+	}
+	return ps.session.State() == SessionStateReady
+}
+
+// evictLocked closes an idle session asynchronously. Callers must hold mu.
+func (p *SessionPool) evictLocked(ps pooledSession) {
+	p.metrics.Evicted++
+	go ps.session.Close()
+}
+
+// notifyLocked wakes goroutines waiting in Acquire. Callers must hold mu.
+func (p *SessionPool) notifyLocked() {
+	close(p.changed)
+	p.changed = make(chan struct{})
+}
@@ -0,0 +1,123 @@
+package chucky
+
+import (
+	"sort"
+	"sync"
+
+	"github.com/chucky-cloud/chucky-sdk-go/pkg/types"
+)
+
+// sessionRegistry tracks the open sessions of a client. Sessions are keyed
+// by their local ID until the server assigns an ID, then re-keyed; the local
+// ID remains an alias so either can be used for lookups.
+type sessionRegistry struct {
+	mu      sync.RWMutex
+	byID    map[string]*Session
+	aliases map[string]string // Local ID -> server ID
+}
+
+func newSessionRegistry() *sessionRegistry {
+	return &sessionRegistry{
+		byID:    make(map[string]*Session),
+		aliases: make(map[string]string),
+	}
+}
+
+func (r *sessionRegistry) add(s *Session) {
+	r.mu.Lock()
+	r.byID[s.localID] = s
+	r.mu.Unlock()
+}
+
+// rekey moves a session from its local ID to the server-assigned ID. It
+// reports false if the session is no longer registered. If another open
+// session already has the server ID, the session stays registered under its
+// local ID and an error is returned.
+func (r *sessionRegistry) rekey(s *Session, serverID string) (bool, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	key := s.localID
+	if prev, ok := r.aliases[s.localID]; ok {
+		key = prev
+	}
+	if r.byID[key] != s {
+		return false, nil
+	}
+	if other, ok := r.byID[serverID]; ok && other != s {
+		return true, types.SessionError("another open session has the same ID").WithDetails(map[string]any{
+			"sessionId":    serverID,
+			"localId":      s.localID,
+			"otherLocalId": other.localID,
+		})
+	}
+
+	delete(r.byID, key)
+	r.byID[serverID] = s
+	r.aliases[s.localID] = serverID
+	return true, nil
+}
+
+func (r *sessionRegistry) remove(s *Session) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	key := s.localID
+	if serverID, ok := r.aliases[s.localID]; ok {
+		key = serverID
+		delete(r.aliases, s.localID)
+	}
+	if r.byID[key] == s {
+		delete(r.byID, key)
+	}
+}
+
+func (r *sessionRegistry) get(id string) (*Session, bool) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	if serverID, ok := r.aliases[id]; ok {
+		id = serverID
+	}
+	s, ok := r.byID[id]
+	return s, ok
+}
+
+// list returns the registered sessions in creation order.
+func (r *sessionRegistry) list() []*Session {
+	r.mu.RLock()
+	sessions := make([]*Session, 0, len(r.byID))
+	for _, s := range r.byID {
+		sessions = append(sessions, s)
+	}
+	r.mu.RUnlock()
+
+	sort.Slice(sessions, func(i, j int) bool {
+		return sessions[i].seq < sessions[j].seq
+	})
+	return sessions
+}
+
+func (r *sessionRegistry) len() int {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	return len(r.byID)
+}
+
+// Sessions returns the client's open sessions in creation order.
+func (c *Client) Sessions() []*Session {
+	return c.registry.list()
+}
+
+// Session returns the open session with the given server-assigned or
+// local ID. If several open sessions have the same server ID, e.g. after
+// resuming a session twice, the server ID finds the first one; the others
+// report an error and remain available by their local ID.
+func (c *Client) Session(id string) (*Session, bool) {
+	return c.registry.get(id)
+}
+
+// ActiveCount returns the number of open sessions.
+func (c *Client) ActiveCount() int {
+	return c.registry.len()
+}
@@ -0,0 +1,57 @@
+package chucky
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/chucky-cloud/chucky-sdk-go/pkg/types"
+)
+
+func TestRegistryRekey(t *testing.T) {
+	r := newSessionRegistry()
+	a := &Session{localID: "local-a", seq: 1}
+	b := &Session{localID: "local-b", seq: 2}
+	r.add(a)
+	r.add(b)
+
+	if ok, err := r.rekey(a, "srv"); !ok || err != nil {
+		t.Fatalf("rekey a = %v, %v", ok, err)
+	}
+	ok, err := r.rekey(b, "srv")
+	if !ok || !errors.Is(err, types.ErrSession) {
+		t.Fatalf("rekey b to a's ID = %v, %v; want a session error", ok, err)
+	}
+
+	lookups := []struct {
+		id   string
+		want *Session
+	}{
+		{"srv", a},
+		{"local-a", a},
+		{"local-b", b},
+	}
+	for _, l := range lookups {
+		if got, _ := r.get(l.id); got != l.want {
+			t.Errorf("get(%q) = %v, want %v", l.id, got, l.want)
+		}
+	}
+	if r.len() != 2 {
+		t.Errorf("len = %d, want 2", r.len())
+	}
+
+	// Once a is closed, b can take the ID
+	r.remove(a)
+	if ok, err := r.rekey(b, "srv"); !ok || err != nil {
+		t.Fatalf("rekey b after remove = %v, %v", ok, err)
+	}
+	if got, _ := r.get("srv"); got != b {
+		t.Errorf("get(srv) = %v, want b", got)
+	}
+	r.remove(b)
+	if ok, _ := r.rekey(b, "other"); ok {
+		t.Error("rekey of a removed session reported registered")
+	}
+	if r.len() != 0 {
+		t.Errorf("len = %d after removing all", r.len())
+	}
+}
@@ -0,0 +1,180 @@
+package chucky
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/gorilla/websocket"
+
+	"github.com/chucky-cloud/chucky-sdk-go/pkg/types"
+)
+
+// fakeServer is an in-process websocket server speaking the session
+// protocol. Each connection answers the init message with control:ready and
+// is then handed to the test.
+type fakeServer struct {
+	t     *testing.T
+	srv   *httptest.Server
+	conns chan *fakeConn
+}
+
+// fakeConn is one client connection to a fakeServer.
+type fakeConn struct {
+	t       *testing.T
+	ws      *websocket.Conn
+	writeMu sync.Mutex
+	in      chan map[string]any // Messages from the client after init
+	init    map[string]any
+	token   string // Token the client connected with
+}
+
+func newFakeServer(t *testing.T) *fakeServer {
+	t.Helper()
+	f := &fakeServer{t: t, conns: make(chan *fakeConn, 4)}
+	upgrader := websocket.Upgrader{CheckOrigin: func(*http.Request) bool { return true }}
+	f.srv = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		ws, err := upgrader.Upgrade(w, r, nil)
+		if err != nil {
+			return
+		}
+		c := &fakeConn{t: t, ws: ws, in: make(chan map[string]any, 1024), token: r.URL.Query().Get("token")}
+		if err := ws.ReadJSON(&c.init); err != nil {
+			ws.Close()
+			return
+		}
+		c.send(types.ControlEnvelope{Type: types.MessageTypeControl, Payload: types.ControlPayload{Action: types.ControlActionReady}})
+		f.conns <- c
+
+		for {
+			var msg map[string]any
+			if err := ws.ReadJSON(&msg); err != nil {
+				close(c.in)
+				return
+			}
+			if msg["type"] == string(types.MessageTypePing) {
+				continue
+			}
+			c.in <- msg
+		}
+	}))
+	t.Cleanup(f.srv.Close)
+	return f
+}
+
+// client returns a client connected to the server.
+func (f *fakeServer) client(opts types.ClientOptions) *Client {
+	opts.BaseURL = "ws" + strings.TrimPrefix(f.srv.URL, "http")
+	if opts.Token == "" && opts.TokenProvider == nil {
+		opts.Token = "test-token"
+	}
+	if opts.Timeout == 0 {
+		opts.Timeout = 5 * time.Second
+	}
+	return NewClient(opts)
+}
+
+// accept waits for the next connection.
+func (f *fakeServer) accept() *fakeConn {
+	f.t.Helper()
+	select {
+	case c := <-f.conns:
+		return c
+	case <-time.After(5 * time.Second):
+		f.t.Fatal("no connection")
+		return nil
+	}
+}
+
+// send writes v to the client as JSON.
+func (c *fakeConn) send(v any) {
+	c.writeMu.Lock()
+	defer c.writeMu.Unlock()
+	if err := c.ws.WriteJSON(v); err != nil {
+		c.t.Errorf("write to client: %v", err)
+	}
+}
+
+// sendRaw writes a JSON document to the client.
+func (c *fakeConn) sendRaw(data string) {
+	c.writeMu.Lock()
+	defer c.writeMu.Unlock()
+	if err := c.ws.WriteMessage(websocket.TextMessage, []byte(data)); err != nil {
+		c.t.Errorf("write to client: %v", err)
+	}
+}
+
+// recv returns the next message from the client.
+func (c *fakeConn) recv() map[string]any {
+	c.t.Helper()
+	select {
+	case msg, ok := <-c.in:
+		if !ok {
+			c.t.Fatal("connection closed")
+		}
+		return msg
+	case <-time.After(5 * time.Second):
+		c.t.Fatal("no message from client")
+		return nil
+	}
+}
+
+// recvControl returns the payload of the next control message with the
+// given action, skipping other messages.
+func (c *fakeConn) recvControl(action types.ControlAction) map[string]any {
+	c.t.Helper()
+	for {
+		msg := c.recv()
+		payload, _ := msg["payload"].(map[string]any)
+		if msg["type"] == string(types.MessageTypeControl) && payload["action"] == string(action) {
+			data, _ := payload["data"].(map[string]any)
+			return data
+		}
+	}
+}
+
+// respond answers a control request.
+func (c *fakeConn) respond(request map[string]any, data any) {
+	c.send(types.ControlEnvelope{
+		Type: types.MessageTypeControl,
+		Payload: types.ControlPayload{
+			Action: types.ControlActionResponse,
+			Data: map[string]any{
+				"requestId": request["requestId"],
+				"success":   true,
+				"data":      data,
+			},
+		},
+	})
+}
+
+// assistantText returns an assistant message frame with the given text.
+func assistantText(text string) string {
+	raw, _ := json.Marshal(map[string]any{
+		"type": "assistant",
+		"message": map[string]any{
+			"role":    "assistant",
+			"content": []any{map[string]any{"type": "text", "text": text}},
+		},
+	})
+	return string(raw)
+}
+
+// connectSession creates and connects a session on the server.
+func connectSession(t *testing.T, f *fakeServer, client *Client, opts *types.SessionOptions) (*Session, *fakeConn) {
+	t.Helper()
+	session := client.CreateSession(opts)
+	errCh := make(chan error, 1)
+	go func() { errCh <- session.Connect(context.Background()) }()
+	conn := f.accept()
+	if err := <-errCh; err != nil {
+		t.Fatalf("Connect: %v", err)
+	}
+	t.Cleanup(session.Close)
+	return session, conn
+}
@@ -4,9 +4,12 @@ import (
 	"context"
 	"encoding/json"
 	"sync"
+	"sync/atomic"
+	"time"
 
 	"github.com/google/uuid"
 
+	"github.com/chucky-cloud/chucky-sdk-go/pkg/tools"
 	"github.com/chucky-cloud/chucky-sdk-go/pkg/transport"
 	"github.com/chucky-cloud/chucky-sdk-go/pkg/types"
 )
@@ -22,6 +25,8 @@ const (
 	SessionStateWaitingTool SessionState = "waiting_tool"
 	SessionStateCompleted   SessionState = "completed"
 	SessionStateError       SessionState = "error"
+	SessionStateReconnecting SessionState = "reconnecting"
+	SessionStateClosed      SessionState = "closed"
 )
 
 // SessionEventHandlers contains callbacks for session events.
@@ -29,54 +34,139 @@ type SessionEventHandlers struct {
 	OnMessage func(msg types.IncomingMessage)
 	OnError   func(err error)
 	OnClose   func()
+
+	// OnStateChange is called after every state transition.
+	OnStateChange func(from, to SessionState)
+
+	// OnCompact is called when the server compacts the conversation context.
+	OnCompact func(meta types.CompactMetadata)
 }
 
 // Session manages a multi-turn conversation with Claude.
 type Session struct {
-	client    *Client
-	transport transport.Transport
+	client      *Client
+	transport   transport.Transport
+	transportMu sync.RWMutex
 	options   types.SessionOptions
-	sessionID string
+	optionsErr error // From SessionOptions.Validate, returned by Connect
+	localID   string // Client-side ID, valid before the server assigns one
+	seq       uint64 // Creation order within the client
+	sessionID string // Guarded by idMu
+	idMu      sync.RWMutex
+	info      *types.SystemInitData // Guarded by idMu
+	infoCh    chan struct{}         // Closed when info first arrives
 	state     SessionState
 	stateMu   sync.RWMutex
+	resumeState SessionState // State to restore after reconnecting, guarded by stateMu
 	handlers  SessionEventHandlers
 
 	connected    bool
 	connectedMu  sync.RWMutex
 
-	msgCh        chan types.IncomingMessage
+	msgCh        chan types.IncomingMessage // Read by Stream
+	streamReaders atomic.Int32             // Active Stream calls
+	streamLeft   chan struct{}              // Signalled when a Stream call ends
+	content      *contentQueue
+	hub          *messageHub
 	errCh        chan error
 	closeCh      chan struct{}
 	closeOnce    sync.Once
+	doneCh       chan struct{}
+	doneOnce     sync.Once
+	stateCh      chan struct{} // Closed and replaced on state changes, guarded by stateMu
+	draining     atomic.Bool   // Set by CloseGracefully, rejects new turns
+
+	// ctx is cancelled on Close and parents tool, hook and permission calls
+	ctx    context.Context
+	cancel context.CancelFunc
+	wg     sync.WaitGroup // SDK goroutines owned by the session
 
-	// For waiting on server ready
-	readyCh      chan struct{}
-	readyOnce    sync.Once
-	initErr      error
+	// For waiting on server ready, replaced on every connect attempt
+	initWait   *initWaiter
+	initWaitMu sync.Mutex
 
+	// toolsMu also guards options that can change after creation
 	toolHandlers map[string]types.ToolHandler
 	toolsMu      sync.RWMutex
+	initSent     bool       // Guarded by toolsMu
+	updateMu     sync.Mutex // Held across a tool list change and its update_mcp_server message
+	toolCalls    atomic.Int64
+	toolsActive  atomic.Int32 // Tool calls currently running
+
+	// Cumulative cost and turns of the last result, as reported by the
+	// server, so the ledger records each turn's share once
+	costMu       sync.Mutex
+	costSeen     bool
+	lastCostUsd  float64
+	lastNumTurns int
+
+	// Local stdio MCP servers by name, started on the first connect
+	localMcpServers map[string]*localMcpServer // Guarded by toolsMu
+
+	// Control requests awaiting a control_response
+	pending   map[string]chan *types.ControlResponse
+	pendingMu sync.Mutex
+
+	permissionRules *types.PermissionRules
+	hooks           map[string]hookRegistration
 }
 
 func newSession(client *Client, t transport.Transport, opts types.SessionOptions) *Session {
+	// Copy server list so AddTools/RemoveTool don't touch the caller's slice.
+	// Agent servers are added so their tools are exposed and handled.
+	opts.McpServers = opts.AllMcpServers()
+
+	ctx, cancel := context.WithCancel(context.Background())
+
+	bufferSize := opts.MessageBufferSize
+	if bufferSize <= 0 {
+		bufferSize = 100
+	}
+
 	// Don't generate sessionID - server will assign it
 	s := &Session{
 		client:       client,
 		transport:    t,
 		options:      opts,
+		localID:      "local_" + uuid.New().String(),
 		sessionID:    "", // Will be assigned by server in system:init
 		state:        SessionStateIdle,
-		msgCh:        make(chan types.IncomingMessage, 100),
+		msgCh:        make(chan types.IncomingMessage, bufferSize),
+		streamLeft:   make(chan struct{}, 1),
+		content:      newContentQueue(bufferSize),
+		hub:          newMessageHub(),
 		errCh:        make(chan error, 10),
 		closeCh:      make(chan struct{}),
-		readyCh:      make(chan struct{}),
+		doneCh:       make(chan struct{}),
+		stateCh:      make(chan struct{}),
+		infoCh:       make(chan struct{}),
+		ctx:          ctx,
+		cancel:       cancel,
 		toolHandlers: make(map[string]types.ToolHandler),
+		pending:      make(map[string]chan *types.ControlResponse),
+	}
+
+	s.permissionRules = opts.PermissionRules
+	if s.permissionRules == nil {
+		s.permissionRules = types.NewPermissionRules()
 	}
 
 	// Extract tool handlers from MCP servers
 	s.extractToolHandlers()
 
+	// Assign callback IDs to hooks
+	s.registerHooks()
+
+	// Deliver content frames off the transport read loop
+	s.goTracked(s.dispatchContent)
+
 	// Set up transport handlers
+	s.attachTransport(t)
+
+	return s
+}
+
+func (s *Session) attachTransport(t transport.Transport) {
 	t.SetEventHandlers(transport.TransportEvents{
 		OnMessage:      s.handleMessage,
 		OnClose:        s.handleClose,
@@ -84,14 +174,74 @@ func newSession(client *Client, t transport.Transport, opts types.SessionOptions
 		OnError:        s.handleError,
 	})
 
-	return s
+	s.transportMu.Lock()
+	s.transport = t
+	s.transportMu.Unlock()
+}
+
+func (s *Session) getTransport() transport.Transport {
+	s.transportMu.RLock()
+	defer s.transportMu.RUnlock()
+	return s.transport
+}
+
+// initWaiter is signalled when the server is ready or initialization fails.
+type initWaiter struct {
+	ch   chan struct{}
+	once sync.Once
+	err  error
 }
 
-// ID returns the session ID.
+func (w *initWaiter) done(err error) {
+	w.once.Do(func() {
+		w.err = err
+		close(w.ch)
+	})
+}
+
+// signalInit completes the current connect attempt, if any.
+func (s *Session) signalInit(err error) {
+	s.initWaitMu.Lock()
+	w := s.initWait
+	s.initWaitMu.Unlock()
+	if w != nil {
+		w.done(err)
+	}
+}
+
+// ID returns the server-assigned session ID, or "" until system:init arrives.
 func (s *Session) ID() string {
+	s.idMu.RLock()
+	defer s.idMu.RUnlock()
 	return s.sessionID
 }
 
+// LocalID returns the client-side session ID. It is available immediately
+// and can be used with Client.Session before the server assigns an ID.
+func (s *Session) LocalID() string {
+	return s.localID
+}
+
+// assignID records the server-assigned session ID and re-keys the session
+// in the client registry. OnSessionStart fires the first time.
+func (s *Session) assignID(id string) {
+	s.idMu.Lock()
+	prev := s.sessionID
+	s.sessionID = id
+	s.idMu.Unlock()
+	if prev == id {
+		return
+	}
+
+	registered, err := s.client.registry.rekey(s, id)
+	if err != nil {
+		s.handleError(err)
+	}
+	if registered && prev == "" {
+		s.client.notifySessionStart(id)
+	}
+}
+
 // State returns the current session state.
 func (s *Session) State() SessionState {
 	s.stateMu.RLock()
@@ -99,12 +249,6 @@ func (s *Session) State() SessionState {
 	return s.state
 }
 
-func (s *Session) setState(state SessionState) {
-	s.stateMu.Lock()
-	s.state = state
-	s.stateMu.Unlock()
-}
-
 // Connect establishes the connection and initializes the session.
 func (s *Session) Connect(ctx context.Context) error {
 	s.connectedMu.Lock()
@@ -114,50 +258,136 @@ func (s *Session) Connect(ctx context.Context) error {
 	}
 	s.connectedMu.Unlock()
 
+	select {
+	case <-s.closeCh:
+		return types.SessionError("session closed")
+	default:
+	}
+	if s.client.shutdown.Load() {
+		return types.SessionError("client is shutting down")
+	}
+	if s.optionsErr != nil {
+		return s.optionsErr
+	}
+
+	if err := s.client.checkBudget(); err != nil {
+		return err
+	}
+
+	// Local servers must be running before init so their tools can be listed
+	if err := s.startLocalMcpServers(ctx); err != nil {
+		return err
+	}
+
 	s.setState(SessionStateInitializing)
 
-	if err := s.transport.Connect(); err != nil {
-		s.setState(SessionStateError)
+	policy := s.client.options.RetryPolicy
+	for attempt := 1; ; attempt++ {
+		err := s.connectOnce(ctx)
+		if err == nil {
+			break
+		}
+
+		select {
+		case <-s.closeCh:
+			return err
+		default:
+		}
+		if ctx.Err() != nil || !policy.ShouldRetry(attempt, err) {
+			// Leave a fresh transport so a later Connect does not dial
+			// over the failed connection
+			s.resetTransport()
+			s.setState(SessionStateError)
+			return err
+		}
+
+		// Retry on a fresh connection
+		s.resetTransport()
+
+		select {
+		case <-time.After(policy.Delay(attempt, err)):
+		case <-ctx.Done():
+			s.setState(SessionStateError)
+			return ctx.Err()
+		case <-s.closeCh:
+			return types.SessionError("session closed during initialization")
+		}
+	}
+
+	s.connectedMu.Lock()
+	s.connected = true
+	s.connectedMu.Unlock()
+
+	s.setState(SessionStateReady)
+
+	return nil
+}
+
+// connectOnce makes a single attempt to connect and initialize.
+func (s *Session) connectOnce(ctx context.Context) error {
+	w := &initWaiter{ch: make(chan struct{})}
+	s.initWaitMu.Lock()
+	s.initWait = w
+	s.initWaitMu.Unlock()
+
+	t := s.getTransport()
+
+	if err := t.Connect(); err != nil {
 		return err
 	}
 
-	if err := s.transport.WaitForReady(); err != nil {
-		s.setState(SessionStateError)
+	if err := t.WaitForReady(); err != nil {
 		return err
 	}
 
 	// Send init message
 	if err := s.sendInit(); err != nil {
-		s.setState(SessionStateError)
 		return err
 	}
 
 	// Wait for server to be ready (control:ready or system:init)
 	select {
-	case <-s.readyCh:
-		if s.initErr != nil {
-			s.setState(SessionStateError)
-			return s.initErr
-		}
+	case <-w.ch:
+		return w.err
 	case <-ctx.Done():
-		s.setState(SessionStateError)
 		return ctx.Err()
 	case <-s.closeCh:
-		s.setState(SessionStateError)
 		return types.SessionError("session closed during initialization")
 	}
+}
 
-	s.connectedMu.Lock()
-	s.connected = true
-	s.connectedMu.Unlock()
+// resetTransport replaces a failed transport with a fresh one so the next
+// connect attempt starts from a clean connection.
+func (s *Session) resetTransport() {
+	old := s.getTransport()
+	old.SetEventHandlers(transport.TransportEvents{})
+	_ = old.Disconnect()
 
-	s.setState(SessionStateReady)
-	s.client.notifySessionStart(s.sessionID)
+	s.toolsMu.Lock()
+	s.initSent = false
+	s.toolsMu.Unlock()
 
-	return nil
+	s.attachTransport(s.client.newTransport())
 }
 
 func (s *Session) sendInit() error {
+	// Holding the lock until the init is sent orders it before any
+	// AddTools/RemoveTool update.
+	s.toolsMu.Lock()
+	defer s.toolsMu.Unlock()
+	s.initSent = true
+
+	init := types.InitEnvelope{
+		Type:    types.MessageTypeInit,
+		Payload: s.initPayload(),
+	}
+
+	return s.getTransport().Send(init)
+}
+
+// initPayload converts the session options to the init payload. Callers
+// must hold toolsMu.
+func (s *Session) initPayload() types.InitPayload {
 	// Convert MCP servers to serializable format
 	var mcpServers any
 	if len(s.options.McpServers) > 0 {
@@ -171,29 +401,29 @@ func (s *Session) sendInit() error {
 		mcpServers = servers
 	}
 
-	init := types.InitEnvelope{
-		Type: types.MessageTypeInit,
-		Payload: types.InitPayload{
-			Model:                  s.options.Model,
-			FallbackModel:          s.options.FallbackModel,
-			SystemPrompt:           s.options.SystemPrompt,
-			MaxTurns:               s.options.MaxTurns,
-			MaxBudgetUsd:           s.options.MaxBudgetUsd,
-			MaxThinkingTokens:      s.options.MaxThinkingTokens,
-			Tools:                  s.options.Tools,
-			McpServers:             mcpServers,
-			PermissionMode:         s.options.PermissionMode,
-			OutputFormat:           s.options.OutputFormat,
-			IncludePartialMessages: s.options.IncludePartialMessages,
-			Env:                    s.options.Env,
-			// Note: SessionID is NOT sent - server assigns it in system:init
-			ForkSession:            s.options.ForkSession,
-			ResumeSessionAt:        s.options.ResumeSessionAt,
-			Continue:               s.options.Continue,
-		},
+	return types.InitPayload{
+		Model:                  s.options.Model,
+		FallbackModel:          s.options.FallbackModel,
+		SystemPrompt:           s.options.SystemPrompt,
+		MaxTurns:               s.options.MaxTurns,
+		MaxBudgetUsd:           s.options.MaxBudgetUsd,
+		MaxThinkingTokens:      s.options.MaxThinkingTokens,
+		Tools:                  s.options.Tools,
+		McpServers:             mcpServers,
+		Agents:                 s.agentsToMap(),
+		PermissionMode:         s.options.PermissionMode,
+		OutputFormat:           s.options.OutputFormat,
+		IncludePartialMessages: s.options.IncludePartialMessages,
+		Env:                    s.options.Env,
+		// Only set when resuming: the server assigns new sessions their ID in system:init
+		SessionID:              s.options.SessionID,
+		ForkSession:            s.options.ForkSession,
+		ResumeSessionAt:        s.options.ResumeSessionAt,
+		Continue:               s.options.Continue,
+		SettingSources:         s.options.SettingSources,
+		CanUseTool:             s.options.CanUseTool != nil,
+		Hooks:                  s.hooksToMap(),
 	}
-
-	return s.transport.Send(init)
 }
 
 func (s *Session) mcpServerToMap(server types.McpServerDefinition) map[string]any {
@@ -211,6 +441,8 @@ func (s *Session) mcpServerToMap(server types.McpServerDefinition) map[string]an
 			// This tells the server to send tool_call messages back to us
 			if tool.Handler != nil {
 				toolMap["executeIn"] = "client"
+			} else if tool.ExecuteIn != "" {
+				toolMap["executeIn"] = tool.ExecuteIn
 			}
 			tools = append(tools, toolMap)
 		}
@@ -220,6 +452,13 @@ func (s *Session) mcpServerToMap(server types.McpServerDefinition) map[string]an
 			"tools":   tools,
 		}
 	case types.McpStdioServerConfig:
+		if srv.Local {
+			// Exposed as a client tools server; nil until it has started
+			if local, ok := s.localMcpServers[srv.Name]; ok {
+				return local.toMap()
+			}
+			return nil
+		}
 		return map[string]any{
 			"name":    srv.Name,
 			"type":    "stdio",
@@ -258,10 +497,18 @@ func (s *Session) Send(ctx context.Context, message string) error {
 		}
 	}
 
+	switch state := s.State(); state {
+	case SessionStateClosed, SessionStateError:
+		return types.SessionError("session is " + string(state))
+	}
+	if s.draining.Load() {
+		return types.SessionError("session is closing")
+	}
 	s.setState(SessionStateProcessing)
+	s.hub.startTurn()
 
 	// Use server-assigned session ID, or "unknown" if not yet received
-	sessionID := s.sessionID
+	sessionID := s.ID()
 	if sessionID == "" {
 		sessionID = "unknown"
 	}
@@ -277,14 +524,24 @@ func (s *Session) Send(ctx context.Context, message string) error {
 		ParentToolUseID: nil,
 	}
 
-	return s.transport.Send(msg)
+	return s.getTransport().Send(msg)
 }
 
-// Stream returns a channel that yields incoming messages.
+// Stream returns a channel that yields incoming messages until the turn's
+// result. While no Stream call is active, the session keeps the latest
+// MessageBufferSize messages for the next call.
 func (s *Session) Stream(ctx context.Context) <-chan types.IncomingMessage {
 	out := make(chan types.IncomingMessage)
 
-	go func() {
+	s.streamReaders.Add(1)
+	s.goTracked(func() {
+		defer func() {
+			s.streamReaders.Add(-1)
+			select {
+			case s.streamLeft <- struct{}{}:
+			default:
+			}
+		}()
 		defer close(out)
 		for {
 			select {
@@ -304,14 +561,13 @@ func (s *Session) Stream(ctx context.Context) <-chan types.IncomingMessage {
 					return
 				}
 
-				// Check if session is complete
+				// The turn is complete
 				if _, ok := msg.(*types.SDKResultMessage); ok {
-					s.setState(SessionStateCompleted)
 					return
 				}
 			}
 		}
-	}()
+	})
 
 	return out
 }
@@ -321,10 +577,17 @@ func (s *Session) Receive(ctx context.Context) <-chan types.IncomingMessage {
 	return s.Stream(ctx)
 }
 
+// Interrupt asks the server to stop the current turn.
+func (s *Session) Interrupt() error {
+	return s.sendControl(types.ControlActionInterrupt, nil)
+}
+
 // Close closes the session.
 func (s *Session) Close() {
 	s.closeOnce.Do(func() {
 		close(s.closeCh)
+		s.cancel()
+		s.setState(SessionStateClosed)
 
 		// Send close control message
 		closeMsg := types.ControlEnvelope{
@@ -333,11 +596,12 @@ func (s *Session) Close() {
 				Action: types.ControlActionClose,
 			},
 		}
-		_ = s.transport.Send(closeMsg)
+		_ = s.getTransport().Send(closeMsg)
 
-		_ = s.transport.Disconnect()
+		_ = s.getTransport().Disconnect()
+		s.stopLocalMcpServers()
 
-		s.client.removeSession(s.sessionID)
+		s.client.removeSession(s)
 
 		if s.handlers.OnClose != nil {
 			s.handlers.OnClose()
@@ -363,54 +627,88 @@ func (s *Session) handleMessage(msg types.IncomingMessage) {
 		case *types.ControlEnvelope:
 			// Signal ready on control:ready - we can send user message before system:init
 			if m.Payload.Action == types.ControlActionReady || m.Payload.Action == types.ControlActionSessionInfo {
-				s.readyOnce.Do(func() {
-					close(s.readyCh)
-				})
+				s.signalInit(nil)
 				return
 			}
 		case *types.SDKSystemMessage:
 			if m.Subtype == types.SystemSubtypeInit {
-				// Update session ID from server when it arrives (may come after first user message)
-				if m.SessionID != "" {
-					s.sessionID = m.SessionID
-				}
 				// Also signal ready in case control:ready didn't come first
-				s.readyOnce.Do(func() {
-					close(s.readyCh)
-				})
+				s.signalInit(nil)
 				// Don't return - also forward to message channel
 			}
 		case *types.ErrorEnvelope:
-			s.initErr = types.SessionError(m.Payload.Message)
-			s.readyOnce.Do(func() {
-				close(s.readyCh)
-			})
+			s.signalInit(types.ErrorFromPayload(m.Payload))
 			// Forward error to channel too
 		}
 	}
 
-	// Handle tool calls internally
+	// Adopt the server session ID and capabilities when they arrive (may
+	// come after the first user message)
+	if m, ok := msg.(*types.SDKSystemMessage); ok && m.Subtype == types.SystemSubtypeInit {
+		if m.SessionID != "" {
+			s.assignID(m.SessionID)
+		}
+		if init, ok := m.InitData(); ok {
+			s.setInfo(init)
+		}
+	}
+
+	// Report server errors once the session is up
+	if errMsg, ok := msg.(*types.ErrorEnvelope); ok && connected {
+		s.handleError(types.ErrorFromPayload(errMsg.Payload))
+	}
+
+	// Track cost for the ledger and budget guard
+	if result, ok := msg.(*types.SDKResultMessage); ok {
+		s.setState(SessionStateCompleted)
+		s.client.recordResult(s, result)
+	}
+
+	// Keep-alive responses need no further handling
+	if _, ok := msg.(*types.PongEnvelope); ok {
+		return
+	}
+
+	// Run tool calls off the read loop so long handlers don't block it
 	if toolCall, ok := msg.(*types.ToolCallEnvelope); ok {
-		s.handleToolCall(toolCall)
+		s.startToolCall()
+		s.goTracked(func() { s.handleToolCall(toolCall) })
 		return
 	}
 
-	// Forward message to channel
-	select {
-	case s.msgCh <- msg:
-	case <-s.closeCh:
+	// Handle permission requests internally
+	if ctrl, ok := msg.(*types.ControlEnvelope); ok && ctrl.Payload.Action == types.ControlActionCanUseTool {
+		s.goTracked(func() { s.handleCanUseTool(ctrl.Payload.Data) })
+		return
+	}
+
+	// Deliver control responses to waiting requests
+	if ctrl, ok := msg.(*types.ControlEnvelope); ok && ctrl.Payload.Action == types.ControlActionResponse {
+		s.handleControlResponse(ctrl.Payload.Data)
+		return
 	}
 
-	if s.handlers.OnMessage != nil {
-		s.handlers.OnMessage(msg)
+	// Handle hook callbacks internally
+	if ctrl, ok := msg.(*types.ControlEnvelope); ok && ctrl.Payload.Action == types.ControlActionHookCallback {
+		s.goTracked(func() { s.handleHookCallback(ctrl.Payload.Data) })
+		return
 	}
+
+	// Content frames are delivered by dispatchContent
+	s.enqueueContent(msg)
 }
 
-func (s *Session) handleToolCall(call *types.ToolCallEnvelope) {
+// startToolCall records a tool call before its handler runs.
+func (s *Session) startToolCall() {
+	s.toolCalls.Add(1)
+	s.toolsActive.Add(1)
 	s.setState(SessionStateWaitingTool)
+}
+
+func (s *Session) handleToolCall(call *types.ToolCallEnvelope) {
 
 	s.toolsMu.RLock()
-	handler, ok := s.toolHandlers[call.Payload.ToolName]
+	handler, ok := s.toolHandler(call.Payload.ToolName)
 	s.toolsMu.RUnlock()
 
 	var result *types.ToolResult
@@ -436,8 +734,14 @@ func (s *Session) handleToolCall(call *types.ToolCallEnvelope) {
 			_ = json.Unmarshal(data, &input)
 		}
 
+		ctx := types.WithToolCallInfo(s.ctx, types.ToolCallInfo{
+			CallID:    call.Payload.CallID,
+			ToolName:  call.Payload.ToolName,
+			SessionID: s.ID(),
+		})
+
 		var err error
-		result, err = handler(context.Background(), input)
+		result, err = handler(ctx, input)
 		if err != nil {
 			result = &types.ToolResult{
 				Content: []any{
@@ -460,19 +764,69 @@ func (s *Session) handleToolCall(call *types.ToolCallEnvelope) {
 		},
 	}
 
-	if err := s.transport.Send(resultMsg); err != nil {
+	if err := s.getTransport().Send(resultMsg); err != nil {
 		s.handleError(err)
 	}
 
-	s.setState(SessionStateProcessing)
+	// Resume processing once the last concurrent tool call finishes, unless
+	// the turn has already moved on
+	if s.toolsActive.Add(-1) == 0 {
+		s.setStateIf(SessionStateWaitingTool, SessionStateProcessing)
+	}
+	s.notifyStateWaiters()
 }
 
-func (s *Session) handleClose(code int, reason string) {
-	s.Close()
+// toolCallCount returns the number of tool calls received so far.
+func (s *Session) toolCallCount() int64 {
+	return s.toolCalls.Load()
+}
+
+// sendControl sends a control message with the given action and data.
+func (s *Session) sendControl(action types.ControlAction, data any) error {
+	return s.getTransport().Send(types.ControlEnvelope{
+		Type: types.MessageTypeControl,
+		Payload: types.ControlPayload{
+			Action: action,
+			Data:   data,
+		},
+	})
+}
+
+// contextUntilClose returns a context that is cancelled when the session closes.
+func (s *Session) contextUntilClose() (context.Context, context.CancelFunc) {
+	return context.WithCancel(s.ctx)
+}
+
+// goTracked runs fn in a goroutine that CloseGracefully waits for.
+func (s *Session) goTracked(fn func()) {
+	s.wg.Add(1)
+	go func() {
+		defer s.wg.Done()
+		fn()
+	}()
+}
+
+// decodeData converts loosely typed message data into dst.
+func decodeData(data any, dst any) error {
+	raw, err := json.Marshal(data)
+	if err != nil {
+		return err
+	}
+	return json.Unmarshal(raw, dst)
 }
 
-func (s *Session) handleStatusChange(status transport.ConnectionStatus) {
-	// Could map transport status to session state
+func (s *Session) handleClose(code int, reason string) {
+	s.connectedMu.RLock()
+	connected := s.connected
+	s.connectedMu.RUnlock()
+
+	// During initialization, fail the connect attempt so it can be retried
+	if !connected {
+		s.signalInit(types.ConnectionError("connection closed during initialization: " + reason))
+		return
+	}
+
+	s.Close()
 }
 
 func (s *Session) handleError(err error) {
@@ -489,15 +843,28 @@ func (s *Session) handleError(err error) {
 }
 
 func (s *Session) extractToolHandlers() {
+	s.toolsMu.Lock()
+	defer s.toolsMu.Unlock()
 	for _, server := range s.options.McpServers {
 		if clientTools, ok := server.(types.McpClientToolsServer); ok {
 			for _, tool := range clientTools.Tools {
 				if tool.Handler != nil {
-					s.toolsMu.Lock()
-					s.toolHandlers[tool.Name] = tool.Handler
-					s.toolsMu.Unlock()
+					s.toolHandlers[tool.Name] = s.wrapToolHandler(clientTools, tool)
 				}
 			}
 		}
 	}
 }
+
+// wrapToolHandler composes the middleware chain for a tool handler.
+// Panic recovery is always outermost so a misbehaving handler cannot
+// take down the transport read loop.
+func (s *Session) wrapToolHandler(server types.McpClientToolsServer, tool types.ToolDefinition) types.ToolHandler {
+	middleware := []types.ToolMiddleware{tools.Recover()}
+	if s.client != nil {
+		middleware = append(middleware, s.client.toolMiddleware...)
+	}
+	middleware = append(middleware, server.Middleware...)
+	middleware = append(middleware, tool.Middleware...)
+	return types.ChainToolMiddleware(tool.Handler, middleware...)
+}
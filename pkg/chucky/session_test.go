@@ -0,0 +1,180 @@
+package chucky
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/gorilla/websocket"
+
+	"github.com/chucky-cloud/chucky-sdk-go/pkg/types"
+)
+
+func TestConcurrentToolCallsSerializeWrites(t *testing.T) {
+	const calls = 50
+
+	var running, maxRunning atomic.Int32
+	release := make(chan struct{})
+	echo := types.ToolDefinition{
+		Name:        "echo",
+		InputSchema: types.ToolInputSchema{Type: "object"},
+		Handler: func(ctx context.Context, input map[string]any) (*types.ToolResult, error) {
+			n := running.Add(1)
+			defer running.Add(-1)
+			for {
+				m := maxRunning.Load()
+				if n <= m || maxRunning.CompareAndSwap(m, n) {
+					break
+				}
+			}
+			<-release
+			return &types.ToolResult{Content: []any{types.TextToolContent{Type: "text", Text: fmt.Sprint(input["i"])}}}, nil
+		},
+	}
+
+	f := newFakeServer(t)
+	// Pings race the tool results
+	client := f.client(types.ClientOptions{KeepAliveInterval: time.Millisecond})
+	_, conn := connectSession(t, f, client, &types.SessionOptions{
+		BaseOptions: types.BaseOptions{
+			McpServers: []types.McpServerDefinition{types.McpClientToolsServer{Name: "tools", Tools: []types.ToolDefinition{echo}}},
+		},
+	})
+
+	for i := 0; i < calls; i++ {
+		conn.send(types.ToolCallEnvelope{
+			Type: types.MessageTypeToolCall,
+			Payload: types.ToolCallPayload{
+				CallID:   fmt.Sprintf("call_%d", i),
+				ToolName: "echo",
+				Input:    map[string]any{"i": i},
+			},
+		})
+	}
+	// Let the handlers pile up, then release them all at once
+	deadline := time.Now().Add(5 * time.Second)
+	for running.Load() < calls && time.Now().Before(deadline) {
+		time.Sleep(time.Millisecond)
+	}
+	close(release)
+
+	seen := make(map[string]bool)
+	for len(seen) < calls {
+		msg := conn.recv()
+		if msg["type"] != string(types.MessageTypeToolResult) {
+			continue
+		}
+		payload := msg["payload"].(map[string]any)
+		seen[payload["callId"].(string)] = true
+	}
+	if maxRunning.Load() < 2 {
+		t.Errorf("tool calls did not run concurrently (max %d)", maxRunning.Load())
+	}
+}
+
+func TestConcurrentToolUpdatesArriveInOrder(t *testing.T) {
+	const updates = 50
+
+	f := newFakeServer(t)
+	session, conn := connectSession(t, f, f.client(types.ClientOptions{}), nil)
+
+	start := make(chan struct{})
+	var wg sync.WaitGroup
+	for i := 0; i < updates; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			<-start
+			tool := types.ToolDefinition{Name: fmt.Sprintf("tool%d", i), InputSchema: types.ToolInputSchema{Type: "object"}}
+			if err := session.AddTools("dynamic", tool); err != nil {
+				t.Errorf("AddTools: %v", err)
+			}
+		}(i)
+	}
+	close(start)
+	wg.Wait()
+
+	// Every update adds one tool, so in order they list 1, 2, ... tools
+	for want := 1; want <= updates; want++ {
+		data := conn.recvControl(types.ControlActionUpdateMcpServer)
+		server, _ := data["server"].(map[string]any)
+		tools, _ := server["tools"].([]any)
+		if len(tools) != want {
+			t.Fatalf("update %d lists %d tools", want, len(tools))
+		}
+	}
+}
+
+func TestAddToolsRejectsToolOfAnotherServer(t *testing.T) {
+	search := types.ToolDefinition{Name: "search", InputSchema: types.ToolInputSchema{Type: "object"}}
+	session := NewClient(types.ClientOptions{Token: "test-token"}).CreateSession(&types.SessionOptions{
+		BaseOptions: types.BaseOptions{
+			McpServers: []types.McpServerDefinition{types.McpClientToolsServer{Name: "app", Tools: []types.ToolDefinition{search}}},
+		},
+	})
+	defer session.Close()
+
+	if err := session.AddTools("research", search); !errors.Is(err, types.ErrValidation) {
+		t.Errorf("AddTools to another server = %v, want a validation error", err)
+	}
+	if err := session.AddTools("app", search); err != nil {
+		t.Errorf("AddTools replacing the tool = %v", err)
+	}
+}
+
+func TestFailedConnectClosesConnection(t *testing.T) {
+	upgrader := websocket.Upgrader{CheckOrigin: func(*http.Request) bool { return true }}
+	conns := make(chan *websocket.Conn, 2)
+	var attempts atomic.Int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		ws, err := upgrader.Upgrade(w, r, nil)
+		if err != nil {
+			return
+		}
+		var init map[string]any
+		if err := ws.ReadJSON(&init); err != nil {
+			return
+		}
+		// Refuse the first init, accept the next
+		if attempts.Add(1) == 1 {
+			_ = ws.WriteJSON(map[string]any{"type": "error", "payload": map[string]any{"code": "SESSION_ERROR", "message": "init refused"}})
+		} else {
+			_ = ws.WriteJSON(types.ControlEnvelope{Type: types.MessageTypeControl, Payload: types.ControlPayload{Action: types.ControlActionReady}})
+		}
+		conns <- ws
+	}))
+	defer srv.Close()
+
+	client := NewClient(types.ClientOptions{BaseURL: "ws" + strings.TrimPrefix(srv.URL, "http"), Token: "test-token", Timeout: 5 * time.Second})
+	session := client.CreateSession(nil)
+	defer session.Close()
+
+	if err := session.Connect(context.Background()); !errors.Is(err, types.ErrSession) {
+		t.Fatalf("Connect = %v, want the init error", err)
+	}
+	first := <-conns
+	defer first.Close()
+	_ = first.SetReadDeadline(time.Now().Add(5 * time.Second))
+	for {
+		if _, _, err := first.ReadMessage(); err != nil {
+			if ne, ok := err.(net.Error); ok && ne.Timeout() {
+				t.Fatal("failed connection was left open")
+			}
+			break
+		}
+	}
+
+	if err := session.Connect(context.Background()); err != nil {
+		t.Fatalf("second Connect: %v", err)
+	}
+	second := <-conns
+	defer second.Close()
+}
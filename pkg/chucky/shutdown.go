@@ -0,0 +1,111 @@
+package chucky
+
+import (
+	"context"
+	"sync"
+
+	"github.com/chucky-cloud/chucky-sdk-go/pkg/transport"
+)
+
+// CloseGracefully closes the session once the running turn has finished and
+// in-flight tool calls have sent their results. New turns are rejected while
+// it waits. If ctx expires first, the turn is interrupted, tool handler
+// contexts are cancelled and the session is closed; ctx.Err() is returned.
+//
+// It returns after the transport and all SDK goroutines of the session have
+// exited. Tool handlers and hooks that ignore context cancellation delay the
+// return.
+// It must not be called from a session event handler.
+func (s *Session) CloseGracefully(ctx context.Context) error {
+	s.draining.Store(true)
+
+	err := s.waitIdle(ctx)
+	if err != nil {
+		_ = s.Interrupt()
+		s.cancel()
+		s.waitTools()
+	}
+
+	s.Close()
+	s.wait()
+	return err
+}
+
+// turnActive reports whether a turn may still produce messages or tool calls.
+func turnActive(state SessionState) bool {
+	switch state {
+	case SessionStateProcessing, SessionStateWaitingTool, SessionStateReconnecting:
+		return true
+	}
+	return false
+}
+
+// waitIdle blocks until no turn is running and no tool call is in flight.
+func (s *Session) waitIdle(ctx context.Context) error {
+	for {
+		s.stateMu.RLock()
+		state, changed := s.state, s.stateCh
+		s.stateMu.RUnlock()
+
+		if !turnActive(state) && s.toolsActive.Load() == 0 {
+			return nil
+		}
+
+		select {
+		case <-changed:
+		case <-s.closeCh:
+			s.waitTools()
+			return nil
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+}
+
+// waitTools blocks until in-flight tool calls have returned.
+func (s *Session) waitTools() {
+	for s.toolsActive.Load() > 0 {
+		s.stateMu.RLock()
+		changed := s.stateCh
+		s.stateMu.RUnlock()
+		if s.toolsActive.Load() == 0 {
+			return
+		}
+		<-changed
+	}
+}
+
+// wait blocks until the transport and the session's goroutines have exited.
+func (s *Session) wait() {
+	if w, ok := s.getTransport().(transport.Waiter); ok {
+		w.Wait()
+	}
+	s.wg.Wait()
+}
+
+// Shutdown stops the client from connecting new sessions and closes every
+// session gracefully: running turns may finish until ctx expires, after which
+// they are interrupted. It returns once all sessions and their goroutines
+// have exited, with ctx.Err() if any session had to be interrupted.
+func (c *Client) Shutdown(ctx context.Context) error {
+	c.shutdown.Store(true)
+
+	sessions := c.Sessions()
+	var wg sync.WaitGroup
+	errs := make([]error, len(sessions))
+	for i, s := range sessions {
+		wg.Add(1)
+		go func(i int, s *Session) {
+			defer wg.Done()
+			errs[i] = s.CloseGracefully(ctx)
+		}(i, s)
+	}
+	wg.Wait()
+
+	for _, err := range errs {
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,170 @@
+package chucky
+
+import (
+	"fmt"
+
+	"github.com/chucky-cloud/chucky-sdk-go/pkg/transport"
+	"github.com/chucky-cloud/chucky-sdk-go/pkg/types"
+)
+
+// sessionTransitions lists the legal transitions out of each state.
+// Self-transitions are always allowed and do not fire OnStateChange.
+var sessionTransitions = map[SessionState][]SessionState{
+	SessionStateIdle: {
+		SessionStateInitializing, SessionStateClosed,
+	},
+	SessionStateInitializing: {
+		SessionStateReady, SessionStateError, SessionStateClosed,
+	},
+	SessionStateReady: {
+		SessionStateProcessing, SessionStateCompleted, SessionStateReconnecting,
+		SessionStateError, SessionStateClosed,
+	},
+	SessionStateProcessing: {
+		SessionStateWaitingTool, SessionStateCompleted, SessionStateReconnecting,
+		SessionStateError, SessionStateClosed,
+	},
+	SessionStateWaitingTool: {
+		SessionStateProcessing, SessionStateCompleted, SessionStateReconnecting,
+		SessionStateError, SessionStateClosed,
+	},
+	SessionStateCompleted: {
+		SessionStateProcessing, SessionStateReconnecting,
+		SessionStateError, SessionStateClosed,
+	},
+	SessionStateReconnecting: {
+		SessionStateReady, SessionStateProcessing, SessionStateWaitingTool,
+		SessionStateCompleted, SessionStateError, SessionStateClosed,
+	},
+	SessionStateError: {
+		SessionStateInitializing, SessionStateClosed,
+	},
+	SessionStateClosed: {},
+}
+
+// CanTransition reports whether a session may move from one state to another.
+func CanTransition(from, to SessionState) bool {
+	if from == to {
+		return true
+	}
+	for _, next := range sessionTransitions[from] {
+		if next == to {
+			return true
+		}
+	}
+	return false
+}
+
+// setState moves the session to state. Illegal transitions are rejected and
+// reported through OnError as an internal error.
+func (s *Session) setState(state SessionState) bool {
+	return s.transition(state, nil)
+}
+
+// setStateIf moves the session to state only if it is currently in from.
+// It is used where a concurrent transition may already have superseded
+// the caller, e.g. a result arriving while a tool call finishes.
+func (s *Session) setStateIf(from, state SessionState) bool {
+	return s.transition(state, &from)
+}
+
+func (s *Session) transition(state SessionState, expect *SessionState) bool {
+	s.stateMu.Lock()
+	from := s.state
+	if expect != nil && from != *expect {
+		s.stateMu.Unlock()
+		return false
+	}
+	if from == state {
+		s.stateMu.Unlock()
+		return true
+	}
+	if !CanTransition(from, state) {
+		s.stateMu.Unlock()
+		s.handleError(types.InternalError(
+			fmt.Sprintf("illegal session state transition %s -> %s", from, state),
+		).WithDetails(map[string]any{
+			"from": string(from),
+			"to":   string(state),
+		}))
+		return false
+	}
+
+	s.state = state
+	if state == SessionStateReconnecting {
+		s.resumeState = from
+	}
+	close(s.stateCh)
+	s.stateCh = make(chan struct{})
+	s.stateMu.Unlock()
+
+	if state == SessionStateClosed || state == SessionStateError {
+		s.closeDone(state)
+	}
+
+	if s.handlers.OnStateChange != nil {
+		s.handlers.OnStateChange(from, state)
+	}
+	return true
+}
+
+// notifyStateWaiters wakes goroutines waiting for a state change without
+// changing the state, e.g. when a tool call finishes.
+func (s *Session) notifyStateWaiters() {
+	s.stateMu.Lock()
+	close(s.stateCh)
+	s.stateCh = make(chan struct{})
+	s.stateMu.Unlock()
+}
+
+// closeDone closes the Done channel once the session is closed. An error
+// state only ends the session if it is already connected; a failed
+// Connect can still be retried.
+func (s *Session) closeDone(state SessionState) {
+	if state == SessionStateError && !s.isConnected() {
+		return
+	}
+	s.doneOnce.Do(func() { close(s.doneCh) })
+}
+
+// Done returns a channel that is closed when the session ends, either by
+// Close or by an unrecoverable error after it was connected.
+func (s *Session) Done() <-chan struct{} {
+	return s.doneCh
+}
+
+func (s *Session) isConnected() bool {
+	s.connectedMu.RLock()
+	defer s.connectedMu.RUnlock()
+	return s.connected
+}
+
+// handleStatusChange maps transport status changes into session states.
+// Status changes during Connect are handled by the connect loop itself.
+func (s *Session) handleStatusChange(status transport.ConnectionStatus) {
+	if !s.isConnected() {
+		return
+	}
+
+	switch status {
+	case transport.StatusReconnecting:
+		s.setState(SessionStateReconnecting)
+	case transport.StatusConnected:
+		s.stateMu.RLock()
+		resume := s.resumeState
+		s.stateMu.RUnlock()
+		if resume == "" {
+			resume = SessionStateReady
+		}
+		s.setStateIf(SessionStateReconnecting, resume)
+	case transport.StatusError:
+		s.setState(SessionStateError)
+	case transport.StatusDisconnected:
+		// Close moves to closed; an unexpected drop is an error
+		select {
+		case <-s.closeCh:
+		default:
+			s.setState(SessionStateError)
+		}
+	}
+}
@@ -0,0 +1,245 @@
+package chucky
+
+import (
+	"context"
+	"sync"
+
+	"github.com/chucky-cloud/chucky-sdk-go/pkg/types"
+)
+
+// MessageFilter selects the messages a subscriber receives. A nil filter
+// receives everything.
+type MessageFilter func(msg types.IncomingMessage) bool
+
+// SubscribeOptions configures a subscription.
+type SubscribeOptions struct {
+	// BufferSize is the number of messages buffered for the subscriber.
+	// Default: 64.
+	BufferSize int
+
+	// Overflow is applied when the buffer is full. Default: OverflowBlock,
+	// which delays delivery to the session's other consumers. OverflowError
+	// ends the subscription and reports the overflow to OnError.
+	Overflow types.OverflowPolicy
+
+	// Replay delivers the messages of the current turn received before
+	// the subscription, starting with the turn's first message.
+	Replay bool
+}
+
+// Subscribe returns a channel that receives a copy of every incoming message
+// matching filter. Unlike Stream, any number of subscribers can observe the
+// same session; each has its own buffer. The channel is closed when ctx is
+// done, the session closes, or the subscription overflows under OverflowError.
+func (s *Session) Subscribe(ctx context.Context, filter MessageFilter, opts *SubscribeOptions) <-chan types.IncomingMessage {
+	o := SubscribeOptions{}
+	if opts != nil {
+		o = *opts
+	}
+	if o.BufferSize <= 0 {
+		o.BufferSize = 64
+	}
+	if o.Overflow == "" {
+		o.Overflow = types.OverflowBlock
+	}
+
+	sub := &subscriber{
+		filter:  filter,
+		opts:    o,
+		out:     make(chan types.IncomingMessage),
+		notify:  make(chan struct{}, 1),
+		space:   make(chan struct{}, 1),
+		done:    make(chan struct{}),
+		closeCh: s.closeCh,
+	}
+
+	s.hub.add(sub, o.Replay)
+
+	s.goTracked(func() {
+		defer s.hub.remove(sub)
+		sub.forward(ctx)
+	})
+
+	return sub.out
+}
+
+// messageHub fans incoming messages out to subscribers and keeps the
+// messages of the current turn for replay.
+type messageHub struct {
+	mu          sync.Mutex
+	subscribers map[*subscriber]struct{}
+	turn        []types.IncomingMessage
+}
+
+func newMessageHub() *messageHub {
+	return &messageHub{subscribers: make(map[*subscriber]struct{})}
+}
+
+func (h *messageHub) add(sub *subscriber, replay bool) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	if replay {
+		// Replayed messages are not subject to the buffer limit
+		for _, msg := range h.turn {
+			if sub.matches(msg) {
+				sub.queue = append(sub.queue, msg)
+			}
+		}
+		sub.signal()
+	}
+	h.subscribers[sub] = struct{}{}
+}
+
+func (h *messageHub) remove(sub *subscriber) {
+	h.mu.Lock()
+	delete(h.subscribers, sub)
+	h.mu.Unlock()
+}
+
+// startTurn clears the replay buffer when a new turn begins.
+func (h *messageHub) startTurn() {
+	h.mu.Lock()
+	h.turn = nil
+	h.mu.Unlock()
+}
+
+// publish delivers msg to every subscriber. It returns the subscribers that
+// overflowed under OverflowError.
+func (h *messageHub) publish(msg types.IncomingMessage) []*subscriber {
+	h.mu.Lock()
+	h.turn = append(h.turn, msg)
+	subs := make([]*subscriber, 0, len(h.subscribers))
+	for sub := range h.subscribers {
+		subs = append(subs, sub)
+	}
+	h.mu.Unlock()
+
+	var overflowed []*subscriber
+	for _, sub := range subs {
+		if sub.matches(msg) && !sub.push(msg) {
+			overflowed = append(overflowed, sub)
+		}
+	}
+	return overflowed
+}
+
+// subscriber buffers messages for one Subscribe call.
+type subscriber struct {
+	filter MessageFilter
+	opts   SubscribeOptions
+	out    chan types.IncomingMessage
+
+	mu       sync.Mutex
+	queue    []types.IncomingMessage
+	finished bool
+
+	notify  chan struct{} // Signalled when the queue grows
+	space   chan struct{} // Signalled when the queue shrinks
+	done    chan struct{} // Closed when the subscription ends
+	once    sync.Once
+	closeCh chan struct{} // Session close
+}
+
+func (sub *subscriber) matches(msg types.IncomingMessage) bool {
+	return sub.filter == nil || sub.filter(msg)
+}
+
+// push adds msg to the queue according to the overflow policy. It returns
+// false if the subscription overflowed under OverflowError.
+func (sub *subscriber) push(msg types.IncomingMessage) bool {
+	for {
+		sub.mu.Lock()
+		if sub.finished {
+			sub.mu.Unlock()
+			return true
+		}
+		if len(sub.queue) < sub.opts.BufferSize {
+			sub.queue = append(sub.queue, msg)
+			sub.mu.Unlock()
+			sub.signal()
+			return true
+		}
+
+		switch sub.opts.Overflow {
+		case types.OverflowDropOldest:
+			sub.queue = append(sub.queue[1:], msg)
+			sub.mu.Unlock()
+			sub.signal()
+			return true
+		case types.OverflowError:
+			sub.finished = true
+			sub.mu.Unlock()
+			sub.finish()
+			return false
+		}
+		sub.mu.Unlock()
+
+		select {
+		case <-sub.space:
+		case <-sub.done:
+			return true
+		case <-sub.closeCh:
+			return true
+		}
+	}
+}
+
+func (sub *subscriber) signal() {
+	select {
+	case sub.notify <- struct{}{}:
+	default:
+	}
+}
+
+func (sub *subscriber) finish() {
+	sub.once.Do(func() { close(sub.done) })
+}
+
+// forward moves queued messages to the subscriber's channel.
+func (sub *subscriber) forward(ctx context.Context) {
+	defer close(sub.out)
+	defer func() {
+		sub.mu.Lock()
+		sub.finished = true
+		sub.mu.Unlock()
+		sub.finish()
+	}()
+
+	for {
+		sub.mu.Lock()
+		if len(sub.queue) == 0 {
+			finished := sub.finished
+			sub.mu.Unlock()
+			if finished {
+				return
+			}
+			select {
+			case <-sub.notify:
+				continue
+			case <-sub.done:
+				continue
+			case <-ctx.Done():
+				return
+			case <-sub.closeCh:
+				return
+			}
+		}
+		msg := sub.queue[0]
+		sub.queue = sub.queue[1:]
+		sub.mu.Unlock()
+
+		select {
+		case sub.space <- struct{}{}:
+		default:
+		}
+
+		select {
+		case sub.out <- msg:
+		case <-ctx.Done():
+			return
+		case <-sub.closeCh:
+			return
+		}
+	}
+}
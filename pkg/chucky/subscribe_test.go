@@ -0,0 +1,85 @@
+package chucky
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/chucky-cloud/chucky-sdk-go/pkg/types"
+)
+
+func TestSubscriberWithoutStreamReceivesEverything(t *testing.T) {
+	const buffer = 8
+	const sent = 10 * buffer
+
+	f := newFakeServer(t)
+	session, conn := connectSession(t, f, f.client(types.ClientOptions{}), &types.SessionOptions{MessageBufferSize: buffer})
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	sub := session.Subscribe(ctx, nil, &SubscribeOptions{BufferSize: buffer})
+
+	go func() {
+		for i := 0; i < sent; i++ {
+			conn.sendRaw(assistantText(fmt.Sprint(i)))
+		}
+	}()
+
+	for i := 0; i < sent; i++ {
+		select {
+		case msg, ok := <-sub:
+			if !ok {
+				t.Fatalf("subscription ended after %d of %d messages", i, sent)
+			}
+			if got := textOf(t, msg); got != fmt.Sprint(i) {
+				t.Fatalf("message %d = %q", i, got)
+			}
+			// A slow consumer must not lose messages to the unread Stream buffer
+			if i%buffer == 0 {
+				time.Sleep(time.Millisecond)
+			}
+		case <-ctx.Done():
+			t.Fatalf("received %d of %d messages", i, sent)
+		}
+	}
+}
+
+func TestSubscribersHaveIndependentBuffers(t *testing.T) {
+	const sent = 50
+
+	f := newFakeServer(t)
+	session, conn := connectSession(t, f, f.client(types.ClientOptions{}), &types.SessionOptions{MessageBufferSize: 4})
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	// Never read; drops its oldest messages instead of holding up the others
+	stalled := session.Subscribe(ctx, nil, &SubscribeOptions{BufferSize: 2, Overflow: types.OverflowDropOldest})
+	reader := session.Subscribe(ctx, nil, nil)
+
+	for i := 0; i < sent; i++ {
+		conn.sendRaw(assistantText(fmt.Sprint(i)))
+	}
+	for i := 0; i < sent; i++ {
+		select {
+		case <-reader:
+		case <-ctx.Done():
+			t.Fatalf("reader received %d of %d messages", i, sent)
+		}
+	}
+
+	// The subscriber's buffer plus the message its forwarder holds
+	var kept []string
+	for len(kept) < 3 {
+		select {
+		case msg := <-stalled:
+			kept = append(kept, textOf(t, msg))
+			continue
+		case <-time.After(50 * time.Millisecond):
+		}
+		break
+	}
+	if len(kept) == 0 || kept[len(kept)-1] != fmt.Sprint(sent-1) {
+		t.Errorf("stalled subscriber kept %v, want the latest messages", kept)
+	}
+}
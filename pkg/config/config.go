@@ -0,0 +1,605 @@
+// Package config loads client and session options from the environment and
+// configuration files.
+//
+// Settings are layered, later layers overriding earlier ones:
+//
+//  1. SDK defaults
+//  2. Top-level keys of the config file, shared by all profiles
+//  3. The selected profile
+//  4. CHUCKY_* environment variables
+//
+// Options set in code after loading override all of them.
+package config
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/chucky-cloud/chucky-sdk-go/pkg/types"
+	"github.com/chucky-cloud/chucky-sdk-go/pkg/utils"
+)
+
+// DefaultProfile is used when no profile is selected.
+const DefaultProfile = "default"
+
+// Duration is a time.Duration read from a string such as "90s" or "5m", or
+// from a number of seconds.
+type Duration time.Duration
+
+// UnmarshalJSON implements json.Unmarshaler.
+func (d *Duration) UnmarshalJSON(data []byte) error {
+	var v any
+	if err := json.Unmarshal(data, &v); err != nil {
+		return err
+	}
+	switch v := v.(type) {
+	case float64:
+		*d = Duration(v * float64(time.Second))
+	case string:
+		parsed, err := time.ParseDuration(v)
+		if err != nil {
+			return err
+		}
+		*d = Duration(parsed)
+	case nil:
+		*d = 0
+	default:
+		return fmt.Errorf("invalid duration %v", v)
+	}
+	return nil
+}
+
+// Budget is the budget of tokens minted from a project secret.
+type Budget struct {
+	AIDollars    float64            `json:"ai_dollars"`
+	ComputeHours float64            `json:"compute_hours"`
+	Window       types.BudgetWindow `json:"window"` // Default: day
+}
+
+// McpServer configures a stdio, SSE or HTTP MCP server.
+type McpServer struct {
+	Name    string            `json:"name"`
+	Type    string            `json:"type"` // stdio, sse or http. Default: stdio with a command, http with a URL
+	Command string            `json:"command"`
+	Args    []string          `json:"args"`
+	Env     map[string]string `json:"env"`
+	URL     string            `json:"url"`
+	Headers map[string]string `json:"headers"`
+	Local   bool              `json:"local"` // Run a stdio server on the client
+}
+
+// Profile is one named set of settings.
+type Profile struct {
+	// Connection
+	BaseURL string   `json:"base_url"`
+	Timeout Duration `json:"timeout"`
+	Debug   bool     `json:"debug"`
+
+	// Credentials: a token, or a project secret to mint one
+	Token     string   `json:"token"`
+	ProjectID string   `json:"project_id"` // Default: derived from the secret
+	Secret    string   `json:"secret"`
+	UserID    string   `json:"user_id"`
+	TokenTTL  Duration `json:"token_ttl"` // Default: 1 hour
+	Budget    *Budget  `json:"budget"`
+
+	// Session defaults
+	Model          types.Model          `json:"model"`
+	FallbackModel  string               `json:"fallback_model"`
+	SystemPrompt   string               `json:"system_prompt"`
+	MaxTurns       int                  `json:"max_turns"`
+	MaxBudgetUsd   float64              `json:"max_budget_usd"`
+	PermissionMode types.PermissionMode `json:"permission_mode"`
+	McpServers     []McpServer          `json:"mcp_servers"`
+	Env            map[string]string    `json:"env"`
+	SettingSources []string             `json:"setting_sources"`
+}
+
+// LoadOptions configures LoadWith.
+type LoadOptions struct {
+	// Path of the config file. Default: $CHUCKY_CONFIG, then the first of
+	// chucky.{json,yaml,yml,toml} in the working directory and
+	// config.{json,yaml,yml,toml} in the user config directory's chucky
+	// folder. Loading fails if an explicit path does not exist; without one,
+	// a missing file is not an error.
+	Path string
+
+	// Profile to select. Default: $CHUCKY_PROFILE, then the file's
+	// default_profile, then DefaultProfile.
+	Profile string
+
+	// LookupEnv reads environment variables. Default: os.LookupEnv.
+	LookupEnv func(key string) (string, bool)
+}
+
+// Config is the result of loading.
+type Config struct {
+	Path    string // Config file used, if any
+	Profile string // Selected profile
+
+	// Settings after all layers were applied
+	Settings Profile
+
+	// Client.DefaultSession points to Session, so every session created by
+	// a client built from Client inherits the session settings. With a
+	// secret, Client.TokenProvider mints a token for every connection.
+	Client  types.ClientOptions
+	Session types.SessionOptions
+}
+
+// Load reads the configuration from the default locations and environment.
+func Load() (*Config, error) {
+	return LoadWith(LoadOptions{})
+}
+
+// LoadWith reads the configuration using opts.
+func LoadWith(opts LoadOptions) (*Config, error) {
+	lookup := opts.LookupEnv
+	if lookup == nil {
+		lookup = os.LookupEnv
+	}
+	getenv := func(key string) string {
+		v, _ := lookup(key)
+		return v
+	}
+
+	path := opts.Path
+	explicit := path != ""
+	if !explicit {
+		path = getenv("CHUCKY_CONFIG")
+		explicit = path != ""
+	}
+	if !explicit {
+		path = findConfigFile()
+	}
+
+	var file map[string]any
+	if path != "" {
+		var err error
+		file, err = readFile(path)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	// Select the profile
+	profiles, _ := file["profiles"].(map[string]any)
+	name := opts.Profile
+	if name == "" {
+		name = getenv("CHUCKY_PROFILE")
+	}
+	if name == "" {
+		name, _ = file["default_profile"].(string)
+	}
+	selected := name != ""
+	if name == "" {
+		name = DefaultProfile
+	}
+
+	settings := make(map[string]any)
+	for key, value := range file {
+		if key != "profiles" && key != "default_profile" {
+			settings[key] = value
+		}
+	}
+	if profile, ok := profiles[name]; ok {
+		profileMap, ok := profile.(map[string]any)
+		if !ok {
+			return nil, validationError("config", []types.FieldError{{Field: "profiles." + name, Message: "must be a table"}})
+		}
+		mergeMaps(settings, profileMap)
+	} else if selected {
+		return nil, validationError("config", []types.FieldError{{
+			Field:   "profile",
+			Message: fmt.Sprintf("profile %q not found (available: %s)", name, strings.Join(sortedKeys(profiles), ", ")),
+		}})
+	}
+
+	env, errs := envSettings(lookup)
+	mergeMaps(settings, env)
+	if len(errs) > 0 {
+		return nil, validationError("environment", errs)
+	}
+
+	var profile Profile
+	if err := decodeSettings(settings, &profile); err != nil {
+		return nil, validationError("config", []types.FieldError{{Field: "profiles." + name, Message: err.Error()}})
+	}
+
+	cfg := &Config{Path: path, Profile: name, Settings: profile}
+	if err := cfg.build(); err != nil {
+		return nil, err
+	}
+	return cfg, nil
+}
+
+// build converts the settings to client and session options.
+func (c *Config) build() error {
+	p := c.Settings
+	var errs []types.FieldError
+
+	c.Client = types.DefaultClientOptions().Merge(types.ClientOptions{
+		BaseURL: p.BaseURL,
+		Debug:   p.Debug,
+		Timeout: time.Duration(p.Timeout),
+	})
+	if !strings.HasPrefix(c.Client.BaseURL, "ws://") && !strings.HasPrefix(c.Client.BaseURL, "wss://") {
+		errs = append(errs, types.FieldError{Field: "base_url", Message: fmt.Sprintf("must be a ws:// or wss:// URL, got %q", c.Client.BaseURL)})
+	}
+	if p.Timeout < 0 {
+		errs = append(errs, types.FieldError{Field: "timeout", Message: "must not be negative"})
+	}
+
+	switch {
+	case p.Token != "":
+		c.Client.Token = p.Token
+	case p.Secret != "":
+		provider, tokenErrs := tokenProvider(p)
+		errs = append(errs, tokenErrs...)
+		c.Client.TokenProvider = provider
+	default:
+		errs = append(errs, types.FieldError{Field: "token", Message: "a token or a secret is required"})
+	}
+
+	c.Session = types.SessionOptions{
+		BaseOptions: types.BaseOptions{
+			Model:          p.Model,
+			FallbackModel:  p.FallbackModel,
+			MaxTurns:       p.MaxTurns,
+			MaxBudgetUsd:   p.MaxBudgetUsd,
+			PermissionMode: p.PermissionMode,
+			Env:            p.Env,
+		},
+		SettingSources: p.SettingSources,
+	}
+	if p.SystemPrompt != "" {
+		c.Session.SystemPrompt = p.SystemPrompt
+	}
+	for i, server := range p.McpServers {
+		def, err := server.definition()
+		if err != nil {
+			errs = append(errs, types.FieldError{Field: fmt.Sprintf("mcp_servers[%d]", i), Message: err.Error()})
+			continue
+		}
+		c.Session.McpServers = append(c.Session.McpServers, def)
+	}
+
+	if err := c.Session.Validate(); err != nil {
+		var ce *types.ChuckyError
+		if errors.As(err, &ce) {
+			errs = append(errs, ce.FieldErrors()...)
+		}
+	}
+
+	if len(errs) > 0 {
+		return validationError(fmt.Sprintf("profile %q", c.Profile), errs)
+	}
+	c.Client.DefaultSession = &c.Session
+	return nil
+}
+
+// tokenProvider returns a function minting a token from the secret for each
+// connection, so long-running clients never hold an expired token. The
+// budget window is anchored at load time so that every token shares it.
+func tokenProvider(p Profile) (func() (string, error), []types.FieldError) {
+	var errs []types.FieldError
+	projectID := p.ProjectID
+	if projectID == "" {
+		projectID = utils.ExtractProjectID(p.Secret)
+	}
+	if projectID == "" {
+		errs = append(errs, types.FieldError{Field: "project_id", Message: "required to mint a token"})
+	}
+	if p.UserID == "" {
+		errs = append(errs, types.FieldError{Field: "user_id", Message: "required to mint a token"})
+	}
+	if p.Budget == nil || p.Budget.AIDollars <= 0 {
+		errs = append(errs, types.FieldError{Field: "budget.ai_dollars", Message: "must be positive to mint a token"})
+	}
+	if len(errs) > 0 {
+		return nil, errs
+	}
+
+	window := p.Budget.Window
+	if window == "" {
+		window = types.BudgetWindowDay
+	}
+	anchor := time.Now()
+	provider := func() (string, error) {
+		start, _ := window.Bounds(anchor, time.Now())
+		return utils.CreateToken(types.CreateTokenOptions{
+			UserID:    p.UserID,
+			ProjectID: projectID,
+			Secret:    p.Secret,
+			ExpiresIn: time.Duration(p.TokenTTL),
+			Budget: utils.CreateBudget(types.CreateBudgetOptions{
+				AIDollars:    p.Budget.AIDollars,
+				ComputeHours: p.Budget.ComputeHours,
+				Window:       window,
+				WindowStart:  start,
+			}),
+		})
+	}
+
+	// Mint once to report configuration errors at load time
+	if _, err := provider(); err != nil {
+		return nil, []types.FieldError{{Field: "token", Message: err.Error()}}
+	}
+	return provider, nil
+}
+
+func (s McpServer) definition() (types.McpServerDefinition, error) {
+	serverType := s.Type
+	if serverType == "" {
+		serverType = "stdio"
+		if s.URL != "" {
+			serverType = "http"
+		}
+	}
+	if s.Local && types.McpServerType(serverType) != types.McpServerTypeStdio {
+		return nil, fmt.Errorf("local is only supported for stdio servers")
+	}
+	switch types.McpServerType(serverType) {
+	case types.McpServerTypeStdio:
+		return types.McpStdioServerConfig{Name: s.Name, Type: types.McpServerTypeStdio, Command: s.Command, Args: s.Args, Env: s.Env, Local: s.Local}, nil
+	case types.McpServerTypeSSE:
+		return types.McpSSEServerConfig{Name: s.Name, Type: types.McpServerTypeSSE, URL: s.URL, Headers: s.Headers}, nil
+	case types.McpServerTypeHTTP:
+		return types.McpHTTPServerConfig{Name: s.Name, Type: types.McpServerTypeHTTP, URL: s.URL, Headers: s.Headers}, nil
+	}
+	return nil, fmt.Errorf("unknown type %q", s.Type)
+}
+
+// envVars maps CHUCKY_* variables to settings keys.
+var envVars = []struct {
+	name string
+	key  string
+	kind string // string, bool, int, float or duration
+}{
+	{"CHUCKY_BASE_URL", "base_url", "string"},
+	{"CHUCKY_URL", "base_url", "string"}, // Alias used by the examples
+	{"CHUCKY_TIMEOUT", "timeout", "duration"},
+	{"CHUCKY_DEBUG", "debug", "bool"},
+	{"CHUCKY_TOKEN", "token", "string"},
+	{"CHUCKY_PROJECT_ID", "project_id", "string"},
+	{"CHUCKY_SECRET", "secret", "string"},
+	{"CHUCKY_HMAC_KEY", "secret", "string"}, // Alias used by the integration tests
+	{"CHUCKY_USER_ID", "user_id", "string"},
+	{"CHUCKY_TOKEN_TTL", "token_ttl", "duration"},
+	{"CHUCKY_AI_DOLLARS", "budget.ai_dollars", "float"},
+	{"CHUCKY_COMPUTE_HOURS", "budget.compute_hours", "float"},
+	{"CHUCKY_BUDGET_WINDOW", "budget.window", "string"},
+	{"CHUCKY_MODEL", "model", "string"},
+	{"CHUCKY_FALLBACK_MODEL", "fallback_model", "string"},
+	{"CHUCKY_SYSTEM_PROMPT", "system_prompt", "string"},
+	{"CHUCKY_MAX_TURNS", "max_turns", "int"},
+	{"CHUCKY_MAX_BUDGET_USD", "max_budget_usd", "float"},
+	{"CHUCKY_PERMISSION_MODE", "permission_mode", "string"},
+}
+
+// envSettings reads the CHUCKY_* variables. When a variable and its alias
+// are both set, the one listed first wins.
+func envSettings(lookup func(string) (string, bool)) (map[string]any, []types.FieldError) {
+	settings := make(map[string]any)
+	seen := make(map[string]bool)
+	var errs []types.FieldError
+	for _, v := range envVars {
+		raw, ok := lookup(v.name)
+		if !ok || raw == "" || seen[v.key] {
+			continue
+		}
+		seen[v.key] = true
+
+		value, err := parseScalar(raw, v.kind)
+		if err != nil {
+			errs = append(errs, types.FieldError{Field: v.name, Message: err.Error()})
+			continue
+		}
+		setPath(settings, strings.Split(v.key, "."), value)
+	}
+	return settings, errs
+}
+
+func parseScalar(raw, kind string) (any, error) {
+	switch kind {
+	case "bool":
+		switch strings.ToLower(raw) {
+		case "1", "true", "yes", "on":
+			return true, nil
+		case "0", "false", "no", "off":
+			return false, nil
+		}
+		return nil, fmt.Errorf("invalid boolean %q", raw)
+	case "int", "float":
+		n, ok := parseNumber(raw)
+		if !ok {
+			return nil, fmt.Errorf("invalid number %q", raw)
+		}
+		if _, isInt := n.(int64); !isInt && kind == "int" {
+			return nil, fmt.Errorf("invalid integer %q", raw)
+		}
+		return n, nil
+	case "duration":
+		if _, err := time.ParseDuration(raw); err != nil {
+			return nil, fmt.Errorf("invalid duration %q", raw)
+		}
+	}
+	return raw, nil
+}
+
+// findConfigFile returns the first config file in the default locations.
+func findConfigFile() string {
+	var candidates []string
+	for _, ext := range []string{"json", "yaml", "yml", "toml"} {
+		candidates = append(candidates, "chucky."+ext)
+	}
+	if dir, err := os.UserConfigDir(); err == nil {
+		for _, ext := range []string{"json", "yaml", "yml", "toml"} {
+			candidates = append(candidates, filepath.Join(dir, "chucky", "config."+ext))
+		}
+	}
+	for _, path := range candidates {
+		if info, err := os.Stat(path); err == nil && !info.IsDir() {
+			return path
+		}
+	}
+	return ""
+}
+
+// readFile parses a config file according to its extension.
+func readFile(path string) (map[string]any, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, types.ValidationError("cannot read config file").Wrap(err)
+	}
+
+	var settings map[string]any
+	switch ext := strings.ToLower(filepath.Ext(path)); ext {
+	case ".json":
+		err = json.Unmarshal(data, &settings)
+	case ".yaml", ".yml":
+		settings, err = parseYAML(data)
+	case ".toml":
+		settings, err = parseTOML(data)
+	default:
+		return nil, types.ValidationError(fmt.Sprintf("unsupported config file type %q", ext))
+	}
+	if err != nil {
+		return nil, types.ValidationError("cannot parse config file " + path).Wrap(err)
+	}
+	return settings, nil
+}
+
+// decodeSettings converts parsed settings to a Profile, rejecting unknown keys.
+// Scalars are converted to the field's type where unambiguous, so quoted or
+// templated numbers ("20") and unquoted env values (PORT: 8080) decode.
+func decodeSettings(settings map[string]any, profile *Profile) error {
+	data, err := json.Marshal(coerce(settings, reflect.TypeOf(profile).Elem()))
+	if err != nil {
+		return err
+	}
+	dec := json.NewDecoder(strings.NewReader(string(data)))
+	dec.DisallowUnknownFields()
+	return dec.Decode(profile)
+}
+
+var jsonUnmarshaler = reflect.TypeOf((*json.Unmarshaler)(nil)).Elem()
+
+// coerce converts the scalars in value to the kinds of the fields of t they
+// will be decoded into. Values that do not convert are left for the decoder
+// to report.
+func coerce(value any, t reflect.Type) any {
+	for t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+	if reflect.PointerTo(t).Implements(jsonUnmarshaler) {
+		return value
+	}
+
+	switch v := value.(type) {
+	case map[string]any:
+		out := make(map[string]any, len(v))
+		for key, item := range v {
+			out[key] = item
+			switch t.Kind() {
+			case reflect.Map:
+				out[key] = coerce(item, t.Elem())
+			case reflect.Struct:
+				if field, ok := fieldByJSONName(t, key); ok {
+					out[key] = coerce(item, field.Type)
+				}
+			}
+		}
+		return out
+	case []any:
+		if t.Kind() != reflect.Slice {
+			return value
+		}
+		out := make([]any, len(v))
+		for i, item := range v {
+			out[i] = coerce(item, t.Elem())
+		}
+		return out
+	case string:
+		switch t.Kind() {
+		case reflect.Int, reflect.Int64, reflect.Float64:
+			if n, ok := parseNumber(strings.TrimSpace(v)); ok {
+				return n
+			}
+		case reflect.Bool:
+			if b, err := strconv.ParseBool(strings.TrimSpace(v)); err == nil {
+				return b
+			}
+		}
+	case int64, float64, bool:
+		if t.Kind() == reflect.String {
+			return fmt.Sprint(v)
+		}
+	}
+	return value
+}
+
+func fieldByJSONName(t reflect.Type, name string) (reflect.StructField, bool) {
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		if tag, _, _ := strings.Cut(field.Tag.Get("json"), ","); tag == name {
+			return field, true
+		}
+	}
+	return reflect.StructField{}, false
+}
+
+// mergeMaps merges src into dst. Nested maps are merged, other values replaced.
+func mergeMaps(dst, src map[string]any) {
+	for key, value := range src {
+		srcMap, srcIsMap := value.(map[string]any)
+		dstMap, dstIsMap := dst[key].(map[string]any)
+		if srcIsMap && dstIsMap {
+			merged := make(map[string]any, len(dstMap))
+			mergeMaps(merged, dstMap)
+			mergeMaps(merged, srcMap)
+			dst[key] = merged
+			continue
+		}
+		dst[key] = value
+	}
+}
+
+func setPath(m map[string]any, path []string, value any) {
+	for _, key := range path[:len(path)-1] {
+		child, ok := m[key].(map[string]any)
+		if !ok {
+			child = make(map[string]any)
+			m[key] = child
+		}
+		m = child
+	}
+	m[path[len(path)-1]] = value
+}
+
+func sortedKeys(m map[string]any) []string {
+	keys := make([]string, 0, len(m))
+	for key := range m {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+func validationError(what string, errs []types.FieldError) error {
+	messages := make([]string, len(errs))
+	for i, e := range errs {
+		messages[i] = e.String()
+	}
+	return types.ValidationError(fmt.Sprintf("invalid %s: %s", what, strings.Join(messages, "; "))).WithDetails(map[string]any{
+		types.DetailFieldErrors: errs,
+	})
+}
@@ -0,0 +1,195 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/chucky-cloud/chucky-sdk-go/pkg/types"
+	"github.com/chucky-cloud/chucky-sdk-go/pkg/utils"
+)
+
+func noEnv(string) (string, bool) { return "", false }
+
+func writeConfig(t *testing.T, name, content string) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), name)
+	if err := os.WriteFile(path, []byte(content), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func TestLoadCoercesScalars(t *testing.T) {
+	tests := []struct {
+		name    string
+		file    string
+		content string
+	}{
+		{"yaml", "chucky.yaml", `
+token: tok
+timeout: 90
+max_turns: "20"
+max_budget_usd: "1.5"
+debug: "true"
+env:
+  PORT: 8080
+  VERBOSE: true
+system_prompt: |
+  Be brief.
+  Be kind.
+`},
+		{"toml", "chucky.toml", `
+token = "tok"
+timeout = 90
+max_turns = "20"
+max_budget_usd = "1.5"
+debug = "true"
+system_prompt = """
+Be brief.
+Be kind.
+"""
+
+[env]
+PORT = 8080
+VERBOSE = true
+`},
+		{"json", "chucky.json", `{"token": "tok", "timeout": 90, "max_turns": "20", "max_budget_usd": "1.5", "debug": "true",
+"env": {"PORT": 8080, "VERBOSE": true}, "system_prompt": "Be brief.\nBe kind.\n"}`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg, err := LoadWith(LoadOptions{Path: writeConfig(t, tt.file, tt.content), LookupEnv: noEnv})
+			if err != nil {
+				t.Fatalf("LoadWith: %v", err)
+			}
+			s := cfg.Settings
+			if s.MaxTurns != 20 || s.MaxBudgetUsd != 1.5 || !s.Debug || time.Duration(s.Timeout) != 90*time.Second {
+				t.Errorf("settings = %+v", s)
+			}
+			if s.Env["PORT"] != "8080" || s.Env["VERBOSE"] != "true" {
+				t.Errorf("env = %v", s.Env)
+			}
+			if s.SystemPrompt != "Be brief.\nBe kind.\n" {
+				t.Errorf("system_prompt = %q", s.SystemPrompt)
+			}
+		})
+	}
+}
+
+func TestLoadErrors(t *testing.T) {
+	tests := []struct {
+		name    string
+		file    string
+		content string
+		env     map[string]string
+		want    string
+	}{
+		{"unknown key", "c.yaml", "token: t\nmax_turn: 2\n", nil, "max_turn"},
+		{"not a number", "c.yaml", "token: t\nmax_turns: many\n", nil, "max_turns"},
+		{"fractional int", "c.toml", "token = \"t\"\nmax_turns = \"1.5\"\n", nil, "max_turns"},
+		{"parse error", "c.yaml", "token: t\n\tmodel: x\n", nil, "cannot parse config file"},
+		{"missing profile", "c.yaml", "token: t\n", map[string]string{"CHUCKY_PROFILE": "prod"}, `profile "prod" not found`},
+		{"bad env number", "c.yaml", "token: t\n", map[string]string{"CHUCKY_MAX_TURNS": "x"}, "CHUCKY_MAX_TURNS"},
+		{"no credentials", "c.yaml", "model: x\n", nil, "token or a secret"},
+		{"unsupported type", "c.ini", "token=t\n", nil, "unsupported config file type"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			lookup := func(key string) (string, bool) {
+				v, ok := tt.env[key]
+				return v, ok
+			}
+			_, err := LoadWith(LoadOptions{Path: writeConfig(t, tt.file, tt.content), LookupEnv: lookup})
+			if err == nil || !strings.Contains(err.Error(), tt.want) {
+				t.Errorf("LoadWith error = %v, want %q", err, tt.want)
+			}
+		})
+	}
+}
+
+func TestLoadLayers(t *testing.T) {
+	path := writeConfig(t, "chucky.yaml", `
+token: shared
+model: claude-opus-4-5-20251101
+default_profile: dev
+profiles:
+  dev:
+    model: claude-sonnet-4-5-20250929
+    max_turns: 3
+`)
+	env := map[string]string{"CHUCKY_MAX_TURNS": "7"}
+	cfg, err := LoadWith(LoadOptions{Path: path, LookupEnv: func(key string) (string, bool) {
+		v, ok := env[key]
+		return v, ok
+	}})
+	if err != nil {
+		t.Fatalf("LoadWith: %v", err)
+	}
+	if cfg.Profile != "dev" || cfg.Client.Token != "shared" || cfg.Settings.Model != "claude-sonnet-4-5-20250929" || cfg.Session.MaxTurns != 7 {
+		t.Errorf("cfg = %+v", cfg)
+	}
+}
+
+func TestLoadMintsTokenPerConnection(t *testing.T) {
+	path := writeConfig(t, "chucky.toml", `
+secret = "hmac_proj_test"
+project_id = "proj"
+user_id = "worker"
+token_ttl = "10m"
+budget = { ai_dollars = 2, window = "hour" }
+`)
+	cfg, err := LoadWith(LoadOptions{Path: path, LookupEnv: noEnv})
+	if err != nil {
+		t.Fatalf("LoadWith: %v", err)
+	}
+	if cfg.Client.Token != "" || cfg.Client.TokenProvider == nil {
+		t.Fatalf("want a token provider instead of a fixed token")
+	}
+
+	var starts []string
+	for i := 0; i < 2; i++ {
+		token, err := cfg.Client.TokenProvider()
+		if err != nil {
+			t.Fatalf("TokenProvider: %v", err)
+		}
+		decoded, err := utils.DecodeToken(token)
+		if err != nil {
+			t.Fatalf("DecodeToken: %v", err)
+		}
+		p := decoded.Payload
+		if p.Subject != "worker" || p.Budget.Window != types.BudgetWindowHour {
+			t.Errorf("payload = %+v", p)
+		}
+		if ttl := p.ExpiresAt - p.IssuedAt; ttl != 600 {
+			t.Errorf("token lifetime = %ds, want 600s", ttl)
+		}
+		starts = append(starts, p.Budget.WindowStart)
+	}
+	if starts[0] != starts[1] {
+		t.Errorf("window start changed between tokens: %v", starts)
+	}
+}
+
+func TestLoadTokenErrors(t *testing.T) {
+	tests := []struct {
+		name    string
+		content string
+		want    []string
+	}{
+		{"none", "model = \"claude-sonnet-4-5-20250929\"\n", []string{"token or a secret"}},
+		{"secret without details", "secret = \"s\"\n", []string{"project_id", "user_id", "budget.ai_dollars"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := LoadWith(LoadOptions{Path: writeConfig(t, "c.toml", tt.content), LookupEnv: noEnv})
+			for _, want := range tt.want {
+				if err == nil || !strings.Contains(err.Error(), want) {
+					t.Errorf("LoadWith error = %v, want %q", err, want)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,776 @@
+package config
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// The YAML and TOML readers below cover the subset used by config files, so
+// the SDK needs no parser dependencies.
+//
+// YAML: block mappings and sequences (including sequences of mappings),
+// flow sequences and mappings of scalars, quoted and plain scalars, literal
+// (|) and folded (>) block scalars with the - and + chomping indicators, and
+// comments. Anchors, tags, explicit indentation indicators and multiple
+// documents are rejected.
+//
+// TOML: tables, arrays of tables, dotted and quoted keys, strings including
+// multi-line basic (""") and literal (''') strings, integers, floats,
+// booleans, arrays (which may span lines) and inline tables. Dates are
+// rejected.
+
+// parseNumber parses an integer or float, returning int64 or float64.
+func parseNumber(s string) (any, bool) {
+	s = strings.ReplaceAll(s, "_", "")
+	if i, err := strconv.ParseInt(s, 10, 64); err == nil {
+		return i, true
+	}
+	if f, err := strconv.ParseFloat(s, 64); err == nil {
+		return f, true
+	}
+	return nil, false
+}
+
+// --- YAML ---
+
+type yamlLine struct {
+	num    int
+	indent int
+	text   string
+}
+
+func parseYAML(data []byte) (map[string]any, error) {
+	raws := strings.Split(strings.ReplaceAll(string(data), "\r\n", "\n"), "\n")
+	var lines []yamlLine
+	for i := 0; i < len(raws); i++ {
+		raw := strings.TrimRight(raws[i], " \t")
+		text := strings.TrimLeft(raw, " ")
+		if strings.HasPrefix(text, "\t") {
+			return nil, fmt.Errorf("line %d: tabs are not allowed for indentation", i+1)
+		}
+		text = stripComment(text)
+		if text == "" || (text == "---" && len(lines) == 0) {
+			continue
+		}
+		if text == "..." || text == "---" || strings.HasPrefix(text, "--- ") {
+			return nil, fmt.Errorf("line %d: multiple documents are not supported", i+1)
+		}
+		indent := len(raw) - len(strings.TrimLeft(raw, " "))
+
+		// Replace a block scalar by its quoted value
+		if prefix, header, ok := blockScalarHeader(text); ok {
+			value, next, err := blockScalar(raws, i+1, indent, header)
+			if err != nil {
+				return nil, fmt.Errorf("line %d: %w", i+1, err)
+			}
+			text = prefix + strconv.Quote(value)
+			lines = append(lines, yamlLine{num: i + 1, indent: indent, text: text})
+			i = next - 1
+			continue
+		}
+		lines = append(lines, yamlLine{num: i + 1, indent: indent, text: text})
+	}
+	if len(lines) == 0 {
+		return map[string]any{}, nil
+	}
+
+	p := &yamlParser{lines: lines}
+	value, err := p.block(lines[0].indent)
+	if err != nil {
+		return nil, err
+	}
+	if p.pos < len(p.lines) {
+		return nil, fmt.Errorf("line %d: unexpected indentation", p.lines[p.pos].num)
+	}
+	m, ok := value.(map[string]any)
+	if !ok {
+		return nil, fmt.Errorf("top level must be a mapping")
+	}
+	return m, nil
+}
+
+type yamlParser struct {
+	lines []yamlLine
+	pos   int
+}
+
+// block parses the mapping or sequence starting at the current line.
+func (p *yamlParser) block(indent int) (any, error) {
+	if isSeqItem(p.lines[p.pos].text) {
+		return p.sequence(indent)
+	}
+	return p.mapping(indent)
+}
+
+func (p *yamlParser) mapping(indent int) (map[string]any, error) {
+	m := make(map[string]any)
+	for p.pos < len(p.lines) {
+		line := p.lines[p.pos]
+		if line.indent < indent {
+			break
+		}
+		if line.indent > indent {
+			return nil, fmt.Errorf("line %d: unexpected indentation", line.num)
+		}
+		if isSeqItem(line.text) {
+			return nil, fmt.Errorf("line %d: expected a key", line.num)
+		}
+
+		key, rest, ok := splitKey(line.text)
+		if !ok {
+			return nil, fmt.Errorf("line %d: expected \"key: value\"", line.num)
+		}
+		if _, dup := m[key]; dup {
+			return nil, fmt.Errorf("line %d: duplicate key %q", line.num, key)
+		}
+		p.pos++
+
+		if rest != "" {
+			value, err := yamlScalar(rest)
+			if err != nil {
+				return nil, fmt.Errorf("line %d: %w", line.num, err)
+			}
+			m[key] = value
+			continue
+		}
+
+		// Nested block, or a sequence at the same indentation
+		if p.pos < len(p.lines) {
+			next := p.lines[p.pos]
+			if next.indent > indent || (next.indent == indent && isSeqItem(next.text)) {
+				value, err := p.block(next.indent)
+				if err != nil {
+					return nil, err
+				}
+				m[key] = value
+				continue
+			}
+		}
+		m[key] = nil
+	}
+	return m, nil
+}
+
+func (p *yamlParser) sequence(indent int) ([]any, error) {
+	var items []any
+	for p.pos < len(p.lines) {
+		line := p.lines[p.pos]
+		if line.indent != indent || !isSeqItem(line.text) {
+			if line.indent > indent {
+				return nil, fmt.Errorf("line %d: unexpected indentation", line.num)
+			}
+			break
+		}
+
+		rest := strings.TrimLeft(strings.TrimPrefix(line.text, "-"), " ")
+		if rest == "" {
+			p.pos++
+			if p.pos < len(p.lines) && p.lines[p.pos].indent > indent {
+				value, err := p.block(p.lines[p.pos].indent)
+				if err != nil {
+					return nil, err
+				}
+				items = append(items, value)
+			} else {
+				items = append(items, nil)
+			}
+			continue
+		}
+
+		if _, _, isKey := splitKey(rest); isKey || isSeqItem(rest) {
+			// "- key: value" starts a mapping indented to the key
+			p.lines[p.pos] = yamlLine{num: line.num, indent: indent + len(line.text) - len(rest), text: rest}
+			value, err := p.block(p.lines[p.pos].indent)
+			if err != nil {
+				return nil, err
+			}
+			items = append(items, value)
+			continue
+		}
+
+		value, err := yamlScalar(rest)
+		if err != nil {
+			return nil, fmt.Errorf("line %d: %w", line.num, err)
+		}
+		items = append(items, value)
+		p.pos++
+	}
+	return items, nil
+}
+
+// blockScalarHeader reports whether text ends with a block scalar header
+// ("key: |", "- >-", ...), returning the text before the header.
+func blockScalarHeader(text string) (prefix, header string, ok bool) {
+	value := text
+	if key, rest, isKey := splitKey(text); isKey && key != "" {
+		value = rest
+	} else if isSeqItem(text) {
+		value = strings.TrimLeft(strings.TrimPrefix(text, "-"), " ")
+	} else {
+		return "", "", false
+	}
+	if value == "" || (value[0] != '|' && value[0] != '>') {
+		return "", "", false
+	}
+	return strings.TrimSuffix(text, value), value, true
+}
+
+// blockScalar reads the block scalar starting at lines[start], indented
+// more than parent, and returns its value and the index of the next line.
+func blockScalar(lines []string, start, parent int, header string) (string, int, error) {
+	style, chomp := header[0], header[1:]
+	if chomp != "" && chomp != "-" && chomp != "+" {
+		return "", 0, fmt.Errorf("unsupported block scalar header %q", header)
+	}
+
+	// The first non-empty line sets the indentation
+	indent := -1
+	end := start
+	for ; end < len(lines); end++ {
+		line := strings.TrimRight(lines[end], " \t")
+		if line == "" {
+			continue
+		}
+		n := len(line) - len(strings.TrimLeft(line, " "))
+		if indent < 0 {
+			if n <= parent {
+				break
+			}
+			indent = n
+		}
+		if n < indent {
+			break
+		}
+	}
+
+	var content []string
+	for _, line := range lines[start:end] {
+		if len(line) >= indent && indent >= 0 {
+			content = append(content, strings.TrimRight(line[indent:], "\r"))
+		} else {
+			content = append(content, "")
+		}
+	}
+
+	// Trailing empty lines only survive "keep" chomping
+	trailing := 0
+	for len(content) > 0 && strings.TrimSpace(content[len(content)-1]) == "" {
+		content = content[:len(content)-1]
+		trailing++
+	}
+	// They belong to the scalar, but the parent continues after them
+	next := end
+
+	var b strings.Builder
+	if style == '|' {
+		b.WriteString(strings.Join(content, "\n"))
+	} else {
+		prevText := false
+		for i, line := range content {
+			switch {
+			case line == "":
+				b.WriteByte('\n')
+				prevText = false
+			case strings.HasPrefix(line, " ") || strings.HasPrefix(line, "\t"):
+				// More-indented lines keep their line breaks
+				if i > 0 && prevText {
+					b.WriteByte('\n')
+				}
+				b.WriteString(line)
+				b.WriteByte('\n')
+				prevText = false
+			default:
+				if prevText {
+					b.WriteByte(' ')
+				}
+				b.WriteString(line)
+				prevText = true
+			}
+		}
+	}
+	value := strings.TrimSuffix(b.String(), "\n")
+
+	switch {
+	case len(content) == 0:
+		value = ""
+		if chomp == "+" {
+			value = strings.Repeat("\n", trailing)
+		}
+	case chomp == "-":
+	case chomp == "+":
+		value += "\n" + strings.Repeat("\n", trailing)
+	default:
+		value += "\n"
+	}
+	return value, next, nil
+}
+
+func isSeqItem(text string) bool {
+	return text == "-" || strings.HasPrefix(text, "- ")
+}
+
+// splitKey splits "key: value" outside quotes.
+func splitKey(text string) (key, rest string, ok bool) {
+	if text == "" || text[0] == '[' || text[0] == '{' {
+		return "", "", false
+	}
+	quote := byte(0)
+	for i := 0; i < len(text); i++ {
+		c := text[i]
+		switch {
+		case quote != 0:
+			if c == '\\' && quote == '"' {
+				i++
+			} else if c == quote {
+				quote = 0
+			}
+		case c == '"' || c == '\'':
+			quote = c
+		case c == ':' && (i == len(text)-1 || text[i+1] == ' '):
+			key = strings.TrimSpace(text[:i])
+			if unquoted, err := yamlScalar(key); err == nil {
+				if s, isString := unquoted.(string); isString {
+					key = s
+				}
+			}
+			return key, strings.TrimSpace(text[i+1:]), key != ""
+		}
+	}
+	return "", "", false
+}
+
+// stripComment removes a trailing comment outside quotes.
+func stripComment(text string) string {
+	quote := byte(0)
+	for i := 0; i < len(text); i++ {
+		c := text[i]
+		switch {
+		case quote != 0:
+			if c == '\\' && quote == '"' {
+				i++
+			} else if c == quote {
+				quote = 0
+			}
+		case c == '"' || c == '\'':
+			quote = c
+		case c == '#' && (i == 0 || text[i-1] == ' ' || text[i-1] == '\t'):
+			return strings.TrimRight(text[:i], " \t")
+		}
+	}
+	return text
+}
+
+func yamlScalar(s string) (any, error) {
+	switch {
+	case s == "":
+		return nil, nil
+	case s[0] == '"':
+		return strconv.Unquote(s)
+	case s[0] == '\'':
+		if len(s) < 2 || s[len(s)-1] != '\'' {
+			return nil, fmt.Errorf("unterminated string %s", s)
+		}
+		return strings.ReplaceAll(s[1:len(s)-1], "''", "'"), nil
+	case s[0] == '[':
+		if s[len(s)-1] != ']' {
+			return nil, fmt.Errorf("unterminated sequence %s", s)
+		}
+		items := []any{}
+		for _, part := range splitFlow(s[1 : len(s)-1]) {
+			value, err := yamlScalar(part)
+			if err != nil {
+				return nil, err
+			}
+			items = append(items, value)
+		}
+		return items, nil
+	case s[0] == '{':
+		if s[len(s)-1] != '}' {
+			return nil, fmt.Errorf("unterminated mapping %s", s)
+		}
+		m := make(map[string]any)
+		for _, part := range splitFlow(s[1 : len(s)-1]) {
+			key, rest, ok := splitKey(part)
+			if !ok {
+				return nil, fmt.Errorf("invalid mapping entry %q", part)
+			}
+			value, err := yamlScalar(rest)
+			if err != nil {
+				return nil, err
+			}
+			m[key] = value
+		}
+		return m, nil
+	case s[0] == '|' || s[0] == '>':
+		return nil, fmt.Errorf("unsupported block scalar header %q", s)
+	case s[0] == '&' || s[0] == '*' || s[0] == '!':
+		return nil, fmt.Errorf("anchors, aliases and tags are not supported")
+	}
+
+	switch s {
+	case "true", "True", "TRUE":
+		return true, nil
+	case "false", "False", "FALSE":
+		return false, nil
+	case "null", "Null", "NULL", "~":
+		return nil, nil
+	}
+	if n, ok := parseNumber(s); ok && !strings.ContainsAny(s, "_") {
+		return n, nil
+	}
+	return s, nil
+}
+
+// splitFlow splits the entries of a flow collection on top-level commas.
+func splitFlow(s string) []string {
+	var parts []string
+	depth, start := 0, 0
+	quote := byte(0)
+	for i := 0; i < len(s); i++ {
+		c := s[i]
+		switch {
+		case quote != 0:
+			if c == '\\' && quote == '"' {
+				i++
+			} else if c == quote {
+				quote = 0
+			}
+		case c == '"' || c == '\'':
+			quote = c
+		case c == '[' || c == '{':
+			depth++
+		case c == ']' || c == '}':
+			depth--
+		case c == ',' && depth == 0:
+			parts = append(parts, strings.TrimSpace(s[start:i]))
+			start = i + 1
+		}
+	}
+	if last := strings.TrimSpace(s[start:]); last != "" {
+		parts = append(parts, last)
+	}
+	return parts
+}
+
+// --- TOML ---
+
+func parseTOML(data []byte) (map[string]any, error) {
+	root := make(map[string]any)
+	current := root
+	defined := make(map[string]bool) // Explicitly defined tables
+
+	lines := strings.Split(strings.ReplaceAll(string(data), "\r\n", "\n"), "\n")
+	for i := 0; i < len(lines); i++ {
+		num := i + 1
+		text := strings.TrimSpace(stripComment(strings.TrimSpace(lines[i])))
+		if text == "" {
+			continue
+		}
+
+		// Join multi-line strings verbatim, up to the closing delimiter
+		if delim := openMultiline(text); delim != "" {
+			open := strings.Index(text, delim) + len(delim)
+			joined := text
+			for !strings.Contains(joined[open:], delim) {
+				if i+1 >= len(lines) {
+					return nil, fmt.Errorf("line %d: unterminated multi-line string", num)
+				}
+				i++
+				joined += "\n" + lines[i]
+			}
+			closing := open + strings.Index(joined[open:], delim) + len(delim)
+			// A delimiter may be followed by up to two quotes of content
+			for k := 0; k < 2 && closing < len(joined) && joined[closing] == delim[0]; k++ {
+				closing++
+			}
+			if rest := strings.TrimSpace(stripComment(joined[closing:])); rest != "" {
+				return nil, fmt.Errorf("line %d: unexpected %q after multi-line string", i+1, rest)
+			}
+			text = joined[:closing]
+		}
+
+		// Join arrays and inline tables that span lines
+		for depth := bracketDepth(text); depth > 0 && i+1 < len(lines); depth = bracketDepth(text) {
+			i++
+			text += " " + strings.TrimSpace(stripComment(strings.TrimSpace(lines[i])))
+		}
+
+		switch {
+		case strings.HasPrefix(text, "[["):
+			if !strings.HasSuffix(text, "]]") {
+				return nil, fmt.Errorf("line %d: invalid array of tables header", num)
+			}
+			path, err := tomlKey(text[2 : len(text)-2])
+			if err != nil {
+				return nil, fmt.Errorf("line %d: %w", num, err)
+			}
+			parent, err := tomlTable(root, path[:len(path)-1])
+			if err != nil {
+				return nil, fmt.Errorf("line %d: %w", num, err)
+			}
+			last := path[len(path)-1]
+			list, ok := parent[last].([]any)
+			if parent[last] != nil && !ok {
+				return nil, fmt.Errorf("line %d: %q is not an array of tables", num, last)
+			}
+			current = make(map[string]any)
+			parent[last] = append(list, current)
+
+		case strings.HasPrefix(text, "["):
+			if !strings.HasSuffix(text, "]") {
+				return nil, fmt.Errorf("line %d: invalid table header", num)
+			}
+			path, err := tomlKey(text[1 : len(text)-1])
+			if err != nil {
+				return nil, fmt.Errorf("line %d: %w", num, err)
+			}
+			name := strings.Join(path, ".")
+			if defined[name] {
+				return nil, fmt.Errorf("line %d: table %q defined twice", num, name)
+			}
+			defined[name] = true
+			current, err = tomlTable(root, path)
+			if err != nil {
+				return nil, fmt.Errorf("line %d: %w", num, err)
+			}
+
+		default:
+			if err := tomlAssign(current, text); err != nil {
+				return nil, fmt.Errorf("line %d: %w", num, err)
+			}
+		}
+	}
+	return root, nil
+}
+
+// tomlAssign parses "key = value" into table.
+func tomlAssign(table map[string]any, text string) error {
+	eq := -1
+	quote := byte(0)
+	for i := 0; i < len(text) && eq < 0; i++ {
+		switch c := text[i]; {
+		case quote != 0:
+			if c == quote {
+				quote = 0
+			}
+		case c == '"' || c == '\'':
+			quote = c
+		case c == '=':
+			eq = i
+		}
+	}
+	if eq < 0 {
+		return fmt.Errorf("expected \"key = value\"")
+	}
+
+	path, err := tomlKey(text[:eq])
+	if err != nil {
+		return err
+	}
+	value, err := tomlValue(strings.TrimSpace(text[eq+1:]))
+	if err != nil {
+		return err
+	}
+	parent, err := tomlTable(table, path[:len(path)-1])
+	if err != nil {
+		return err
+	}
+	last := path[len(path)-1]
+	if _, dup := parent[last]; dup {
+		return fmt.Errorf("duplicate key %q", last)
+	}
+	parent[last] = value
+	return nil
+}
+
+// tomlTable returns the table at path, creating missing tables. A path
+// through an array of tables refers to its last element.
+func tomlTable(root map[string]any, path []string) (map[string]any, error) {
+	table := root
+	for _, key := range path {
+		switch child := table[key].(type) {
+		case nil:
+			next := make(map[string]any)
+			table[key] = next
+			table = next
+		case map[string]any:
+			table = child
+		case []any:
+			last, ok := child[len(child)-1].(map[string]any)
+			if !ok {
+				return nil, fmt.Errorf("%q is not a table", key)
+			}
+			table = last
+		default:
+			return nil, fmt.Errorf("%q is not a table", key)
+		}
+	}
+	return table, nil
+}
+
+// tomlKey splits a dotted key, unquoting its parts.
+func tomlKey(s string) ([]string, error) {
+	var path []string
+	for _, part := range splitOutsideQuotes(strings.TrimSpace(s), '.') {
+		part = strings.TrimSpace(part)
+		switch {
+		case part == "":
+			return nil, fmt.Errorf("empty key in %q", s)
+		case part[0] == '"':
+			unquoted, err := strconv.Unquote(part)
+			if err != nil {
+				return nil, fmt.Errorf("invalid key %s", part)
+			}
+			part = unquoted
+		case part[0] == '\'':
+			part = strings.Trim(part, "'")
+		}
+		path = append(path, part)
+	}
+	return path, nil
+}
+
+func tomlValue(s string) (any, error) {
+	switch {
+	case s == "":
+		return nil, fmt.Errorf("missing value")
+	case strings.HasPrefix(s, `"""`) || strings.HasPrefix(s, "'''"):
+		return tomlMultiline(s)
+	case s[0] == '"':
+		return strconv.Unquote(s)
+	case s[0] == '\'':
+		if len(s) < 2 || s[len(s)-1] != '\'' {
+			return nil, fmt.Errorf("unterminated string %s", s)
+		}
+		return s[1 : len(s)-1], nil
+	case s[0] == '[':
+		if s[len(s)-1] != ']' {
+			return nil, fmt.Errorf("unterminated array %s", s)
+		}
+		items := []any{}
+		for _, part := range splitFlow(s[1 : len(s)-1]) {
+			value, err := tomlValue(part)
+			if err != nil {
+				return nil, err
+			}
+			items = append(items, value)
+		}
+		return items, nil
+	case s[0] == '{':
+		if s[len(s)-1] != '}' {
+			return nil, fmt.Errorf("unterminated inline table %s", s)
+		}
+		table := make(map[string]any)
+		for _, part := range splitFlow(s[1 : len(s)-1]) {
+			if err := tomlAssign(table, part); err != nil {
+				return nil, err
+			}
+		}
+		return table, nil
+	case s == "true":
+		return true, nil
+	case s == "false":
+		return false, nil
+	}
+	if n, ok := parseNumber(s); ok {
+		return n, nil
+	}
+	return nil, fmt.Errorf("unsupported value %s", s)
+}
+
+// openMultiline returns the delimiter of a multi-line string value that
+// starts but does not end in text.
+func openMultiline(text string) string {
+	eq := strings.Index(text, "=")
+	if eq < 0 {
+		return ""
+	}
+	value := strings.TrimSpace(text[eq+1:])
+	for _, delim := range []string{`"""`, "'''"} {
+		if strings.HasPrefix(value, delim) && !strings.Contains(value[len(delim):], delim) {
+			return delim
+		}
+	}
+	return ""
+}
+
+// tomlMultiline parses a multi-line basic or literal string. A newline
+// right after the opening delimiter is trimmed; in basic strings a
+// backslash at the end of a line trims the line break and the whitespace
+// that follows.
+func tomlMultiline(s string) (any, error) {
+	delim := s[:3]
+	if len(s) < 6 || !strings.HasSuffix(s, delim) {
+		return nil, fmt.Errorf("unterminated multi-line string")
+	}
+	body := strings.TrimPrefix(s[3:len(s)-3], "\n")
+	if delim == "'''" {
+		return body, nil
+	}
+
+	var b strings.Builder
+	for len(body) > 0 {
+		if body[0] != '\\' {
+			b.WriteByte(body[0])
+			body = body[1:]
+			continue
+		}
+		if rest := strings.TrimLeft(body[1:], " \t"); strings.HasPrefix(rest, "\n") {
+			body = strings.TrimLeft(rest, " \t\n")
+			continue
+		}
+		value, _, tail, err := strconv.UnquoteChar(body, '"')
+		if err != nil {
+			return nil, fmt.Errorf("invalid escape in %q", body[:min(len(body), 6)])
+		}
+		b.WriteRune(value)
+		body = tail
+	}
+	return b.String(), nil
+}
+
+// bracketDepth returns the number of unclosed brackets and braces in s.
+func bracketDepth(s string) int {
+	depth := 0
+	quote := byte(0)
+	for i := 0; i < len(s); i++ {
+		c := s[i]
+		switch {
+		case quote != 0:
+			if c == '\\' && quote == '"' {
+				i++
+			} else if c == quote {
+				quote = 0
+			}
+		case c == '"' || c == '\'':
+			quote = c
+		case c == '[' || c == '{':
+			depth++
+		case c == ']' || c == '}':
+			depth--
+		}
+	}
+	return depth
+}
+
+func splitOutsideQuotes(s string, sep byte) []string {
+	var parts []string
+	start := 0
+	quote := byte(0)
+	for i := 0; i < len(s); i++ {
+		c := s[i]
+		switch {
+		case quote != 0:
+			if c == quote {
+				quote = 0
+			}
+		case c == '"' || c == '\'':
+			quote = c
+		case c == sep:
+			parts = append(parts, s[start:i])
+			start = i + 1
+		}
+	}
+	return append(parts, s[start:])
+}
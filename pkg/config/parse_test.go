@@ -0,0 +1,138 @@
+package config
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+)
+
+func TestParseYAML(t *testing.T) {
+	tests := []struct {
+		name string
+		in   string
+		want string // JSON
+	}{
+		{"scalars", "a: 1\nb: 1.5\nc: true\nd: null\ne: text with spaces\nf: \"quoted: #\"\ng: 'it''s'\n",
+			`{"a":1,"b":1.5,"c":true,"d":null,"e":"text with spaces","f":"quoted: #","g":"it's"}`},
+		{"comments", "# header\na: 1 # trailing\nb: x#y\n", `{"a":1,"b":"x#y"}`},
+		{"nested", "a:\n  b:\n    c: 1\n  d: 2\n", `{"a":{"b":{"c":1},"d":2}}`},
+		{"sequence", "a:\n  - 1\n  - two\nb:\n- x\n", `{"a":[1,"two"],"b":["x"]}`},
+		{"sequence of mappings", "s:\n  - name: fs\n    args: [-y, \"x y\"]\n  - name: web\n",
+			`{"s":[{"args":["-y","x y"],"name":"fs"},{"name":"web"}]}`},
+		{"flow mapping", "b: {ai_dollars: 20, window: day}\n", `{"b":{"ai_dollars":20,"window":"day"}}`},
+		{"literal", "p: |\n  line one\n    indented\n\n  # not a comment\nq: 1\n",
+			`{"p":"line one\n  indented\n\n# not a comment\n","q":1}`},
+		{"literal strip", "p: |-\n  a\n  b\n\n", `{"p":"a\nb"}`},
+		{"literal keep", "p: |+\n  a\n\n\nq: 1\n", `{"p":"a\n\n\n","q":1}`},
+		{"folded", "p: >\n  one\n  two\n\n  three\n", `{"p":"one two\nthree\n"}`},
+		{"folded more indented", "p: >-\n  a\n    code\n  b\n", `{"p":"a\n  code\nb"}`},
+		{"block scalar in sequence", "l:\n  - |\n    x\n  - y\n", `{"l":["x\n","y"]}`},
+		{"block scalar in sequence mapping", "l:\n  - name: a\n    prompt: >\n      p q\n", `{"l":[{"name":"a","prompt":"p q\n"}]}`},
+		{"crlf", "a: 1\r\nb: |\r\n  x\r\n", `{"a":1,"b":"x\n"}`},
+		{"empty", "# nothing\n", `{}`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := parseYAML([]byte(tt.in))
+			if err != nil {
+				t.Fatalf("parseYAML: %v", err)
+			}
+			assertJSON(t, got, tt.want)
+		})
+	}
+}
+
+func TestParseYAMLErrors(t *testing.T) {
+	tests := []struct {
+		name string
+		in   string
+		want string
+	}{
+		{"tab indent", "a:\n\tb: 1\n", "tabs"},
+		{"bad indent", "a: 1\n  b: 2\n", "unexpected indentation"},
+		{"duplicate", "a: 1\na: 2\n", "duplicate key"},
+		{"no key", "just text\n", "expected"},
+		{"anchor", "a: &x 1\n", "anchors"},
+		{"indentation indicator", "a: |2\n  x\n", "unsupported block scalar header"},
+		{"documents", "a: 1\n--- \nb: 2\n", "multiple documents"},
+		{"top level sequence", "- a\n", "mapping"},
+		{"unterminated flow", "a: [1, 2\n", "unterminated"},
+		{"bad quote", "a: \"x\n", "line 1"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := parseYAML([]byte(tt.in))
+			if err == nil || !strings.Contains(err.Error(), tt.want) {
+				t.Errorf("parseYAML error = %v, want %q", err, tt.want)
+			}
+		})
+	}
+}
+
+func TestParseTOML(t *testing.T) {
+	tests := []struct {
+		name string
+		in   string
+		want string // JSON
+	}{
+		{"scalars", "a = 1\nb = 1_000\nc = 1.5\nd = true\ne = \"x # y\"\nf = 'c:\\path'\n",
+			`{"a":1,"b":1000,"c":1.5,"d":true,"e":"x # y","f":"c:\\path"}`},
+		{"tables", "top = 1\n[profiles.dev]\nmodel = \"m\"\n[profiles.\"prod.eu\"]\nmax_turns = 2\n",
+			`{"profiles":{"dev":{"model":"m"},"prod.eu":{"max_turns":2}},"top":1}`},
+		{"dotted keys", "budget.ai_dollars = 5\nbudget.window = \"day\"\n", `{"budget":{"ai_dollars":5,"window":"day"}}`},
+		{"array of tables", "[[mcp_servers]]\nname = \"a\"\n[[mcp_servers]]\nname = \"b\"\nargs = [\n  \"-y\", # comment\n  \"x\",\n]\n",
+			`{"mcp_servers":[{"name":"a"},{"args":["-y","x"],"name":"b"}]}`},
+		{"inline table", "budget = { ai_dollars = 20, window = \"day\" }\n", `{"budget":{"ai_dollars":20,"window":"day"}}`},
+		{"multi-line basic", "p = \"\"\"\nline \"one\"\n# not a comment\\t!\n\"\"\"\nq = 1\n",
+			`{"p":"line \"one\"\n# not a comment\t!\n","q":1}`},
+		{"multi-line continuation", "p = \"\"\"\\\n  one \\\n  two\"\"\"\n", `{"p":"one two"}`},
+		{"multi-line literal", "p = '''\nC:\\dir\\n\n'''  # trailing comment\n", `{"p":"C:\\dir\\n\n"}`},
+		{"multi-line single line", "p = \"\"\"a\"b\"\"\"\"\n", `{"p":"a\"b\""}`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := parseTOML([]byte(tt.in))
+			if err != nil {
+				t.Fatalf("parseTOML: %v", err)
+			}
+			assertJSON(t, got, tt.want)
+		})
+	}
+}
+
+func TestParseTOMLErrors(t *testing.T) {
+	tests := []struct {
+		name string
+		in   string
+		want string
+	}{
+		{"no value", "a =\n", "missing value"},
+		{"no equals", "a\n", "key = value"},
+		{"duplicate key", "a = 1\na = 2\n", "duplicate key"},
+		{"table twice", "[a]\n[a]\n", "defined twice"},
+		{"date", "d = 2024-01-01\n", "unsupported value"},
+		{"unterminated multi-line", "p = \"\"\"\nabc\n", "unterminated multi-line string"},
+		{"text after multi-line", "p = \"\"\"a\n\"\"\" x\n", "after multi-line string"},
+		{"bad escape", "p = \"\"\"\\q\"\"\"\n", "invalid escape"},
+		{"not a table", "a = 1\n[a.b]\n", "not a table"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := parseTOML([]byte(tt.in))
+			if err == nil || !strings.Contains(err.Error(), tt.want) {
+				t.Errorf("parseTOML error = %v, want %q", err, tt.want)
+			}
+		})
+	}
+}
+
+func assertJSON(t *testing.T, got any, want string) {
+	t.Helper()
+	data, err := json.Marshal(got)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(data) != want {
+		t.Errorf("got  %s\nwant %s", data, want)
+	}
+}
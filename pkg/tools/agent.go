@@ -0,0 +1,72 @@
+package tools
+
+import (
+	"github.com/chucky-cloud/chucky-sdk-go/pkg/types"
+)
+
+// AgentBuilder helps build subagent definitions.
+type AgentBuilder struct {
+	agent types.AgentDefinition
+}
+
+// NewAgent creates a new subagent builder. The description tells the main
+// agent when to delegate to the subagent; the prompt is its system prompt.
+func NewAgent(description, prompt string) *AgentBuilder {
+	return &AgentBuilder{
+		agent: types.AgentDefinition{
+			Description: description,
+			Prompt:      prompt,
+		},
+	}
+}
+
+// Tools restricts the subagent to the given tools, plus those of its MCP
+// servers. Without it the subagent inherits all of the session's tools.
+func (b *AgentBuilder) Tools(names ...string) *AgentBuilder {
+	b.agent.Tools = append(b.agent.Tools, names...)
+	if b.agent.Tools == nil {
+		b.agent.Tools = []string{}
+	}
+	return b
+}
+
+// Model sets the subagent's model: an alias (sonnet, opus, haiku), a full
+// model ID or types.AgentModelInherit.
+func (b *AgentBuilder) Model(model string) *AgentBuilder {
+	b.agent.Model = model
+	return b
+}
+
+// InheritModel runs the subagent on the session's model.
+func (b *AgentBuilder) InheritModel() *AgentBuilder {
+	return b.Model(types.AgentModelInherit)
+}
+
+// McpServer makes an MCP server available to the subagent. The tools of
+// client tool servers are added to the allowed tools and handled by the
+// session.
+func (b *AgentBuilder) McpServer(servers ...types.McpServerDefinition) *AgentBuilder {
+	b.agent.McpServers = append(b.agent.McpServers, servers...)
+	return b
+}
+
+// AddTools gives the subagent client-side tools, served by a new MCP server
+// with the given name.
+func (b *AgentBuilder) AddTools(server string, tools ...types.ToolDefinition) *AgentBuilder {
+	return b.McpServer(CreateSdkMcpServer(server, tools...))
+}
+
+// Build returns the completed subagent definition.
+func (b *AgentBuilder) Build() types.AgentDefinition {
+	return b.agent
+}
+
+// Agent is a shorthand for a subagent restricted to the given tools. With no
+// tools the subagent inherits all of the session's tools.
+func Agent(description, prompt string, tools ...string) types.AgentDefinition {
+	b := NewAgent(description, prompt)
+	if len(tools) > 0 {
+		b.Tools(tools...)
+	}
+	return b.Build()
+}
@@ -6,9 +6,10 @@ import (
 
 // McpServerBuilder helps build MCP server configurations.
 type McpServerBuilder struct {
-	name    string
-	version string
-	tools   []types.ToolDefinition
+	name       string
+	version    string
+	tools      []types.ToolDefinition
+	middleware []types.ToolMiddleware
 }
 
 // NewMcpServer creates a new MCP server builder.
@@ -44,12 +45,19 @@ func (b *McpServerBuilder) AddTools(tools ...types.ToolDefinition) *McpServerBui
 	return b
 }
 
+// Use adds middleware that wraps every tool handler of the server.
+func (b *McpServerBuilder) Use(middleware ...types.ToolMiddleware) *McpServerBuilder {
+	b.middleware = append(b.middleware, middleware...)
+	return b
+}
+
 // Build returns the completed MCP server definition.
 func (b *McpServerBuilder) Build() types.McpClientToolsServer {
 	return types.McpClientToolsServer{
-		Name:    b.name,
-		Version: b.version,
-		Tools:   b.tools,
+		Name:       b.name,
+		Version:    b.version,
+		Tools:      b.tools,
+		Middleware: b.middleware,
 	}
 }
 
@@ -84,6 +92,15 @@ func StdioServerWithEnv(name, command string, args []string, env map[string]stri
 	}
 }
 
+// LocalStdioServer creates an MCP stdio server configuration that runs on
+// the client. The SDK spawns the process and proxies the agent's tool calls
+// to it, so it can use local binaries, files and networks.
+func LocalStdioServer(name, command string, args ...string) types.McpStdioServerConfig {
+	server := StdioServer(name, command, args...)
+	server.Local = true
+	return server
+}
+
 // SSEServer creates an MCP SSE server configuration.
 func SSEServer(name, url string, headers ...map[string]string) types.McpSSEServerConfig {
 	var h map[string]string
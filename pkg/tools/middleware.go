@@ -0,0 +1,238 @@
+package tools
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"runtime/debug"
+	"sync"
+	"time"
+
+	"github.com/chucky-cloud/chucky-sdk-go/pkg/types"
+)
+
+// UseMiddleware returns a copy of tool with the given middleware appended.
+func UseMiddleware(tool types.ToolDefinition, middleware ...types.ToolMiddleware) types.ToolDefinition {
+	tool.Middleware = append(append([]types.ToolMiddleware(nil), tool.Middleware...), middleware...)
+	return tool
+}
+
+// Recover converts a panic in the wrapped handler into an error result.
+func Recover() types.ToolMiddleware {
+	return func(next types.ToolHandler) types.ToolHandler {
+		return func(ctx context.Context, input map[string]any) (result *types.ToolResult, err error) {
+			defer func() {
+				if r := recover(); r != nil {
+					toolName := ""
+					if info, ok := types.ToolCallInfoFromContext(ctx); ok {
+						toolName = info.ToolName
+					}
+					result = nil
+					err = types.ToolExecutionError(toolName, fmt.Sprintf("panic: %v", r)).
+						WithDetails(map[string]any{
+							"toolName": toolName,
+							"stack":    string(debug.Stack()),
+						})
+				}
+			}()
+			return next(ctx, input)
+		}
+	}
+}
+
+// Logging logs every tool invocation with its duration and outcome.
+// If logger is nil, slog.Default() is used.
+func Logging(logger *slog.Logger) types.ToolMiddleware {
+	if logger == nil {
+		logger = slog.Default()
+	}
+	return func(next types.ToolHandler) types.ToolHandler {
+		return func(ctx context.Context, input map[string]any) (*types.ToolResult, error) {
+			info, _ := types.ToolCallInfoFromContext(ctx)
+			start := time.Now()
+
+			result, err := next(ctx, input)
+
+			attrs := []any{
+				slog.String("tool", info.ToolName),
+				slog.String("call_id", info.CallID),
+				slog.String("session_id", info.SessionID),
+				slog.Duration("duration", time.Since(start)),
+			}
+			switch {
+			case err != nil:
+				logger.ErrorContext(ctx, "tool call failed", append(attrs, slog.Any("error", err))...)
+			case result != nil && result.IsError:
+				logger.WarnContext(ctx, "tool call returned error result", attrs...)
+			default:
+				logger.InfoContext(ctx, "tool call completed", attrs...)
+			}
+			return result, err
+		}
+	}
+}
+
+// RetryOptions configures the Retry middleware.
+type RetryOptions struct {
+	// MaxAttempts is the total number of attempts, including the first one.
+	// Default: 3.
+	MaxAttempts int
+
+	// InitialBackoff is the delay before the first retry. Default: 100ms.
+	InitialBackoff time.Duration
+
+	// MaxBackoff caps the delay between retries. Default: 5s.
+	MaxBackoff time.Duration
+
+	// Multiplier is applied to the backoff after every retry. Default: 2.
+	Multiplier float64
+
+	// Retryable reports whether an error is transient. Default:
+	// types.IsRetryable, which accepts overloads, rate and concurrency
+	// limits, connection failures and timeouts, but not plain errors.
+	Retryable func(err error) bool
+}
+
+// Retry retries the wrapped handler with exponential backoff when it
+// returns a transient error. It stops once the call's context is done.
+func Retry(opts RetryOptions) types.ToolMiddleware {
+	if opts.MaxAttempts <= 0 {
+		opts.MaxAttempts = 3
+	}
+	if opts.InitialBackoff <= 0 {
+		opts.InitialBackoff = 100 * time.Millisecond
+	}
+	if opts.MaxBackoff <= 0 {
+		opts.MaxBackoff = 5 * time.Second
+	}
+	if opts.Multiplier < 1 {
+		opts.Multiplier = 2
+	}
+	if opts.Retryable == nil {
+		opts.Retryable = types.IsRetryable
+	}
+
+	return func(next types.ToolHandler) types.ToolHandler {
+		return func(ctx context.Context, input map[string]any) (*types.ToolResult, error) {
+			backoff := opts.InitialBackoff
+			for attempt := 1; ; attempt++ {
+				result, err := next(ctx, input)
+				if err == nil || attempt >= opts.MaxAttempts || ctx.Err() != nil || !opts.Retryable(err) {
+					return result, err
+				}
+
+				select {
+				case <-time.After(backoff):
+				case <-ctx.Done():
+					return nil, ctx.Err()
+				}
+
+				backoff = time.Duration(float64(backoff) * opts.Multiplier)
+				if backoff > opts.MaxBackoff {
+					backoff = opts.MaxBackoff
+				}
+			}
+		}
+	}
+}
+
+// RateLimit limits each tool to rate calls per second with the given burst,
+// using one token bucket per tool name. Calls wait for a token until the
+// context is done. Like time.NewTicker, it panics if rate is not positive;
+// omit the middleware for unlimited calls.
+func RateLimit(rate float64, burst int) types.ToolMiddleware {
+	if !(rate > 0) {
+		panic("tools: non-positive rate for RateLimit")
+	}
+	if burst <= 0 {
+		burst = 1
+	}
+
+	var mu sync.Mutex
+	buckets := make(map[string]*tokenBucket)
+
+	return func(next types.ToolHandler) types.ToolHandler {
+		return func(ctx context.Context, input map[string]any) (*types.ToolResult, error) {
+			info, _ := types.ToolCallInfoFromContext(ctx)
+
+			mu.Lock()
+			bucket, ok := buckets[info.ToolName]
+			if !ok {
+				bucket = newTokenBucket(rate, burst)
+				buckets[info.ToolName] = bucket
+			}
+			mu.Unlock()
+
+			if err := bucket.wait(ctx); err != nil {
+				return nil, types.RateLimitError("tool rate limit wait aborted").Wrap(err)
+			}
+			return next(ctx, input)
+		}
+	}
+}
+
+// ConcurrencyLimit caps the number of concurrent executions of the wrapped
+// handlers. Calls wait for a free slot until the context is done.
+func ConcurrencyLimit(max int) types.ToolMiddleware {
+	if max <= 0 {
+		max = 1
+	}
+	sem := make(chan struct{}, max)
+
+	return func(next types.ToolHandler) types.ToolHandler {
+		return func(ctx context.Context, input map[string]any) (*types.ToolResult, error) {
+			select {
+			case sem <- struct{}{}:
+			case <-ctx.Done():
+				return nil, types.ConcurrencyLimitError("tool concurrency limit wait aborted").Wrap(ctx.Err())
+			}
+			defer func() { <-sem }()
+			return next(ctx, input)
+		}
+	}
+}
+
+// tokenBucket is a minimal token-bucket rate limiter.
+type tokenBucket struct {
+	mu     sync.Mutex
+	rate   float64
+	burst  float64
+	tokens float64
+	last   time.Time
+}
+
+func newTokenBucket(rate float64, burst int) *tokenBucket {
+	return &tokenBucket{
+		rate:   rate,
+		burst:  float64(burst),
+		tokens: float64(burst),
+		last:   time.Now(),
+	}
+}
+
+func (b *tokenBucket) wait(ctx context.Context) error {
+	for {
+		b.mu.Lock()
+		now := time.Now()
+		b.tokens += now.Sub(b.last).Seconds() * b.rate
+		if b.tokens > b.burst {
+			b.tokens = b.burst
+		}
+		b.last = now
+
+		if b.tokens >= 1 {
+			b.tokens--
+			b.mu.Unlock()
+			return nil
+		}
+
+		delay := time.Duration((1 - b.tokens) / b.rate * float64(time.Second))
+		b.mu.Unlock()
+
+		select {
+		case <-time.After(delay):
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+}
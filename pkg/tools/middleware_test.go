@@ -0,0 +1,129 @@
+package tools
+
+import (
+	"context"
+	"errors"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/chucky-cloud/chucky-sdk-go/pkg/types"
+)
+
+// failing returns a handler that fails with errs in turn, then succeeds.
+func failing(calls *atomic.Int32, errs ...error) types.ToolHandler {
+	return func(ctx context.Context, input map[string]any) (*types.ToolResult, error) {
+		n := int(calls.Add(1))
+		if n <= len(errs) {
+			return nil, errs[n-1]
+		}
+		return &types.ToolResult{}, nil
+	}
+}
+
+func TestRetry(t *testing.T) {
+	plain := errors.New("bad input")
+	tests := []struct {
+		name      string
+		opts      RetryOptions
+		errs      []error
+		wantCalls int
+		wantErr   error
+	}{
+		{"success", RetryOptions{}, nil, 1, nil},
+		{"overloaded", RetryOptions{}, []error{types.ErrOverloaded, types.ErrOverloaded}, 3, nil},
+		{"connection", RetryOptions{}, []error{types.ConnectionError("reset")}, 2, nil},
+		{"timeout", RetryOptions{}, []error{types.TimeoutError("slow")}, 2, nil},
+		{"wrapped", RetryOptions{}, []error{types.InternalError("x").Wrap(types.ErrOverloaded)}, 1, types.ErrInternal},
+		{"plain error", RetryOptions{}, []error{plain}, 1, plain},
+		{"validation", RetryOptions{}, []error{types.ValidationError("no")}, 1, types.ErrValidation},
+		{"server says permanent", RetryOptions{},
+			[]error{types.ConnectionError("gone").WithDetails(map[string]any{types.DetailRetryable: false})}, 1, types.ErrConnection},
+		{"attempts exhausted", RetryOptions{MaxAttempts: 2},
+			[]error{types.ErrOverloaded, types.ErrOverloaded, types.ErrOverloaded}, 2, types.ErrOverloaded},
+		{"custom retryable", RetryOptions{Retryable: func(err error) bool { return errors.Is(err, plain) }},
+			[]error{plain}, 2, nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.opts.InitialBackoff = time.Millisecond
+			var calls atomic.Int32
+			_, err := Retry(tt.opts)(failing(&calls, tt.errs...))(context.Background(), nil)
+			if int(calls.Load()) != tt.wantCalls {
+				t.Errorf("calls = %d, want %d", calls.Load(), tt.wantCalls)
+			}
+			if tt.wantErr == nil && err != nil || tt.wantErr != nil && !errors.Is(err, tt.wantErr) {
+				t.Errorf("err = %v, want %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestRetryStopsWhenContextDone(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	var calls atomic.Int32
+	handler := func(ctx context.Context, input map[string]any) (*types.ToolResult, error) {
+		calls.Add(1)
+		cancel()
+		return nil, types.ErrOverloaded
+	}
+	_, err := Retry(RetryOptions{MaxAttempts: 5, InitialBackoff: time.Hour})(handler)(ctx, nil)
+	if calls.Load() != 1 || !errors.Is(err, types.ErrOverloaded) {
+		t.Errorf("calls = %d, err = %v", calls.Load(), err)
+	}
+}
+
+func TestRateLimit(t *testing.T) {
+	var calls atomic.Int32
+	limited := RateLimit(20, 2)(failing(&calls))
+	ctx := types.WithToolCallInfo(context.Background(), types.ToolCallInfo{ToolName: "search"})
+	other := types.WithToolCallInfo(context.Background(), types.ToolCallInfo{ToolName: "fetch"})
+
+	start := time.Now()
+	for i := 0; i < 2; i++ {
+		if _, err := limited(ctx, nil); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if elapsed := time.Since(start); elapsed > 25*time.Millisecond {
+		t.Errorf("burst took %v", elapsed)
+	}
+
+	// Each tool has its own bucket
+	if _, err := limited(other, nil); err != nil {
+		t.Fatal(err)
+	}
+	if elapsed := time.Since(start); elapsed > 25*time.Millisecond {
+		t.Errorf("other tool waited for %v", elapsed)
+	}
+
+	// The bucket is empty: the next call waits about 1/rate
+	if _, err := limited(ctx, nil); err != nil {
+		t.Fatal(err)
+	}
+	if elapsed := time.Since(start); elapsed < 40*time.Millisecond {
+		t.Errorf("call after burst returned after %v, want about 50ms", elapsed)
+	}
+
+	cancelled, cancel := context.WithCancel(ctx)
+	cancel()
+	if _, err := limited(cancelled, nil); !errors.Is(err, types.ErrRateLimit) || !errors.Is(err, context.Canceled) {
+		t.Errorf("cancelled wait = %v", err)
+	}
+	if calls.Load() != 4 {
+		t.Errorf("calls = %d, want 4", calls.Load())
+	}
+}
+
+func TestRateLimitRejectsNonPositiveRate(t *testing.T) {
+	for _, rate := range []float64{0, -1} {
+		func() {
+			defer func() {
+				if recover() == nil {
+					t.Errorf("RateLimit(%v) did not panic", rate)
+				}
+			}()
+			RateLimit(rate, 1)
+		}()
+	}
+}
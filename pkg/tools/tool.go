@@ -14,6 +14,7 @@ type CreateToolOptions struct {
 	InputSchema types.ToolInputSchema
 	ExecuteIn   types.ExecuteLocation
 	Handler     types.ToolHandler
+	Middleware  []types.ToolMiddleware
 }
 
 // CreateTool creates a new tool definition.
@@ -29,6 +30,7 @@ func CreateTool(opts CreateToolOptions) types.ToolDefinition {
 		InputSchema: opts.InputSchema,
 		ExecuteIn:   executeIn,
 		Handler:     opts.Handler,
+		Middleware:  opts.Middleware,
 	}
 }
 
@@ -44,3 +44,10 @@ type Transport interface {
 	// WaitForReady blocks until the connection is ready.
 	WaitForReady() error
 }
+
+// Waiter is implemented by transports that run background goroutines.
+type Waiter interface {
+	// Wait blocks until the goroutines started by Connect have exited.
+	// It must not be called from a transport event handler.
+	Wait()
+}
@@ -18,6 +18,7 @@ import (
 type WebSocketTransport struct {
 	baseURL           string
 	token             string
+	tokenProvider     func() (string, error)
 	timeout           time.Duration
 	keepAliveInterval time.Duration
 	debug             bool
@@ -25,6 +26,7 @@ type WebSocketTransport struct {
 	conn    *websocket.Conn
 	status  ConnectionStatus
 	mu      sync.RWMutex
+	writeMu sync.Mutex // Serializes writes; the connection allows one writer at a time
 	handlers TransportEvents
 
 	readyCh    chan struct{}
@@ -34,12 +36,15 @@ type WebSocketTransport struct {
 
 	msgQueue   []types.OutgoingMessage
 	queueMu    sync.Mutex
+
+	wg sync.WaitGroup // Read loop and keep-alive
 }
 
 // WebSocketTransportOptions contains options for creating a WebSocket transport.
 type WebSocketTransportOptions struct {
 	BaseURL           string
 	Token             string
+	TokenProvider     func() (string, error) // Called on every Connect; overrides Token
 	Timeout           time.Duration
 	KeepAliveInterval time.Duration
 	Debug             bool
@@ -57,6 +62,7 @@ func NewWebSocketTransport(opts WebSocketTransportOptions) *WebSocketTransport {
 	return &WebSocketTransport{
 		baseURL:           opts.BaseURL,
 		token:             opts.Token,
+		tokenProvider:     opts.TokenProvider,
 		timeout:           opts.Timeout,
 		keepAliveInterval: opts.KeepAliveInterval,
 		debug:             opts.Debug,
@@ -95,8 +101,16 @@ func (t *WebSocketTransport) Connect() error {
 		return types.ConnectionError("invalid URL").Wrap(err)
 	}
 
+	token := t.token
+	if t.tokenProvider != nil {
+		if token, err = t.tokenProvider(); err != nil {
+			t.setStatus(StatusError)
+			return types.ConnectionError("failed to get token").Wrap(err)
+		}
+	}
+
 	q := u.Query()
-	q.Set("token", t.token)
+	q.Set("token", token)
 	q.Set("type", "prompt")
 	u.RawQuery = q.Encode()
 
@@ -143,11 +157,19 @@ func (t *WebSocketTransport) Connect() error {
 	// Flush queued messages
 	t.flushQueue()
 
+	t.wg.Add(2)
+
 	// Start read loop
-	go t.readLoop()
+	go func() {
+		defer t.wg.Done()
+		t.readLoop()
+	}()
 
 	// Start keep-alive
-	go t.keepAliveLoop()
+	go func() {
+		defer t.wg.Done()
+		t.keepAliveLoop()
+	}()
 
 	return nil
 }
@@ -165,8 +187,10 @@ func (t *WebSocketTransport) Disconnect() error {
 
 	if conn != nil {
 		// Send close control message
+		t.writeMu.Lock()
 		_ = conn.WriteMessage(websocket.CloseMessage,
 			websocket.FormatCloseMessage(websocket.CloseNormalClosure, ""))
+		t.writeMu.Unlock()
 		_ = conn.Close()
 	}
 
@@ -210,7 +234,10 @@ func (t *WebSocketTransport) sendImmediate(msg types.OutgoingMessage) error {
 		return types.ConnectionError("not connected")
 	}
 
-	if err := conn.WriteMessage(websocket.TextMessage, data); err != nil {
+	t.writeMu.Lock()
+	err = conn.WriteMessage(websocket.TextMessage, data)
+	t.writeMu.Unlock()
+	if err != nil {
 		return types.ConnectionError("failed to send message").Wrap(err)
 	}
 
@@ -307,6 +334,12 @@ func (t *WebSocketTransport) keepAliveLoop() {
 	}
 }
 
+// Wait blocks until the read loop and keep-alive have exited. Call it after
+// Disconnect; it must not be called from a transport event handler.
+func (t *WebSocketTransport) Wait() {
+	t.wg.Wait()
+}
+
 // SetEventHandlers sets the callbacks for transport events.
 func (t *WebSocketTransport) SetEventHandlers(handlers TransportEvents) {
 	t.handlers = handlers
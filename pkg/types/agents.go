@@ -0,0 +1,73 @@
+package types
+
+import "sort"
+
+// AgentModelInherit runs a subagent on the parent session's model.
+const AgentModelInherit = "inherit"
+
+// AgentDefinition defines a named subagent the main agent can delegate to
+// with the Task tool.
+type AgentDefinition struct {
+	// Description tells the main agent when to use this subagent.
+	Description string `json:"description"`
+
+	// Prompt is the subagent's system prompt.
+	Prompt string `json:"prompt"`
+
+	// Tools the subagent may use. Nil inherits all of the session's tools.
+	// Tools of McpServers are added automatically.
+	Tools []string `json:"tools,omitempty"`
+
+	// Model is a model alias (sonnet, opus, haiku), a full model ID or
+	// AgentModelInherit. Empty uses the server default for subagents.
+	Model string `json:"model,omitempty"`
+
+	// McpServers are made available to the session so their tools can be
+	// used by this subagent. Client tool handlers are registered with the
+	// session like those of SessionOptions.McpServers.
+	McpServers []McpServerDefinition `json:"-"`
+}
+
+// McpToolName returns the qualified name of an MCP tool, e.g.
+// "mcp__server__tool".
+func McpToolName(server, tool string) string {
+	return "mcp__" + server + "__" + tool
+}
+
+// AllowedTools returns Tools plus the qualified names of the tools of the
+// agent's client tool servers, or nil if Tools is nil.
+func (a AgentDefinition) AllowedTools() []string {
+	if a.Tools == nil {
+		return nil
+	}
+	tools := append([]string(nil), a.Tools...)
+	for _, server := range a.McpServers {
+		if srv, ok := server.(McpClientToolsServer); ok {
+			for _, tool := range srv.Tools {
+				tools = append(tools, McpToolName(srv.Name, tool.Name))
+			}
+		}
+	}
+	return tools
+}
+
+// AllMcpServers returns the session's MCP servers followed by the servers
+// of its agents, in agent name order. Names must be unique across all lists
+// (see SessionOptions.Validate); to share a server between agents, add it to
+// McpServers and list its tools in each agent's Tools.
+func (o *SessionOptions) AllMcpServers() []McpServerDefinition {
+	servers := append([]McpServerDefinition(nil), o.McpServers...)
+	for _, name := range sortedAgentNames(o.Agents) {
+		servers = append(servers, o.Agents[name].McpServers...)
+	}
+	return servers
+}
+
+func sortedAgentNames(agents map[string]AgentDefinition) []string {
+	names := make([]string, 0, len(agents))
+	for name := range agents {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
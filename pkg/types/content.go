@@ -0,0 +1,464 @@
+package types
+
+import (
+	"bytes"
+	"encoding/json"
+	"sort"
+)
+
+// ContentBlockType represents the type of content block.
+type ContentBlockType string
+
+const (
+	ContentBlockTypeText             ContentBlockType = "text"
+	ContentBlockTypeImage            ContentBlockType = "image"
+	ContentBlockTypeToolUse          ContentBlockType = "tool_use"
+	ContentBlockTypeToolResult       ContentBlockType = "tool_result"
+	ContentBlockTypeThinking         ContentBlockType = "thinking"
+	ContentBlockTypeRedactedThinking ContentBlockType = "redacted_thinking"
+	ContentBlockTypeDocument         ContentBlockType = "document"
+)
+
+// ContentBlock is one block of message content. It is one of *TextBlock,
+// *ThinkingBlock, *RedactedThinkingBlock, *ToolUseBlock, *ToolResultBlock,
+// *ImageBlock, *DocumentBlock, or *RawBlock for types the SDK does not know.
+//
+// Fields a block type does not model are kept in its Extra map, so decoded
+// blocks marshal back without losing data.
+type ContentBlock interface {
+	BlockType() ContentBlockType
+	isContentBlock()
+}
+
+// TextBlock is plain text content.
+type TextBlock struct {
+	Text      string                     `json:"text"`
+	Citations any                        `json:"citations,omitempty"`
+	Extra     map[string]json.RawMessage `json:"-"`
+}
+
+// ThinkingBlock is extended thinking content.
+type ThinkingBlock struct {
+	Thinking  string                     `json:"thinking"`
+	Signature string                     `json:"signature,omitempty"`
+	Extra     map[string]json.RawMessage `json:"-"`
+}
+
+// RedactedThinkingBlock is thinking content encrypted by the API.
+type RedactedThinkingBlock struct {
+	Data  string                     `json:"data"`
+	Extra map[string]json.RawMessage `json:"-"`
+}
+
+// ToolUseBlock is a tool invocation by the model. Input is kept as raw JSON.
+type ToolUseBlock struct {
+	ID    string                     `json:"id"`
+	Name  string                     `json:"name"`
+	Input json.RawMessage            `json:"input"`
+	Extra map[string]json.RawMessage `json:"-"`
+}
+
+// ToolResultBlock is the result of a tool invocation. Content is a string or
+// []ContentBlock.
+type ToolResultBlock struct {
+	ToolUseID string                     `json:"tool_use_id"`
+	Content   any                        `json:"content,omitempty"`
+	IsError   bool                       `json:"is_error,omitempty"`
+	Extra     map[string]json.RawMessage `json:"-"`
+}
+
+// ImageBlock is image content.
+type ImageBlock struct {
+	Source *ImageSource               `json:"source"`
+	Extra  map[string]json.RawMessage `json:"-"`
+}
+
+// ImageSource represents the source of an image.
+type ImageSource struct {
+	Type      string `json:"type"` // base64 or url
+	MediaType string `json:"media_type,omitempty"`
+	Data      string `json:"data,omitempty"`
+	URL       string `json:"url,omitempty"`
+}
+
+// DocumentBlock is document content such as a PDF or plain text.
+type DocumentBlock struct {
+	Source    *DocumentSource            `json:"source"`
+	Title     string                     `json:"title,omitempty"`
+	Context   string                     `json:"context,omitempty"`
+	Citations any                        `json:"citations,omitempty"`
+	Extra     map[string]json.RawMessage `json:"-"`
+}
+
+// DocumentSource represents the source of a document.
+type DocumentSource struct {
+	Type      string `json:"type"` // base64, text, url or content
+	MediaType string `json:"media_type,omitempty"`
+	Data      string `json:"data,omitempty"`
+	URL       string `json:"url,omitempty"`
+	Content   any    `json:"content,omitempty"`
+}
+
+// RawBlock holds a block of a type the SDK does not know, as received.
+type RawBlock struct {
+	Type ContentBlockType
+	Raw  json.RawMessage
+}
+
+func (*TextBlock) BlockType() ContentBlockType             { return ContentBlockTypeText }
+func (*ThinkingBlock) BlockType() ContentBlockType         { return ContentBlockTypeThinking }
+func (*RedactedThinkingBlock) BlockType() ContentBlockType { return ContentBlockTypeRedactedThinking }
+func (*ToolUseBlock) BlockType() ContentBlockType          { return ContentBlockTypeToolUse }
+func (*ToolResultBlock) BlockType() ContentBlockType       { return ContentBlockTypeToolResult }
+func (*ImageBlock) BlockType() ContentBlockType            { return ContentBlockTypeImage }
+func (*DocumentBlock) BlockType() ContentBlockType         { return ContentBlockTypeDocument }
+func (b *RawBlock) BlockType() ContentBlockType            { return b.Type }
+
+func (*TextBlock) isContentBlock()             {}
+func (*ThinkingBlock) isContentBlock()         {}
+func (*RedactedThinkingBlock) isContentBlock() {}
+func (*ToolUseBlock) isContentBlock()          {}
+func (*ToolResultBlock) isContentBlock()       {}
+func (*ImageBlock) isContentBlock()            {}
+func (*DocumentBlock) isContentBlock()         {}
+func (*RawBlock) isContentBlock()              {}
+
+// InputMap decodes the tool input into a map.
+func (b *ToolUseBlock) InputMap() map[string]any {
+	var input map[string]any
+	_ = json.Unmarshal(b.Input, &input)
+	return input
+}
+
+// DecodeInput decodes the tool input into dst.
+func (b *ToolUseBlock) DecodeInput(dst any) error {
+	return json.Unmarshal(b.Input, dst)
+}
+
+// Blocks returns the result content as content blocks. String content is
+// returned as a single TextBlock.
+func (b *ToolResultBlock) Blocks() []ContentBlock {
+	return contentBlocks(b.Content)
+}
+
+// Text returns the concatenated text of the result.
+func (b *ToolResultBlock) Text() string {
+	if text, ok := b.Content.(string); ok {
+		return text
+	}
+	var text string
+	for _, block := range b.Blocks() {
+		if t, ok := block.(*TextBlock); ok {
+			text += t.Text
+		}
+	}
+	return text
+}
+
+// ParseContentBlock decodes a single content block by its type.
+func ParseContentBlock(data []byte) (ContentBlock, error) {
+	var base struct {
+		Type ContentBlockType `json:"type"`
+	}
+	if err := json.Unmarshal(data, &base); err != nil {
+		return nil, err
+	}
+
+	var block ContentBlock
+	switch base.Type {
+	case ContentBlockTypeText:
+		block = &TextBlock{}
+	case ContentBlockTypeThinking:
+		block = &ThinkingBlock{}
+	case ContentBlockTypeRedactedThinking:
+		block = &RedactedThinkingBlock{}
+	case ContentBlockTypeToolUse:
+		block = &ToolUseBlock{}
+	case ContentBlockTypeToolResult:
+		block = &ToolResultBlock{}
+	case ContentBlockTypeImage:
+		block = &ImageBlock{}
+	case ContentBlockTypeDocument:
+		block = &DocumentBlock{}
+	default:
+		return &RawBlock{Type: base.Type, Raw: append(json.RawMessage(nil), data...)}, nil
+	}
+
+	if err := json.Unmarshal(data, block); err != nil {
+		return nil, err
+	}
+	return block, nil
+}
+
+func (b TextBlock) MarshalJSON() ([]byte, error) {
+	type plain TextBlock
+	return encodeBlock(ContentBlockTypeText, plain(b), b.Extra)
+}
+
+func (b *TextBlock) UnmarshalJSON(data []byte) error {
+	type plain TextBlock
+	var p plain
+	extra, err := decodeBlock(data, &p)
+	if err != nil {
+		return err
+	}
+	*b = TextBlock(p)
+	b.Extra = extra
+	return nil
+}
+
+func (b ThinkingBlock) MarshalJSON() ([]byte, error) {
+	type plain ThinkingBlock
+	return encodeBlock(ContentBlockTypeThinking, plain(b), b.Extra)
+}
+
+func (b *ThinkingBlock) UnmarshalJSON(data []byte) error {
+	type plain ThinkingBlock
+	var p plain
+	extra, err := decodeBlock(data, &p)
+	if err != nil {
+		return err
+	}
+	*b = ThinkingBlock(p)
+	b.Extra = extra
+	return nil
+}
+
+func (b RedactedThinkingBlock) MarshalJSON() ([]byte, error) {
+	type plain RedactedThinkingBlock
+	return encodeBlock(ContentBlockTypeRedactedThinking, plain(b), b.Extra)
+}
+
+func (b *RedactedThinkingBlock) UnmarshalJSON(data []byte) error {
+	type plain RedactedThinkingBlock
+	var p plain
+	extra, err := decodeBlock(data, &p)
+	if err != nil {
+		return err
+	}
+	*b = RedactedThinkingBlock(p)
+	b.Extra = extra
+	return nil
+}
+
+func (b ToolUseBlock) MarshalJSON() ([]byte, error) {
+	type plain ToolUseBlock
+	if b.Input == nil {
+		b.Input = json.RawMessage("{}")
+	}
+	return encodeBlock(ContentBlockTypeToolUse, plain(b), b.Extra)
+}
+
+func (b *ToolUseBlock) UnmarshalJSON(data []byte) error {
+	type plain ToolUseBlock
+	var p plain
+	extra, err := decodeBlock(data, &p)
+	if err != nil {
+		return err
+	}
+	*b = ToolUseBlock(p)
+	b.Extra = extra
+	return nil
+}
+
+func (b ToolResultBlock) MarshalJSON() ([]byte, error) {
+	type plain ToolResultBlock
+	return encodeBlock(ContentBlockTypeToolResult, plain(b), b.Extra)
+}
+
+func (b *ToolResultBlock) UnmarshalJSON(data []byte) error {
+	type plain ToolResultBlock
+	var p plain
+	extra, err := decodeBlock(data, &p)
+	if err != nil {
+		return err
+	}
+
+	// Decode nested content into typed blocks
+	var raw struct {
+		Content json.RawMessage `json:"content"`
+	}
+	if err := json.Unmarshal(data, &raw); err != nil {
+		return err
+	}
+	if p.Content, err = decodeContent(raw.Content); err != nil {
+		return err
+	}
+
+	*b = ToolResultBlock(p)
+	b.Extra = extra
+	return nil
+}
+
+func (b ImageBlock) MarshalJSON() ([]byte, error) {
+	type plain ImageBlock
+	return encodeBlock(ContentBlockTypeImage, plain(b), b.Extra)
+}
+
+func (b *ImageBlock) UnmarshalJSON(data []byte) error {
+	type plain ImageBlock
+	var p plain
+	extra, err := decodeBlock(data, &p)
+	if err != nil {
+		return err
+	}
+	*b = ImageBlock(p)
+	b.Extra = extra
+	return nil
+}
+
+func (b DocumentBlock) MarshalJSON() ([]byte, error) {
+	type plain DocumentBlock
+	return encodeBlock(ContentBlockTypeDocument, plain(b), b.Extra)
+}
+
+func (b *DocumentBlock) UnmarshalJSON(data []byte) error {
+	type plain DocumentBlock
+	var p plain
+	extra, err := decodeBlock(data, &p)
+	if err != nil {
+		return err
+	}
+	*b = DocumentBlock(p)
+	b.Extra = extra
+	return nil
+}
+
+func (b RawBlock) MarshalJSON() ([]byte, error) {
+	if len(b.Raw) > 0 {
+		return b.Raw, nil
+	}
+	return json.Marshal(map[string]any{"type": b.Type})
+}
+
+// decodeBlock decodes data into v and returns the fields v does not model.
+func decodeBlock(data []byte, v any) (map[string]json.RawMessage, error) {
+	if err := json.Unmarshal(data, v); err != nil {
+		return nil, err
+	}
+
+	var fields map[string]json.RawMessage
+	if err := json.Unmarshal(data, &fields); err != nil {
+		return nil, err
+	}
+	known, err := json.Marshal(v)
+	if err != nil {
+		return nil, err
+	}
+	var knownFields map[string]json.RawMessage
+	if err := json.Unmarshal(known, &knownFields); err != nil {
+		return nil, err
+	}
+
+	delete(fields, "type")
+	for key := range knownFields {
+		delete(fields, key)
+	}
+	if len(fields) == 0 {
+		return nil, nil
+	}
+	return fields, nil
+}
+
+// encodeBlock marshals v with its type first, followed by its fields and the
+// extra fields it does not model.
+func encodeBlock(blockType ContentBlockType, v any, extra map[string]json.RawMessage) ([]byte, error) {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return nil, err
+	}
+	var fields map[string]json.RawMessage
+	if err := json.Unmarshal(data, &fields); err != nil {
+		return nil, err
+	}
+	for key, value := range extra {
+		if _, ok := fields[key]; !ok && key != "type" {
+			fields[key] = value
+		}
+	}
+
+	keys := make([]string, 0, len(fields))
+	for key := range fields {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	var buf bytes.Buffer
+	buf.WriteString(`{"type":`)
+	typeJSON, _ := json.Marshal(blockType)
+	buf.Write(typeJSON)
+	for _, key := range keys {
+		keyJSON, _ := json.Marshal(key)
+		buf.WriteByte(',')
+		buf.Write(keyJSON)
+		buf.WriteByte(':')
+		buf.Write(fields[key])
+	}
+	buf.WriteByte('}')
+	return buf.Bytes(), nil
+}
+
+// decodeContent decodes message content: a string, an array of content
+// blocks, or any other JSON value as is.
+func decodeContent(raw json.RawMessage) (any, error) {
+	raw = bytes.TrimSpace(raw)
+	if len(raw) == 0 || bytes.Equal(raw, []byte("null")) {
+		return nil, nil
+	}
+
+	switch raw[0] {
+	case '"':
+		var text string
+		if err := json.Unmarshal(raw, &text); err != nil {
+			return nil, err
+		}
+		return text, nil
+	case '[':
+		var items []json.RawMessage
+		if err := json.Unmarshal(raw, &items); err != nil {
+			return nil, err
+		}
+		blocks := make([]ContentBlock, 0, len(items))
+		for _, item := range items {
+			block, err := ParseContentBlock(item)
+			if err != nil {
+				return nil, err
+			}
+			blocks = append(blocks, block)
+		}
+		return blocks, nil
+	}
+
+	var value any
+	if err := json.Unmarshal(raw, &value); err != nil {
+		return nil, err
+	}
+	return value, nil
+}
+
+// contentBlocks converts message content to content blocks.
+func contentBlocks(content any) []ContentBlock {
+	switch c := content.(type) {
+	case string:
+		if c == "" {
+			return nil
+		}
+		return []ContentBlock{&TextBlock{Text: c}}
+	case []ContentBlock:
+		return c
+	case nil:
+		return nil
+	}
+
+	// Hand-built content such as []any of maps
+	raw, err := json.Marshal(content)
+	if err != nil {
+		return nil
+	}
+	decoded, err := decodeContent(raw)
+	if err != nil {
+		return nil
+	}
+	blocks, _ := decoded.([]ContentBlock)
+	return blocks
+}
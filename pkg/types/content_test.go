@@ -0,0 +1,98 @@
+package types
+
+import (
+	"encoding/json"
+	"testing"
+)
+
+func TestParseContentBlock(t *testing.T) {
+	tests := []struct {
+		name  string
+		raw   string
+		typ   ContentBlockType
+		check func(t *testing.T, block ContentBlock)
+	}{
+		{"text", `{"type":"text","text":"hi"}`, ContentBlockTypeText, func(t *testing.T, block ContentBlock) {
+			if b := block.(*TextBlock); b.Text != "hi" {
+				t.Errorf("Text = %q", b.Text)
+			}
+		}},
+		{"tool use", `{"type":"tool_use","id":"toolu_1","name":"Read","input":{"file_path":"a.go"}}`, ContentBlockTypeToolUse, func(t *testing.T, block ContentBlock) {
+			b := block.(*ToolUseBlock)
+			if b.ID != "toolu_1" || b.Name != "Read" || b.InputMap()["file_path"] != "a.go" {
+				t.Errorf("tool use = %+v", b)
+			}
+		}},
+		{"tool result", `{"type":"tool_result","tool_use_id":"toolu_1","content":[{"type":"text","text":"a"},{"type":"text","text":"b"}],"is_error":true}`, ContentBlockTypeToolResult, func(t *testing.T, block ContentBlock) {
+			b := block.(*ToolResultBlock)
+			if b.ToolUseID != "toolu_1" || !b.IsError || b.Text() != "ab" || len(b.Blocks()) != 2 {
+				t.Errorf("tool result = %+v", b)
+			}
+		}},
+		{"image", `{"type":"image","source":{"type":"base64","media_type":"image/png","data":"AA=="}}`, ContentBlockTypeImage, func(t *testing.T, block ContentBlock) {
+			if b := block.(*ImageBlock); b.Source == nil || b.Source.MediaType != "image/png" {
+				t.Errorf("image = %+v", b)
+			}
+		}},
+		{"unknown", `{"type":"server_tool_use","id":"srvtoolu_1"}`, "server_tool_use", func(t *testing.T, block ContentBlock) {
+			if _, ok := block.(*RawBlock); !ok {
+				t.Errorf("block = %T, want *RawBlock", block)
+			}
+		}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			block, err := ParseContentBlock([]byte(tt.raw))
+			if err != nil {
+				t.Fatal(err)
+			}
+			if block.BlockType() != tt.typ {
+				t.Errorf("BlockType = %s, want %s", block.BlockType(), tt.typ)
+			}
+			tt.check(t, block)
+
+			data, err := json.Marshal(block)
+			if err != nil {
+				t.Fatal(err)
+			}
+			assertSameJSON(t, string(data), tt.raw)
+		})
+	}
+}
+
+func TestContentBlockKeepsUnknownFields(t *testing.T) {
+	raw := `{"type":"text","text":"hi","cache_control":{"type":"ephemeral"}}`
+	block, err := ParseContentBlock([]byte(raw))
+	if err != nil {
+		t.Fatal(err)
+	}
+	data, err := json.Marshal(block)
+	if err != nil {
+		t.Fatal(err)
+	}
+	assertSameJSON(t, string(data), raw)
+}
+
+func TestConstructedBlockMarshalsType(t *testing.T) {
+	data, err := json.Marshal([]ContentBlock{&TextBlock{Text: "hi"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	assertSameJSON(t, string(data), `[{"type":"text","text":"hi"}]`)
+}
+
+func assertSameJSON(t *testing.T, got, want string) {
+	t.Helper()
+	var g, w any
+	if err := json.Unmarshal([]byte(got), &g); err != nil {
+		t.Fatalf("invalid JSON %s: %v", got, err)
+	}
+	if err := json.Unmarshal([]byte(want), &w); err != nil {
+		t.Fatalf("invalid JSON %s: %v", want, err)
+	}
+	gb, _ := json.Marshal(g)
+	wb, _ := json.Marshal(w)
+	if string(gb) != string(wb) {
+		t.Errorf("JSON = %s, want %s", gb, wb)
+	}
+}
@@ -1,7 +1,13 @@
 // Package types provides type definitions for the Chucky SDK.
 package types
 
-import "fmt"
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+)
 
 // ErrorCode represents the type of error that occurred.
 type ErrorCode string
@@ -17,9 +23,47 @@ const (
 	ErrCodeTimeout          ErrorCode = "TIMEOUT_ERROR"
 	ErrCodeValidation       ErrorCode = "VALIDATION_ERROR"
 	ErrCodeProtocol         ErrorCode = "PROTOCOL_ERROR"
+	ErrCodeHook             ErrorCode = "HOOK_ERROR"
+	ErrCodeMaxTurns         ErrorCode = "MAX_TURNS_EXCEEDED"
+	ErrCodeExecution        ErrorCode = "EXECUTION_ERROR"
+	ErrCodeOverloaded       ErrorCode = "OVERLOADED"
+	ErrCodeModelUnavailable ErrorCode = "MODEL_UNAVAILABLE"
+	ErrCodeInternal         ErrorCode = "INTERNAL_ERROR"
 	ErrCodeUnknown          ErrorCode = "UNKNOWN_ERROR"
 )
 
+// Keys of well-known ChuckyError.Details entries.
+const (
+	DetailRetryAfter         = "retryAfter"         // time.Duration
+	DetailRemainingBudget    = "remainingBudgetUsd" // float64
+	DetailCurrentConcurrency = "currentConcurrency" // int
+	DetailConcurrencyLimit   = "concurrencyLimit"   // int
+	DetailRetryable          = "retryable"          // bool, overrides IsRetryable
+)
+
+// Sentinel errors for use with errors.Is. Any ChuckyError matches the
+// sentinel with the same code. WithDetails and Wrap return copies, so
+// ErrOverloaded.Wrap(err) leaves the sentinel unchanged.
+var (
+	ErrConnection       = &ChuckyError{Code: ErrCodeConnection, Message: "connection error"}
+	ErrAuthentication   = &ChuckyError{Code: ErrCodeAuthentication, Message: "authentication error"}
+	ErrBudgetExceeded   = &ChuckyError{Code: ErrCodeBudgetExceeded, Message: "budget exceeded"}
+	ErrConcurrencyLimit = &ChuckyError{Code: ErrCodeConcurrencyLimit, Message: "concurrency limit reached"}
+	ErrRateLimit        = &ChuckyError{Code: ErrCodeRateLimit, Message: "rate limit reached"}
+	ErrSession          = &ChuckyError{Code: ErrCodeSession, Message: "session error"}
+	ErrToolExecution    = &ChuckyError{Code: ErrCodeToolExecution, Message: "tool execution error"}
+	ErrTimeout          = &ChuckyError{Code: ErrCodeTimeout, Message: "timeout"}
+	ErrValidation       = &ChuckyError{Code: ErrCodeValidation, Message: "validation error"}
+	ErrProtocol         = &ChuckyError{Code: ErrCodeProtocol, Message: "protocol error"}
+	ErrHook             = &ChuckyError{Code: ErrCodeHook, Message: "hook error"}
+	ErrMaxTurns         = &ChuckyError{Code: ErrCodeMaxTurns, Message: "max turns exceeded"}
+	ErrExecution        = &ChuckyError{Code: ErrCodeExecution, Message: "execution error"}
+	ErrOverloaded       = &ChuckyError{Code: ErrCodeOverloaded, Message: "model overloaded"}
+	ErrModelUnavailable = &ChuckyError{Code: ErrCodeModelUnavailable, Message: "model unavailable"}
+	ErrInternal         = &ChuckyError{Code: ErrCodeInternal, Message: "internal error"}
+	ErrUnknown          = &ChuckyError{Code: ErrCodeUnknown, Message: "unknown error"}
+)
+
 // ChuckyError is the base error type for all SDK errors.
 type ChuckyError struct {
 	Code    ErrorCode
@@ -39,6 +83,32 @@ func (e *ChuckyError) Unwrap() error {
 	return e.Err
 }
 
+// Is reports whether target is a ChuckyError with the same code.
+func (e *ChuckyError) Is(target error) bool {
+	t, ok := target.(*ChuckyError)
+	return ok && t.Code == e.Code
+}
+
+// RetryAfter returns the server's retry hint, if any.
+func (e *ChuckyError) RetryAfter() (time.Duration, bool) {
+	d, ok := e.Details[DetailRetryAfter].(time.Duration)
+	return d, ok
+}
+
+// RemainingBudget returns the remaining budget in USD, if known.
+func (e *ChuckyError) RemainingBudget() (float64, bool) {
+	v, ok := e.Details[DetailRemainingBudget].(float64)
+	return v, ok
+}
+
+// CurrentConcurrency returns the current and maximum number of concurrent
+// sessions, if known.
+func (e *ChuckyError) CurrentConcurrency() (current, limit int, ok bool) {
+	current, ok = e.Details[DetailCurrentConcurrency].(int)
+	limit, _ = e.Details[DetailConcurrencyLimit].(int)
+	return current, limit, ok
+}
+
 // NewChuckyError creates a new ChuckyError with the given code and message.
 func NewChuckyError(code ErrorCode, message string) *ChuckyError {
 	return &ChuckyError{
@@ -47,16 +117,26 @@ func NewChuckyError(code ErrorCode, message string) *ChuckyError {
 	}
 }
 
-// WithDetails adds details to the error.
+// WithDetails returns a copy of the error with details added. The receiver,
+// which may be a shared sentinel, is not modified.
 func (e *ChuckyError) WithDetails(details map[string]any) *ChuckyError {
-	e.Details = details
-	return e
+	c := *e
+	c.Details = make(map[string]any, len(e.Details)+len(details))
+	for k, v := range e.Details {
+		c.Details[k] = v
+	}
+	for k, v := range details {
+		c.Details[k] = v
+	}
+	return &c
 }
 
-// Wrap wraps another error.
+// Wrap returns a copy of the error wrapping err. The receiver, which may be
+// a shared sentinel, is not modified.
 func (e *ChuckyError) Wrap(err error) *ChuckyError {
-	e.Err = err
-	return e
+	c := *e
+	c.Err = err
+	return &c
 }
 
 // ConnectionError creates a connection error.
@@ -110,3 +190,208 @@ func ValidationError(message string) *ChuckyError {
 func ProtocolError(message string) *ChuckyError {
 	return NewChuckyError(ErrCodeProtocol, message)
 }
+
+// InternalError creates an error for an SDK invariant violation.
+func InternalError(message string) *ChuckyError {
+	return NewChuckyError(ErrCodeInternal, message)
+}
+
+// HookError creates a hook error.
+func HookError(event HookEvent, message string) *ChuckyError {
+	return NewChuckyError(ErrCodeHook, message).WithDetails(map[string]any{
+		"event": string(event),
+	})
+}
+
+// Wire keys of the details of a server error payload.
+const (
+	payloadRetryAfterMs       = "retryAfterMs"       // number, milliseconds
+	payloadRemainingBudgetUsd = "remainingBudgetUsd" // number, USD
+	payloadCurrentConcurrency = "currentConcurrency" // number
+	payloadConcurrencyLimit   = "concurrencyLimit"   // number
+	payloadRetryable          = "retryable"          // bool
+	payloadType               = "type"               // string, Anthropic API error type
+)
+
+// apiErrorCodes maps Anthropic API error types to error codes.
+var apiErrorCodes = map[string]ErrorCode{
+	"overloaded_error":      ErrCodeOverloaded,
+	"rate_limit_error":      ErrCodeRateLimit,
+	"authentication_error":  ErrCodeAuthentication,
+	"permission_error":      ErrCodeAuthentication,
+	"invalid_request_error": ErrCodeValidation,
+}
+
+// ErrorFromPayload maps a server error message to a ChuckyError.
+//
+// The code is the payload's code, one of the ErrorCode values. Without a
+// known code, an Anthropic API error type in details["type"] (e.g.
+// "overloaded_error") selects it. Details are copied and these keys are
+// decoded: retryAfterMs (milliseconds), remainingBudgetUsd (USD),
+// currentConcurrency and concurrencyLimit (counts), and retryable.
+func ErrorFromPayload(p ErrorPayload) *ChuckyError {
+	raw, _ := p.Details.(map[string]any)
+
+	code := ErrorCode(strings.ToUpper(strings.TrimSpace(p.Code)))
+	switch code {
+	case ErrCodeConnection, ErrCodeAuthentication, ErrCodeBudgetExceeded,
+		ErrCodeConcurrencyLimit, ErrCodeRateLimit, ErrCodeSession,
+		ErrCodeToolExecution, ErrCodeTimeout, ErrCodeValidation,
+		ErrCodeProtocol, ErrCodeHook, ErrCodeMaxTurns, ErrCodeExecution,
+		ErrCodeOverloaded, ErrCodeModelUnavailable, ErrCodeInternal:
+	default:
+		apiType, _ := raw[payloadType].(string)
+		if apiCode, ok := apiErrorCodes[apiType]; ok {
+			code = apiCode
+		} else if code == "" {
+			code = ErrCodeSession
+		} else {
+			code = ErrCodeUnknown
+		}
+	}
+
+	message := p.Message
+	if message == "" {
+		message = strings.ToLower(string(code))
+	}
+
+	err := NewChuckyError(code, message)
+	if len(raw) == 0 {
+		return err
+	}
+
+	details := make(map[string]any, len(raw))
+	for k, v := range raw {
+		details[k] = v
+	}
+	if ms, ok := number(raw[payloadRetryAfterMs]); ok {
+		details[DetailRetryAfter] = time.Duration(ms * float64(time.Millisecond))
+	}
+	if usd, ok := number(raw[payloadRemainingBudgetUsd]); ok {
+		details[DetailRemainingBudget] = usd
+	}
+	if n, ok := number(raw[payloadCurrentConcurrency]); ok {
+		details[DetailCurrentConcurrency] = int(n)
+	}
+	if n, ok := number(raw[payloadConcurrencyLimit]); ok {
+		details[DetailConcurrencyLimit] = int(n)
+	}
+	if b, ok := raw[payloadRetryable].(bool); ok {
+		details[DetailRetryable] = b
+	}
+	return err.WithDetails(details)
+}
+
+// ErrorFromResult maps an error result subtype to a ChuckyError. It returns
+// nil for successful results.
+func ErrorFromResult(msg *SDKResultMessage) error {
+	if msg == nil || (msg.Subtype == ResultSubtypeSuccess && !msg.IsError) {
+		return nil
+	}
+
+	var code ErrorCode
+	switch msg.Subtype {
+	case ResultSubtypeErrorMaxTurns:
+		code = ErrCodeMaxTurns
+	case ResultSubtypeErrorBudget:
+		code = ErrCodeBudgetExceeded
+	case ResultSubtypeErrorConcurrency:
+		code = ErrCodeConcurrencyLimit
+	case ResultSubtypeErrorAuthentication:
+		code = ErrCodeAuthentication
+	default:
+		code = ErrCodeExecution
+	}
+
+	message := string(msg.Subtype)
+	if len(msg.Errors) > 0 {
+		message = strings.Join(msg.Errors, "; ")
+	} else if msg.Result != "" {
+		message = msg.Result
+	}
+
+	// Execution errors caused by an API error, e.g. an overloaded model.
+	// msg is shared between subscribers, so it is only read.
+	if code == ErrCodeExecution {
+		code = resultApiErrorCode(msg)
+	}
+
+	return NewChuckyError(code, message).WithDetails(map[string]any{
+		"subtype":   string(msg.Subtype),
+		"sessionId": msg.SessionID,
+		"numTurns":  msg.NumTurns,
+		"costUsd":   msg.TotalCostUsd,
+	})
+}
+
+// resultApiErrorCode returns the code of the first API error embedded in an
+// error result's errors or result text, or ErrCodeExecution.
+func resultApiErrorCode(msg *SDKResultMessage) ErrorCode {
+	for _, text := range msg.Errors {
+		if code, ok := apiErrorCodes[apiErrorType(text)]; ok {
+			return code
+		}
+	}
+	if code, ok := apiErrorCodes[apiErrorType(msg.Result)]; ok {
+		return code
+	}
+	return ErrCodeExecution
+}
+
+// IsRetryable reports whether err is transient: rate limits, concurrency
+// limits, overloads, connection failures and timeouts. A "retryable" detail set by the
+// server takes precedence.
+func IsRetryable(err error) bool {
+	var chuckyErr *ChuckyError
+	if !errors.As(err, &chuckyErr) {
+		return false
+	}
+	if b, ok := chuckyErr.Details[DetailRetryable].(bool); ok {
+		return b
+	}
+	switch chuckyErr.Code {
+	case ErrCodeRateLimit, ErrCodeConcurrencyLimit, ErrCodeConnection, ErrCodeTimeout, ErrCodeOverloaded:
+		return true
+	}
+	return false
+}
+
+// RetryAfter returns the retry hint of the first ChuckyError in err's chain.
+func RetryAfter(err error) (time.Duration, bool) {
+	var chuckyErr *ChuckyError
+	if !errors.As(err, &chuckyErr) {
+		return 0, false
+	}
+	return chuckyErr.RetryAfter()
+}
+
+// apiErrorType returns the type of an Anthropic API error embedded in text,
+// as in `API Error: 529 {"type":"error","error":{"type":"overloaded_error",...}}`.
+func apiErrorType(text string) string {
+	i := strings.IndexByte(text, '{')
+	if i < 0 {
+		return ""
+	}
+	var body struct {
+		Type  string `json:"type"`
+		Error struct {
+			Type string `json:"type"`
+		} `json:"error"`
+	}
+	if err := json.NewDecoder(strings.NewReader(text[i:])).Decode(&body); err != nil || body.Type != "error" {
+		return ""
+	}
+	return body.Error.Type
+}
+
+func number(v any) (float64, bool) {
+	switch v := v.(type) {
+	case float64:
+		return v, true
+	case int:
+		return float64(v), true
+	case int64:
+		return float64(v), true
+	}
+	return 0, false
+}
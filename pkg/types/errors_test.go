@@ -0,0 +1,164 @@
+package types
+
+import (
+	"encoding/json"
+	"errors"
+	"io"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestWithDetailsAndWrapReturnCopies(t *testing.T) {
+	wrapped := ErrOverloaded.Wrap(io.EOF)
+	detailed := ErrOverloaded.WithDetails(map[string]any{"a": 1})
+	if ErrOverloaded.Err != nil || ErrOverloaded.Details != nil {
+		t.Fatalf("sentinel modified: %+v", ErrOverloaded)
+	}
+	if !errors.Is(wrapped, io.EOF) || !errors.Is(wrapped, ErrOverloaded) || detailed.Details["a"] != 1 {
+		t.Errorf("wrapped = %v, detailed = %v", wrapped, detailed.Details)
+	}
+
+	base := SessionError("x").WithDetails(map[string]any{"a": 1})
+	derived := base.WithDetails(map[string]any{"b": 2})
+	if _, ok := base.Details["b"]; ok {
+		t.Error("WithDetails modified the receiver's details")
+	}
+	if derived.Details["a"] != 1 || derived.Details["b"] != 2 {
+		t.Errorf("derived details = %v", derived.Details)
+	}
+
+	caller := map[string]any{"c": 3}
+	withCaller := SessionError("y").WithDetails(caller)
+	withCaller.Details["d"] = 4
+	if _, ok := caller["d"]; ok {
+		t.Error("WithDetails kept the caller's map")
+	}
+}
+
+func TestErrorFromPayload(t *testing.T) {
+	tests := []struct {
+		name        string
+		raw         string // Error envelope as sent by the server
+		code        ErrorCode
+		retryAfter  time.Duration
+		remaining   float64 // -1: absent
+		current     int     // -1: absent
+		limit       int
+		retryable   bool
+		wantMessage string
+	}{
+		{"rate limit",
+			`{"type":"error","payload":{"code":"RATE_LIMIT","message":"Too many requests","details":{"retryAfterMs":1500}}}`,
+			ErrCodeRateLimit, 1500 * time.Millisecond, -1, -1, 0, true, "Too many requests"},
+		{"concurrency",
+			`{"type":"error","payload":{"code":"CONCURRENCY_LIMIT","message":"Concurrency limit reached","details":{"currentConcurrency":5,"concurrencyLimit":5,"retryAfterMs":2000}}}`,
+			ErrCodeConcurrencyLimit, 2 * time.Second, -1, 5, 5, true, "Concurrency limit reached"},
+		{"budget",
+			`{"type":"error","payload":{"code":"BUDGET_EXCEEDED","message":"AI budget exhausted","details":{"remainingBudgetUsd":0.25}}}`,
+			ErrCodeBudgetExceeded, 0, 0.25, -1, 0, false, "AI budget exhausted"},
+		{"lowercase code",
+			`{"type":"error","payload":{"code":"overloaded","message":"Overloaded"}}`,
+			ErrCodeOverloaded, 0, -1, -1, 0, true, "Overloaded"},
+		{"api error type without code",
+			`{"type":"error","payload":{"message":"Overloaded","details":{"type":"overloaded_error"}}}`,
+			ErrCodeOverloaded, 0, -1, -1, 0, true, "Overloaded"},
+		{"server overrides retryable",
+			`{"type":"error","payload":{"code":"CONNECTION_ERROR","message":"Sandbox terminated","details":{"retryable":false}}}`,
+			ErrCodeConnection, 0, -1, -1, 0, false, "Sandbox terminated"},
+		{"no code",
+			`{"type":"error","payload":{"message":"Session not found"}}`,
+			ErrCodeSession, 0, -1, -1, 0, false, "Session not found"},
+		{"unknown code",
+			`{"type":"error","payload":{"code":"QUOTA","message":""}}`,
+			ErrCodeUnknown, 0, -1, -1, 0, false, "unknown_error"},
+		{"guessed keys are not decoded",
+			`{"type":"error","payload":{"code":"RATE_LIMIT","message":"slow down","details":{"retry_after":3,"remainingBudget":5000000}}}`,
+			ErrCodeRateLimit, 0, -1, -1, 0, true, "slow down"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var env ErrorEnvelope
+			if err := json.Unmarshal([]byte(tt.raw), &env); err != nil {
+				t.Fatal(err)
+			}
+			err := ErrorFromPayload(env.Payload)
+			if err.Code != tt.code || err.Message != tt.wantMessage {
+				t.Errorf("error = %v, want [%s] %s", err, tt.code, tt.wantMessage)
+			}
+			if d, ok := err.RetryAfter(); d != tt.retryAfter || ok != (tt.retryAfter > 0) {
+				t.Errorf("RetryAfter = %v, %v", d, ok)
+			}
+			if v, ok := err.RemainingBudget(); ok != (tt.remaining >= 0) || ok && v != tt.remaining {
+				t.Errorf("RemainingBudget = %v, %v", v, ok)
+			}
+			if current, limit, ok := err.CurrentConcurrency(); ok != (tt.current >= 0) || ok && (current != tt.current || limit != tt.limit) {
+				t.Errorf("CurrentConcurrency = %d, %d, %v", current, limit, ok)
+			}
+			if IsRetryable(err) != tt.retryable {
+				t.Errorf("IsRetryable = %v", IsRetryable(err))
+			}
+		})
+	}
+}
+
+func TestErrorFromResult(t *testing.T) {
+	tests := []struct {
+		name string
+		raw  string
+		code ErrorCode // Empty: nil error
+	}{
+		{"success", `{"type":"result","subtype":"success","is_error":false,"result":"done"}`, ""},
+		{"max turns", `{"type":"result","subtype":"error_max_turns","is_error":true,"num_turns":10}`, ErrCodeMaxTurns},
+		{"budget", `{"type":"result","subtype":"error_budget","is_error":true}`, ErrCodeBudgetExceeded},
+		{"overloaded api error",
+			`{"type":"result","subtype":"error_during_execution","is_error":true,"errors":["API Error: 529 {\"type\":\"error\",\"error\":{\"type\":\"overloaded_error\",\"message\":\"Overloaded\"},\"request_id\":\"req_1\"}"]}`,
+			ErrCodeOverloaded},
+		{"api error in result text",
+			`{"type":"result","subtype":"success","is_error":true,"result":"API Error: 429 {\"type\":\"error\",\"error\":{\"type\":\"rate_limit_error\",\"message\":\"Rate limited\"}}"}`,
+			ErrCodeRateLimit},
+		{"overloaded in prose is not an api error",
+			`{"type":"result","subtype":"error_during_execution","is_error":true,"errors":["the build server looks overloaded"]}`,
+			ErrCodeExecution},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var msg SDKResultMessage
+			if err := json.Unmarshal([]byte(tt.raw), &msg); err != nil {
+				t.Fatal(err)
+			}
+			err := ErrorFromResult(&msg)
+			var ce *ChuckyError
+			switch {
+			case tt.code == "" && err != nil:
+				t.Errorf("error = %v, want nil", err)
+			case tt.code != "" && (!errors.As(err, &ce) || ce.Code != tt.code):
+				t.Errorf("error = %v, want code %s", err, tt.code)
+			}
+		})
+	}
+}
+
+func TestErrorFromResultDoesNotWriteToMessage(t *testing.T) {
+	errs := make([]string, 1, 2)
+	errs[0] = "tool failed"
+	errs[:2][1] = "spare"
+	msg := &SDKResultMessage{Subtype: ResultSubtypeErrorDuringExec, IsError: true, Errors: errs, Result: "failed"}
+
+	// Subscribers share the message, so conversions run concurrently
+	var wg sync.WaitGroup
+	for i := 0; i < 8; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if err := ErrorFromResult(msg); !errors.Is(err, ErrExecution) {
+				t.Errorf("error = %v, want an execution error", err)
+			}
+		}()
+	}
+	wg.Wait()
+
+	if spare := errs[:2][1]; spare != "spare" {
+		t.Errorf("spare capacity of Errors overwritten with %q", spare)
+	}
+}
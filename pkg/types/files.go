@@ -0,0 +1,30 @@
+package types
+
+import "time"
+
+// FileInfo describes a file in the session sandbox.
+type FileInfo struct {
+	Path    string     `json:"path"`
+	Size    int64      `json:"size"`
+	Mode    uint32     `json:"mode,omitempty"`    // Unix permission bits
+	ModTime *time.Time `json:"modTime,omitempty"` // Nil if the server did not report it
+	IsDir   bool       `json:"isDir,omitempty"`
+	SHA256  string     `json:"sha256,omitempty"` // Hex digest of the content, set for transfers
+}
+
+// TransferDirection tells uploads and downloads apart in progress reports.
+type TransferDirection string
+
+const (
+	TransferUpload   TransferDirection = "upload"
+	TransferDownload TransferDirection = "download"
+)
+
+// TransferProgress reports the progress of a file transfer.
+type TransferProgress struct {
+	Direction TransferDirection
+	Path      string // Remote path
+	Bytes     int64  // Transferred so far
+	Total     int64  // Total size, or -1 if unknown
+	Chunks    int    // Chunks transferred so far
+}
@@ -0,0 +1,39 @@
+package types
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+)
+
+func TestFileInfoModTime(t *testing.T) {
+	modTime := time.Date(2025, 11, 1, 12, 30, 0, 0, time.UTC)
+	tests := []struct {
+		name string
+		info FileInfo
+		json string
+	}{
+		{"unknown", FileInfo{Path: "/work/a.txt", Size: 3}, `{"path":"/work/a.txt","size":3}`},
+		{"set", FileInfo{Path: "/work/a.txt", Size: 3, ModTime: &modTime}, `{"path":"/work/a.txt","size":3,"modTime":"2025-11-01T12:30:00Z"}`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			data, err := json.Marshal(tt.info)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if string(data) != tt.json {
+				t.Errorf("Marshal = %s, want %s", data, tt.json)
+			}
+
+			var decoded FileInfo
+			if err := json.Unmarshal([]byte(tt.json), &decoded); err != nil {
+				t.Fatal(err)
+			}
+			if (decoded.ModTime == nil) != (tt.info.ModTime == nil) ||
+				decoded.ModTime != nil && !decoded.ModTime.Equal(*tt.info.ModTime) {
+				t.Errorf("ModTime = %v, want %v", decoded.ModTime, tt.info.ModTime)
+			}
+		})
+	}
+}
@@ -0,0 +1,112 @@
+package types
+
+import (
+	"context"
+	"time"
+)
+
+// HookEvent identifies a point in the agent lifecycle where hooks run.
+type HookEvent string
+
+const (
+	HookEventPreToolUse       HookEvent = "PreToolUse"
+	HookEventPostToolUse      HookEvent = "PostToolUse"
+	HookEventUserPromptSubmit HookEvent = "UserPromptSubmit"
+	HookEventStop             HookEvent = "Stop"
+	HookEventPreCompact       HookEvent = "PreCompact"
+)
+
+// HookDecision is the decision returned by a hook.
+type HookDecision string
+
+const (
+	HookDecisionContinue HookDecision = "continue"
+	HookDecisionBlock    HookDecision = "block"
+)
+
+// DefaultHookTimeout is used when a HookMatcher has no timeout.
+const DefaultHookTimeout = 60 * time.Second
+
+// HookInput is the data passed to a hook callback.
+type HookInput struct {
+	Event     HookEvent `json:"hook_event_name"`
+	SessionID string    `json:"session_id,omitempty"`
+
+	// PreToolUse and PostToolUse
+	ToolName     string         `json:"tool_name,omitempty"`
+	ToolInput    map[string]any `json:"tool_input,omitempty"`
+	ToolUseID    string         `json:"tool_use_id,omitempty"`
+	ToolResponse any            `json:"tool_response,omitempty"`
+
+	// UserPromptSubmit
+	Prompt string `json:"prompt,omitempty"`
+
+	// Stop
+	StopHookActive bool `json:"stop_hook_active,omitempty"`
+
+	// PreCompact
+	Trigger            string `json:"trigger,omitempty"` // "manual" or "auto"
+	CustomInstructions string `json:"custom_instructions,omitempty"`
+}
+
+// HookOutput is the structured result of a hook callback.
+type HookOutput struct {
+	Decision HookDecision `json:"decision,omitempty"`
+
+	// Reason explains a block to the agent.
+	Reason string `json:"reason,omitempty"`
+
+	// AdditionalContext is injected into the conversation.
+	AdditionalContext string `json:"additionalContext,omitempty"`
+
+	// UpdatedInput replaces the tool input (PreToolUse only).
+	UpdatedInput map[string]any `json:"updatedInput,omitempty"`
+}
+
+// ContinueHook returns an output that lets the agent continue.
+func ContinueHook() HookOutput {
+	return HookOutput{Decision: HookDecisionContinue}
+}
+
+// BlockHook returns an output that blocks the action with a reason.
+func BlockHook(reason string) HookOutput {
+	return HookOutput{Decision: HookDecisionBlock, Reason: reason}
+}
+
+// ContextHook returns an output that continues and injects additional context.
+func ContextHook(additionalContext string) HookOutput {
+	return HookOutput{Decision: HookDecisionContinue, AdditionalContext: additionalContext}
+}
+
+// HookCallback is the function signature for hooks. ctx is cancelled when
+// the hook times out or the session closes; the callback should return
+// promptly then, as its output is discarded and closing the session waits
+// for it.
+type HookCallback func(ctx context.Context, input HookInput) (HookOutput, error)
+
+// HookMatcher registers callbacks for the tools matching Matcher.
+type HookMatcher struct {
+	// Matcher is a tool name or regular expression. Empty matches everything.
+	// It is ignored for events that are not about a tool.
+	Matcher string
+
+	// Hooks run in order; the first block wins.
+	Hooks []HookCallback
+
+	// Timeout for all hooks of this matcher. Default: DefaultHookTimeout.
+	Timeout time.Duration
+}
+
+// HookCallbackRequest is the control data sent by the server to run a hook.
+type HookCallbackRequest struct {
+	RequestID  string    `json:"requestId"`
+	CallbackID string    `json:"callbackId"`
+	Input      HookInput `json:"input"`
+}
+
+// HookCallbackResponse is the control data sent back to the server.
+type HookCallbackResponse struct {
+	RequestID string      `json:"requestId"`
+	Output    *HookOutput `json:"output,omitempty"`
+	Error     string      `json:"error,omitempty"`
+}
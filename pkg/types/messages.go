@@ -1,6 +1,10 @@
 package types
 
-import "encoding/json"
+import (
+	"encoding/json"
+	"sort"
+	"strings"
+)
 
 // MessageType represents the type of SDK message.
 type MessageType string
@@ -48,6 +52,35 @@ const (
 	ControlActionSessionInfo ControlAction = "session_info"
 	ControlActionEndInput    ControlAction = "end_input"
 	ControlActionClose       ControlAction = "close"
+	ControlActionInterrupt   ControlAction = "interrupt"
+
+	// Permission requests
+	ControlActionCanUseTool         ControlAction = "can_use_tool"
+	ControlActionPermissionResponse ControlAction = "permission_response"
+
+	// Hooks
+	ControlActionHookCallback ControlAction = "hook_callback"
+	ControlActionHookResponse ControlAction = "hook_response"
+
+	// Dynamic tools
+	ControlActionUpdateMcpServer ControlAction = "update_mcp_server"
+
+	// Runtime settings
+	ControlActionSetModel             ControlAction = "set_model"
+	ControlActionSetPermissionMode    ControlAction = "set_permission_mode"
+	ControlActionSetMaxThinkingTokens ControlAction = "set_max_thinking_tokens"
+
+	// Sandbox files
+	ControlActionFileUploadStart   ControlAction = "file_upload_start"
+	ControlActionFileUploadChunk   ControlAction = "file_upload_chunk"
+	ControlActionFileUploadCommit  ControlAction = "file_upload_commit"
+	ControlActionFileUploadAbort   ControlAction = "file_upload_abort"
+	ControlActionFileDownloadStart ControlAction = "file_download_start"
+	ControlActionFileDownloadChunk ControlAction = "file_download_chunk"
+	ControlActionListFiles         ControlAction = "list_files"
+
+	// Acknowledgement of a control request
+	ControlActionResponse ControlAction = "control_response"
 )
 
 // Role represents the role of a message sender.
@@ -59,40 +92,89 @@ const (
 	RoleSystem    Role = "system"
 )
 
-// ContentBlockType represents the type of content block.
-type ContentBlockType string
+// Message represents a message with role and content.
+type Message struct {
+	Role    Role   `json:"role"`
+	Content any    `json:"content"` // string or []ContentBlock
 
-const (
-	ContentBlockTypeText       ContentBlockType = "text"
-	ContentBlockTypeImage      ContentBlockType = "image"
-	ContentBlockTypeToolUse    ContentBlockType = "tool_use"
-	ContentBlockTypeToolResult ContentBlockType = "tool_result"
-)
+	// Set on assistant messages from the API
+	ID         string `json:"id,omitempty"`
+	Model      string `json:"model,omitempty"`
+	StopReason string `json:"stop_reason,omitempty"`
+	Usage      *Usage `json:"usage,omitempty"`
+}
+
+// UnmarshalJSON decodes array content into typed content blocks.
+func (m *Message) UnmarshalJSON(data []byte) error {
+	type plain Message
+	var raw struct {
+		plain
+		Content json.RawMessage `json:"content"`
+	}
+	if err := json.Unmarshal(data, &raw); err != nil {
+		return err
+	}
+
+	content, err := decodeContent(raw.Content)
+	if err != nil {
+		return err
+	}
+	*m = Message(raw.plain)
+	m.Content = content
+	return nil
+}
 
-// ContentBlock represents a content block in a message.
-type ContentBlock struct {
-	Type       ContentBlockType `json:"type"`
-	Text       string           `json:"text,omitempty"`
-	ID         string           `json:"id,omitempty"`
-	Name       string           `json:"name,omitempty"`
-	Input      any              `json:"input,omitempty"`
-	ToolUseID  string           `json:"tool_use_id,omitempty"`
-	Content    any              `json:"content,omitempty"`
-	IsError    bool             `json:"is_error,omitempty"`
-	Source     *ImageSource     `json:"source,omitempty"`
+// Blocks returns the message content as content blocks. String content is
+// returned as a single TextBlock.
+func (m Message) Blocks() []ContentBlock {
+	return contentBlocks(m.Content)
 }
 
-// ImageSource represents the source of an image.
-type ImageSource struct {
-	Type      string `json:"type"`
-	MediaType string `json:"media_type"`
-	Data      string `json:"data"`
+// Text returns the concatenated text of the message.
+func (m Message) Text() string {
+	if text, ok := m.Content.(string); ok {
+		return text
+	}
+	var text string
+	for _, block := range m.Blocks() {
+		if b, ok := block.(*TextBlock); ok {
+			text += b.Text
+		}
+	}
+	return text
 }
 
-// Message represents a message with role and content.
-type Message struct {
-	Role    Role   `json:"role"`
-	Content any    `json:"content"` // string or []ContentBlock
+// Thinking returns the concatenated thinking of the message.
+func (m Message) Thinking() string {
+	var thinking string
+	for _, block := range m.Blocks() {
+		if b, ok := block.(*ThinkingBlock); ok {
+			thinking += b.Thinking
+		}
+	}
+	return thinking
+}
+
+// ToolUses returns the tool_use blocks of the message.
+func (m Message) ToolUses() []*ToolUseBlock {
+	var uses []*ToolUseBlock
+	for _, block := range m.Blocks() {
+		if b, ok := block.(*ToolUseBlock); ok {
+			uses = append(uses, b)
+		}
+	}
+	return uses
+}
+
+// ToolResults returns the tool_result blocks of the message.
+func (m Message) ToolResults() []*ToolResultBlock {
+	var results []*ToolResultBlock
+	for _, block := range m.Blocks() {
+		if b, ok := block.(*ToolResultBlock); ok {
+			results = append(results, b)
+		}
+	}
+	return results
 }
 
 // Usage represents token usage statistics.
@@ -123,6 +205,7 @@ type InitPayload struct {
 	MaxThinkingTokens     int                 `json:"maxThinkingTokens,omitempty"`
 	Tools                 any                 `json:"tools,omitempty"`
 	McpServers            any                 `json:"mcpServers,omitempty"`
+	Agents                any                 `json:"agents,omitempty"`
 	PermissionMode        PermissionMode      `json:"permissionMode,omitempty"`
 	OutputFormat          *OutputFormat       `json:"outputFormat,omitempty"`
 	IncludePartialMessages bool               `json:"includePartialMessages,omitempty"`
@@ -131,6 +214,9 @@ type InitPayload struct {
 	ForkSession           bool                `json:"forkSession,omitempty"`
 	ResumeSessionAt       string              `json:"resumeSessionAt,omitempty"`
 	Continue              bool                `json:"continue,omitempty"`
+	SettingSources        []string            `json:"settingSources,omitempty"`
+	CanUseTool            bool                `json:"canUseTool,omitempty"`
+	Hooks                 any                 `json:"hooks,omitempty"`
 }
 
 // InitEnvelope is the init message sent to start a session.
@@ -148,6 +234,9 @@ type SDKUserMessage struct {
 	SessionID        string      `json:"session_id"`
 	Message          Message     `json:"message"`
 	ParentToolUseID  *string     `json:"parent_tool_use_id"`
+
+	// Set by the server on tool result messages, e.g. subagent totals
+	ToolUseResult any `json:"tool_use_result,omitempty"`
 }
 
 func (SDKUserMessage) GetType() MessageType { return MessageTypeUser }
@@ -158,6 +247,14 @@ type ControlPayload struct {
 	Data   any           `json:"data,omitempty"`
 }
 
+// ControlResponse is the data of a control_response message.
+type ControlResponse struct {
+	RequestID string `json:"requestId"`
+	Success   bool   `json:"success"`
+	Error     string `json:"error,omitempty"`
+	Data      any    `json:"data,omitempty"`
+}
+
 // ControlEnvelope is a control message for session management.
 type ControlEnvelope struct {
 	Type    MessageType    `json:"type"`
@@ -206,23 +303,7 @@ func (SDKAssistantMessage) GetType() MessageType { return MessageTypeAssistant }
 
 // GetTextContent extracts text content from the message.
 func (m SDKAssistantMessage) GetTextContent() string {
-	switch content := m.Message.Content.(type) {
-	case string:
-		return content
-	case []any:
-		var text string
-		for _, block := range content {
-			if blockMap, ok := block.(map[string]any); ok {
-				if blockMap["type"] == "text" {
-					if t, ok := blockMap["text"].(string); ok {
-						text += t
-					}
-				}
-			}
-		}
-		return text
-	}
-	return ""
+	return m.Message.Text()
 }
 
 // SDKResultMessage is the final result of a session.
@@ -245,11 +326,106 @@ func (SDKResultMessage) GetType() MessageType { return MessageTypeResult }
 
 // SystemInitData contains data for system init messages.
 type SystemInitData struct {
-	CWD            string   `json:"cwd,omitempty"`
-	Tools          []string `json:"tools,omitempty"`
-	McpServers     []string `json:"mcp_servers,omitempty"`
-	Model          string   `json:"model,omitempty"`
-	PermissionMode string   `json:"permissionMode,omitempty"`
+	CWD            string            `json:"cwd,omitempty"`
+	Tools          []string          `json:"tools,omitempty"`
+	McpServers     []McpServerStatus `json:"mcp_servers,omitempty"`
+	Model          string            `json:"model,omitempty"`
+	PermissionMode string            `json:"permissionMode,omitempty"`
+	SlashCommands  []string          `json:"slash_commands,omitempty"`
+	Agents         []string          `json:"agents,omitempty"`
+	APIKeySource   string            `json:"apiKeySource,omitempty"`
+	OutputStyle    string            `json:"output_style,omitempty"`
+}
+
+// McpConnectionStatus is the state of an MCP server on the server side.
+type McpConnectionStatus string
+
+const (
+	McpStatusConnected McpConnectionStatus = "connected"
+	McpStatusFailed    McpConnectionStatus = "failed"
+	McpStatusPending   McpConnectionStatus = "pending"
+	McpStatusNeedsAuth McpConnectionStatus = "needs-auth"
+)
+
+// McpServerStatus reports whether an MCP server came up.
+type McpServerStatus struct {
+	Name   string              `json:"name"`
+	Status McpConnectionStatus `json:"status"`
+}
+
+// UnmarshalJSON accepts a bare server name, which is treated as connected.
+func (s *McpServerStatus) UnmarshalJSON(data []byte) error {
+	var name string
+	if err := json.Unmarshal(data, &name); err == nil {
+		*s = McpServerStatus{Name: name, Status: McpStatusConnected}
+		return nil
+	}
+	type plain McpServerStatus
+	return json.Unmarshal(data, (*plain)(s))
+}
+
+// HasTool reports whether the server exposed a tool. MCP tools use their
+// qualified name, e.g. "mcp__server__tool".
+func (d *SystemInitData) HasTool(name string) bool {
+	for _, tool := range d.Tools {
+		if tool == name {
+			return true
+		}
+	}
+	return false
+}
+
+// McpServer returns the status of the named MCP server.
+func (d *SystemInitData) McpServer(name string) (McpServerStatus, bool) {
+	for _, server := range d.McpServers {
+		if server.Name == name {
+			return server, true
+		}
+	}
+	return McpServerStatus{}, false
+}
+
+// Require returns a session error listing the tools that are missing and the
+// MCP servers that are not connected, or nil if all are available.
+func (d *SystemInitData) Require(tools []string, mcpServers []string) error {
+	var missingTools []string
+	for _, tool := range tools {
+		if !d.HasTool(tool) {
+			missingTools = append(missingTools, tool)
+		}
+	}
+
+	unavailable := make(map[string]string)
+	for _, name := range mcpServers {
+		server, ok := d.McpServer(name)
+		switch {
+		case !ok:
+			unavailable[name] = "missing"
+		case server.Status != McpStatusConnected:
+			unavailable[name] = string(server.Status)
+		}
+	}
+
+	if len(missingTools) == 0 && len(unavailable) == 0 {
+		return nil
+	}
+
+	var parts []string
+	if len(missingTools) > 0 {
+		parts = append(parts, "missing tools: "+strings.Join(missingTools, ", "))
+	}
+	if len(unavailable) > 0 {
+		names := make([]string, 0, len(unavailable))
+		for name := range unavailable {
+			names = append(names, name+" ("+unavailable[name]+")")
+		}
+		sort.Strings(names)
+		parts = append(parts, "unavailable MCP servers: "+strings.Join(names, ", "))
+	}
+	return SessionError("required capabilities unavailable: " + strings.Join(parts, "; ")).WithDetails(map[string]any{
+		"missingTools":          missingTools,
+		"unavailableMcpServers": unavailable,
+	})
 }
 
 // SDKSystemMessage is a system message from the server.
@@ -259,10 +435,77 @@ type SDKSystemMessage struct {
 	UUID      string        `json:"uuid"`
 	SessionID string        `json:"session_id"`
 	Data      any           `json:"data,omitempty"` // SystemInitData or compact metadata
+
+	// Set on compact_boundary messages
+	CompactMetadata *CompactMetadata `json:"compact_metadata,omitempty"`
+
+	// Init is the decoded payload of an init message
+	Init *SystemInitData `json:"-"`
 }
 
 func (SDKSystemMessage) GetType() MessageType { return MessageTypeSystem }
 
+// InitData returns the decoded payload of an init message.
+func (m *SDKSystemMessage) InitData() (*SystemInitData, bool) {
+	if m == nil || m.Subtype != SystemSubtypeInit {
+		return nil, false
+	}
+	if m.Init == nil {
+		m.Init = decodeInitData(m.Data, nil)
+	}
+	return m.Init, true
+}
+
+// CompactTrigger tells whether a compaction was requested or automatic.
+type CompactTrigger string
+
+const (
+	CompactTriggerManual CompactTrigger = "manual"
+	CompactTriggerAuto   CompactTrigger = "auto"
+)
+
+// CompactMetadata describes a context compaction.
+type CompactMetadata struct {
+	Trigger   CompactTrigger `json:"trigger"`
+	PreTokens int            `json:"pre_tokens"` // Context size before compaction
+}
+
+// CompactBoundary returns the compaction metadata of a compact_boundary
+// message. It reads compact_metadata, falling back to Data.
+func (m *SDKSystemMessage) CompactBoundary() (*CompactMetadata, bool) {
+	if m == nil || m.Subtype != SystemSubtypeCompactBoundary {
+		return nil, false
+	}
+	if m.CompactMetadata != nil {
+		return m.CompactMetadata, true
+	}
+
+	meta := &CompactMetadata{}
+	raw, err := json.Marshal(m.Data)
+	if err != nil {
+		return meta, true
+	}
+	var data struct {
+		CompactMetadata *CompactMetadata `json:"compact_metadata"`
+		Trigger         CompactTrigger   `json:"trigger"`
+		PreTokens       *int             `json:"pre_tokens"`
+		PreTokensCamel  *int             `json:"preTokens"`
+	}
+	if json.Unmarshal(raw, &data) != nil {
+		return meta, true
+	}
+	if data.CompactMetadata != nil {
+		return data.CompactMetadata, true
+	}
+	meta.Trigger = data.Trigger
+	if data.PreTokens != nil {
+		meta.PreTokens = *data.PreTokens
+	} else if data.PreTokensCamel != nil {
+		meta.PreTokens = *data.PreTokensCamel
+	}
+	return meta, true
+}
+
 // SDKPartialAssistantMessage is a streaming event.
 type SDKPartialAssistantMessage struct {
 	Type            MessageType `json:"type"`
@@ -330,6 +573,8 @@ func ParseIncomingMessage(data []byte) (IncomingMessage, error) {
 	switch base.Type {
 	case MessageTypeAssistant:
 		msg = &SDKAssistantMessage{}
+	case MessageTypeUser:
+		msg = &SDKUserMessage{}
 	case MessageTypeResult:
 		msg = &SDKResultMessage{}
 	case MessageTypeSystem:
@@ -356,9 +601,26 @@ func ParseIncomingMessage(data []byte) (IncomingMessage, error) {
 	if err := json.Unmarshal(data, msg); err != nil {
 		return nil, err
 	}
+
+	if m, ok := msg.(*SDKSystemMessage); ok && m.Subtype == SystemSubtypeInit {
+		m.Init = decodeInitData(m.Data, data)
+	}
 	return msg, nil
 }
 
+// decodeInitData reads init fields from the data object if present, and
+// from the message itself otherwise.
+func decodeInitData(data any, message []byte) *SystemInitData {
+	init := &SystemInitData{}
+	if data != nil {
+		if raw, err := json.Marshal(data); err == nil && json.Unmarshal(raw, init) == nil {
+			return init
+		}
+	}
+	_ = json.Unmarshal(message, init)
+	return init
+}
+
 // GenericMessage holds unknown message types.
 type GenericMessage struct {
 	RawType MessageType
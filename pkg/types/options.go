@@ -19,6 +19,15 @@ const (
 	PermissionModeBypassPermissions PermissionMode = "bypassPermissions"
 )
 
+// OverflowPolicy decides what happens when a client-side message buffer is full.
+type OverflowPolicy string
+
+const (
+	OverflowBlock      OverflowPolicy = "block"       // Wait for the reader
+	OverflowDropOldest OverflowPolicy = "drop_oldest" // Discard the oldest buffered message
+	OverflowError      OverflowPolicy = "error"       // Report an error to OnError
+)
+
 // SystemPromptPreset represents a preset system prompt.
 type SystemPromptPreset struct {
 	Type   string `json:"type"`
@@ -48,6 +57,9 @@ type BaseOptions struct {
 	Tools      any                   `json:"tools,omitempty"` // []string or ToolsPreset
 	McpServers []McpServerDefinition `json:"mcpServers,omitempty"`
 
+	// Subagents by name
+	Agents map[string]AgentDefinition `json:"agents,omitempty"`
+
 	// Other
 	PermissionMode        PermissionMode `json:"permissionMode,omitempty"`
 	OutputFormat          *OutputFormat  `json:"outputFormat,omitempty"`
@@ -67,6 +79,22 @@ type SessionOptions struct {
 
 	// Setting sources
 	SettingSources []string `json:"settingSources,omitempty"`
+
+	// Permissions
+	CanUseTool      CanUseToolFunc   `json:"-"` // Called for each tool use not covered by PermissionRules
+	PermissionRules *PermissionRules `json:"-"` // "Always allow" rules, may be shared across sessions
+
+	// Lifecycle hooks, invoked over the control channel
+	Hooks map[HookEvent][]HookMatcher `json:"-"`
+
+	// Client-side buffering of content messages for Stream and subscribers.
+	// Tool calls and control messages bypass these buffers. Backpressure
+	// applies while a Stream call is active and to the dispatch queue feeding
+	// subscribers; without a Stream reader the oldest unread messages are
+	// dropped. Under OverflowBlock the dispatch queue grows past its size
+	// while consumers catch up, so reading from the connection never stalls.
+	MessageBufferSize int            `json:"-"` // Size of the Stream buffer and the dispatch queue. Default: 100
+	Backpressure      OverflowPolicy `json:"-"` // Applied when a buffer is full. Default: OverflowBlock
 }
 
 // ClientOptions contains options for creating a Chucky client.
@@ -75,12 +103,26 @@ type ClientOptions struct {
 	BaseURL string `json:"baseUrl,omitempty"`
 	Token   string `json:"token"`
 
+	// TokenProvider returns the token for each new connection, e.g. to mint
+	// a fresh short-lived token from a project secret. It takes precedence
+	// over Token. NewClient calls it once to read the user, budget and
+	// sdkConfig defaults from the token.
+	TokenProvider func() (string, error) `json:"-"`
+
 	// Behavior
 	Debug                 bool          `json:"debug,omitempty"`
 	Timeout               time.Duration `json:"timeout,omitempty"`
 	KeepAliveInterval     time.Duration `json:"keepAliveInterval,omitempty"`
 	AutoReconnect         bool          `json:"autoReconnect,omitempty"`
 	MaxReconnectAttempts  int           `json:"maxReconnectAttempts,omitempty"`
+
+	// RetryPolicy applies to connect/init and one-shot prompts. Nil disables retries.
+	RetryPolicy *RetryPolicy `json:"retryPolicy,omitempty"`
+
+	// DefaultSession is merged under the options of every session the client
+	// creates, see SessionOptions.Merge. The token's TokenSdkConfig is merged
+	// under DefaultSession.
+	DefaultSession *SessionOptions `json:"-"`
 }
 
 // DefaultClientOptions returns the default client options.
@@ -102,6 +144,9 @@ func (o ClientOptions) Merge(other ClientOptions) ClientOptions {
 	if other.Token != "" {
 		o.Token = other.Token
 	}
+	if other.TokenProvider != nil {
+		o.TokenProvider = other.TokenProvider
+	}
 	if other.Debug {
 		o.Debug = true
 	}
@@ -117,5 +162,183 @@ func (o ClientOptions) Merge(other ClientOptions) ClientOptions {
 	if other.MaxReconnectAttempts > 0 {
 		o.MaxReconnectAttempts = other.MaxReconnectAttempts
 	}
+	if other.RetryPolicy != nil {
+		o.RetryPolicy = other.RetryPolicy
+	}
+	if other.DefaultSession != nil {
+		o.DefaultSession = other.DefaultSession
+	}
 	return o
 }
+
+// Merge returns o overridden by other:
+//
+//   - Scalars and pointers are replaced when set in other. Booleans can only
+//     be turned on.
+//   - Env maps are merged; other wins on conflicting keys.
+//   - McpServers are unioned by name; a server in other replaces the one with
+//     the same name in o, keeping its position. Agents are merged by name.
+//   - Hooks are combined per event, o's matchers running first.
+//   - A SystemPromptPreset in other whose Preset is empty or equal to o's
+//     appends its Append to o's prompt instead of replacing it, so layers
+//     can stack instructions. Any other SystemPrompt replaces o's.
+//   - Tools and SettingSources are replaced when set in other.
+func (o SessionOptions) Merge(other SessionOptions) SessionOptions {
+	if other.Model != "" {
+		o.Model = other.Model
+	}
+	if other.FallbackModel != "" {
+		o.FallbackModel = other.FallbackModel
+	}
+	o.SystemPrompt = mergeSystemPrompt(o.SystemPrompt, other.SystemPrompt)
+	if other.MaxTurns > 0 {
+		o.MaxTurns = other.MaxTurns
+	}
+	if other.MaxBudgetUsd > 0 {
+		o.MaxBudgetUsd = other.MaxBudgetUsd
+	}
+	if other.MaxThinkingTokens > 0 {
+		o.MaxThinkingTokens = other.MaxThinkingTokens
+	}
+	if other.Tools != nil {
+		o.Tools = other.Tools
+	}
+	o.McpServers = mergeMcpServers(o.McpServers, other.McpServers)
+	if len(other.Agents) > 0 {
+		agents := make(map[string]AgentDefinition, len(o.Agents)+len(other.Agents))
+		for name, agent := range o.Agents {
+			agents[name] = agent
+		}
+		for name, agent := range other.Agents {
+			agents[name] = agent
+		}
+		o.Agents = agents
+	}
+	if other.PermissionMode != "" {
+		o.PermissionMode = other.PermissionMode
+	}
+	if other.OutputFormat != nil {
+		o.OutputFormat = other.OutputFormat
+	}
+	if other.IncludePartialMessages {
+		o.IncludePartialMessages = true
+	}
+	if len(other.Env) > 0 {
+		env := make(map[string]string, len(o.Env)+len(other.Env))
+		for k, v := range o.Env {
+			env[k] = v
+		}
+		for k, v := range other.Env {
+			env[k] = v
+		}
+		o.Env = env
+	}
+
+	if other.SessionID != "" {
+		o.SessionID = other.SessionID
+	}
+	if other.ForkSession {
+		o.ForkSession = true
+	}
+	if other.ResumeSessionAt != "" {
+		o.ResumeSessionAt = other.ResumeSessionAt
+	}
+	if other.Continue {
+		o.Continue = true
+	}
+	if len(other.SettingSources) > 0 {
+		o.SettingSources = other.SettingSources
+	}
+
+	if other.CanUseTool != nil {
+		o.CanUseTool = other.CanUseTool
+	}
+	if other.PermissionRules != nil {
+		o.PermissionRules = other.PermissionRules
+	}
+	if len(other.Hooks) > 0 {
+		hooks := make(map[HookEvent][]HookMatcher, len(o.Hooks)+len(other.Hooks))
+		for event, matchers := range o.Hooks {
+			hooks[event] = append([]HookMatcher(nil), matchers...)
+		}
+		for event, matchers := range other.Hooks {
+			hooks[event] = append(hooks[event], matchers...)
+		}
+		o.Hooks = hooks
+	}
+
+	if other.MessageBufferSize > 0 {
+		o.MessageBufferSize = other.MessageBufferSize
+	}
+	if other.Backpressure != "" {
+		o.Backpressure = other.Backpressure
+	}
+	return o
+}
+
+func mergeSystemPrompt(base, other any) any {
+	if other == nil {
+		return base
+	}
+	preset, ok := other.(SystemPromptPreset)
+	if p, isPtr := other.(*SystemPromptPreset); isPtr && p != nil {
+		preset, ok = *p, true
+	}
+	if !ok {
+		return other
+	}
+
+	switch b := base.(type) {
+	case SystemPromptPreset:
+		if preset.Preset == "" || preset.Preset == b.Preset {
+			b.Append = joinPrompt(b.Append, preset.Append)
+			return b
+		}
+	case *SystemPromptPreset:
+		if b != nil && (preset.Preset == "" || preset.Preset == b.Preset) {
+			merged := *b
+			merged.Append = joinPrompt(merged.Append, preset.Append)
+			return merged
+		}
+	case string:
+		if preset.Preset == "" {
+			return joinPrompt(b, preset.Append)
+		}
+	}
+	return other
+}
+
+func joinPrompt(a, b string) string {
+	if a == "" {
+		return b
+	}
+	if b == "" {
+		return a
+	}
+	return a + "\n\n" + b
+}
+
+func mergeMcpServers(base, other []McpServerDefinition) []McpServerDefinition {
+	if len(other) == 0 {
+		return base
+	}
+	merged := append([]McpServerDefinition(nil), base...)
+	index := make(map[string]int, len(merged))
+	for i, server := range merged {
+		if server != nil {
+			index[server.GetName()] = i
+		}
+	}
+	for _, server := range other {
+		if server == nil {
+			continue
+		}
+		if i, ok := index[server.GetName()]; ok {
+			merged[i] = server
+			continue
+		}
+		index[server.GetName()] = len(merged)
+		merged = append(merged, server)
+	}
+	return merged
+}
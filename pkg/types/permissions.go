@@ -0,0 +1,136 @@
+package types
+
+import (
+	"context"
+	"sort"
+	"strings"
+	"sync"
+)
+
+// PermissionBehavior is the outcome of a permission check.
+type PermissionBehavior string
+
+const (
+	PermissionBehaviorAllow PermissionBehavior = "allow"
+	PermissionBehaviorDeny  PermissionBehavior = "deny"
+)
+
+// PermissionDecision is returned by a CanUseToolFunc.
+type PermissionDecision struct {
+	Behavior PermissionBehavior `json:"behavior"`
+
+	// Message explains a denial to the agent.
+	Message string `json:"message,omitempty"`
+
+	// UpdatedInput replaces the tool input when allowing.
+	UpdatedInput map[string]any `json:"updatedInput,omitempty"`
+
+	// Interrupt stops the current turn after a denial.
+	Interrupt bool `json:"interrupt,omitempty"`
+
+	// AlwaysAllow adds the tool to the session's permission rules so
+	// later uses are allowed without calling the callback again.
+	AlwaysAllow bool `json:"-"`
+}
+
+// Allow returns a decision that allows the tool use.
+func Allow() PermissionDecision {
+	return PermissionDecision{Behavior: PermissionBehaviorAllow}
+}
+
+// AllowWithInput returns a decision that allows the tool use with modified input.
+func AllowWithInput(input map[string]any) PermissionDecision {
+	return PermissionDecision{Behavior: PermissionBehaviorAllow, UpdatedInput: input}
+}
+
+// AlwaysAllow returns a decision that allows the tool use and remembers it.
+func AlwaysAllow() PermissionDecision {
+	return PermissionDecision{Behavior: PermissionBehaviorAllow, AlwaysAllow: true}
+}
+
+// Deny returns a decision that denies the tool use with the given message.
+func Deny(message string) PermissionDecision {
+	return PermissionDecision{Behavior: PermissionBehaviorDeny, Message: message}
+}
+
+// CanUseToolFunc decides whether the agent may use a tool.
+type CanUseToolFunc func(ctx context.Context, toolName string, input map[string]any) (PermissionDecision, error)
+
+// CanUseToolRequest is the control data sent by the server to ask for permission.
+type CanUseToolRequest struct {
+	RequestID string         `json:"requestId"`
+	ToolName  string         `json:"toolName"`
+	ToolUseID string         `json:"toolUseId,omitempty"`
+	Input     map[string]any `json:"input"`
+}
+
+// PermissionResponse is the control data sent back to the server.
+type PermissionResponse struct {
+	RequestID string `json:"requestId"`
+	PermissionDecision
+}
+
+// PermissionRules is a set of "always allow" rules that can be shared
+// between sessions. A rule is either an exact tool name or a prefix
+// ending in "*" (e.g. "mcp__github__*"). It is safe for concurrent use.
+type PermissionRules struct {
+	mu    sync.RWMutex
+	rules map[string]struct{}
+}
+
+// NewPermissionRules creates a rule set allowing the given tools.
+func NewPermissionRules(rules ...string) *PermissionRules {
+	r := &PermissionRules{rules: make(map[string]struct{})}
+	r.Allow(rules...)
+	return r
+}
+
+// Allow adds rules to the set.
+func (r *PermissionRules) Allow(rules ...string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.rules == nil {
+		r.rules = make(map[string]struct{})
+	}
+	for _, rule := range rules {
+		if rule != "" {
+			r.rules[rule] = struct{}{}
+		}
+	}
+}
+
+// Revoke removes rules from the set.
+func (r *PermissionRules) Revoke(rules ...string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for _, rule := range rules {
+		delete(r.rules, rule)
+	}
+}
+
+// IsAllowed reports whether a rule matches the tool name.
+func (r *PermissionRules) IsAllowed(toolName string) bool {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	if _, ok := r.rules[toolName]; ok {
+		return true
+	}
+	for rule := range r.rules {
+		if strings.HasSuffix(rule, "*") && strings.HasPrefix(toolName, strings.TrimSuffix(rule, "*")) {
+			return true
+		}
+	}
+	return false
+}
+
+// List returns the rules in sorted order.
+func (r *PermissionRules) List() []string {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	list := make([]string, 0, len(r.rules))
+	for rule := range r.rules {
+		list = append(list, rule)
+	}
+	sort.Strings(list)
+	return list
+}
@@ -0,0 +1,100 @@
+package types
+
+import (
+	"errors"
+	"math"
+	"math/rand"
+	"time"
+)
+
+// RetryPolicy configures retries of connection setup and one-shot prompts.
+type RetryPolicy struct {
+	// MaxAttempts is the total number of attempts, including the first one.
+	MaxAttempts int `json:"maxAttempts,omitempty"`
+
+	// InitialBackoff is the delay before the first retry.
+	InitialBackoff time.Duration `json:"initialBackoff,omitempty"`
+
+	// MaxBackoff caps the delay between retries.
+	MaxBackoff time.Duration `json:"maxBackoff,omitempty"`
+
+	// Multiplier is applied to the backoff after every retry.
+	Multiplier float64 `json:"multiplier,omitempty"`
+
+	// Jitter randomizes each delay by up to this fraction (0-1).
+	Jitter float64 `json:"jitter,omitempty"`
+
+	// IgnoreRetryAfter disables waiting for the server's retry-after hint.
+	IgnoreRetryAfter bool `json:"ignoreRetryAfter,omitempty"`
+
+	// DisableModelFallback disables retrying with SessionOptions.FallbackModel
+	// when the primary model is overloaded or unavailable.
+	DisableModelFallback bool `json:"disableModelFallback,omitempty"`
+
+	// RetryOn reports whether an error should be retried. Default: IsRetryable.
+	RetryOn func(err error) bool `json:"-"`
+}
+
+// DefaultRetryPolicy returns a policy with 3 attempts and exponential backoff.
+func DefaultRetryPolicy() *RetryPolicy {
+	return &RetryPolicy{
+		MaxAttempts:    3,
+		InitialBackoff: 500 * time.Millisecond,
+		MaxBackoff:     30 * time.Second,
+		Multiplier:     2,
+		Jitter:         0.2,
+	}
+}
+
+// ShouldRetry reports whether another attempt should follow the given failed
+// attempt (1-based). A nil policy never retries.
+func (p *RetryPolicy) ShouldRetry(attempt int, err error) bool {
+	if p == nil || err == nil || attempt >= p.MaxAttempts {
+		return false
+	}
+	if p.RetryOn != nil {
+		return p.RetryOn(err)
+	}
+	return IsRetryable(err)
+}
+
+// Delay returns how long to wait after the given failed attempt (1-based).
+// The server's retry-after hint is used as a lower bound unless ignored.
+func (p *RetryPolicy) Delay(attempt int, err error) time.Duration {
+	if p == nil {
+		return 0
+	}
+
+	multiplier := p.Multiplier
+	if multiplier < 1 {
+		multiplier = 2
+	}
+	delay := float64(p.InitialBackoff) * math.Pow(multiplier, float64(attempt-1))
+	if p.MaxBackoff > 0 && delay > float64(p.MaxBackoff) {
+		delay = float64(p.MaxBackoff)
+	}
+	if p.Jitter > 0 {
+		delay += delay * p.Jitter * (2*rand.Float64() - 1)
+	}
+
+	d := time.Duration(delay)
+	if !p.IgnoreRetryAfter {
+		if retryAfter, ok := RetryAfter(err); ok && retryAfter > d {
+			d = retryAfter
+		}
+	}
+	return d
+}
+
+// ShouldFallback reports whether a prompt that failed with err should be
+// retried with the fallback model.
+func (p *RetryPolicy) ShouldFallback(err error) bool {
+	if p == nil || p.DisableModelFallback {
+		return false
+	}
+	var chuckyErr *ChuckyError
+	if !errors.As(err, &chuckyErr) {
+		return false
+	}
+	return chuckyErr.Code == ErrCodeOverloaded || chuckyErr.Code == ErrCodeModelUnavailable
+}
@@ -81,3 +81,51 @@ func MicroDollars(dollars float64) int64 {
 func ComputeSeconds(hours float64) int64 {
 	return int64(hours * 3600)
 }
+
+// Bounds returns the start and end of the window containing now. Windows are
+// aligned to anchor (typically TokenBudget.WindowStart); a zero anchor aligns
+// them to calendar boundaries in UTC.
+func (w BudgetWindow) Bounds(anchor, now time.Time) (time.Time, time.Time) {
+	if anchor.IsZero() {
+		now = now.UTC()
+		switch w {
+		case BudgetWindowHour:
+			anchor = now.Truncate(time.Hour)
+		case BudgetWindowWeek:
+			day := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, time.UTC)
+			anchor = day.AddDate(0, 0, -((int(day.Weekday()) + 6) % 7))
+		case BudgetWindowMonth:
+			anchor = time.Date(now.Year(), now.Month(), 1, 0, 0, 0, 0, time.UTC)
+		default:
+			anchor = time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, time.UTC)
+		}
+	}
+
+	if w == BudgetWindowMonth {
+		start := anchor
+		for start.After(now) {
+			start = start.AddDate(0, -1, 0)
+		}
+		for !start.AddDate(0, 1, 0).After(now) {
+			start = start.AddDate(0, 1, 0)
+		}
+		return start, start.AddDate(0, 1, 0)
+	}
+
+	var length time.Duration
+	switch w {
+	case BudgetWindowHour:
+		length = time.Hour
+	case BudgetWindowWeek:
+		length = 7 * 24 * time.Hour
+	default:
+		length = 24 * time.Hour
+	}
+
+	n := now.Sub(anchor) / length
+	if now.Before(anchor) && now.Sub(anchor)%length != 0 {
+		n--
+	}
+	start := anchor.Add(n * length)
+	return start, start.Add(length)
+}
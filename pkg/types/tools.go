@@ -1,6 +1,9 @@
 package types
 
-import "context"
+import (
+	"context"
+	"log/slog"
+)
 
 // ExecuteLocation specifies where a tool executes.
 type ExecuteLocation string
@@ -76,6 +79,40 @@ type ToolResult struct {
 // ToolHandler is the function signature for tool handlers.
 type ToolHandler func(ctx context.Context, input map[string]any) (*ToolResult, error)
 
+// ToolMiddleware wraps a ToolHandler with additional behavior.
+type ToolMiddleware func(next ToolHandler) ToolHandler
+
+// ChainToolMiddleware wraps handler with the given middleware.
+// The first middleware is the outermost one.
+func ChainToolMiddleware(handler ToolHandler, middleware ...ToolMiddleware) ToolHandler {
+	for i := len(middleware) - 1; i >= 0; i-- {
+		if middleware[i] != nil {
+			handler = middleware[i](handler)
+		}
+	}
+	return handler
+}
+
+// ToolCallInfo describes the tool call a handler is serving.
+type ToolCallInfo struct {
+	CallID    string
+	ToolName  string
+	SessionID string
+}
+
+type toolCallInfoKey struct{}
+
+// WithToolCallInfo returns a context carrying the given tool call info.
+func WithToolCallInfo(ctx context.Context, info ToolCallInfo) context.Context {
+	return context.WithValue(ctx, toolCallInfoKey{}, info)
+}
+
+// ToolCallInfoFromContext returns the tool call info stored in ctx, if any.
+func ToolCallInfoFromContext(ctx context.Context) (ToolCallInfo, bool) {
+	info, ok := ctx.Value(toolCallInfoKey{}).(ToolCallInfo)
+	return info, ok
+}
+
 // ToolDefinition defines a tool that can be used by Claude.
 type ToolDefinition struct {
 	Name        string          `json:"name"`
@@ -83,6 +120,7 @@ type ToolDefinition struct {
 	InputSchema ToolInputSchema `json:"inputSchema"`
 	ExecuteIn   ExecuteLocation `json:"executeIn,omitempty"`
 	Handler     ToolHandler     `json:"-"` // Not serialized
+	Middleware  []ToolMiddleware `json:"-"` // Applied to Handler only
 }
 
 // McpServerType represents the type of MCP server.
@@ -102,9 +140,10 @@ type McpServerDefinition interface {
 
 // McpClientToolsServer represents an MCP server with client-side tools.
 type McpClientToolsServer struct {
-	Name    string           `json:"name"`
-	Version string           `json:"version,omitempty"`
-	Tools   []ToolDefinition `json:"tools"`
+	Name       string           `json:"name"`
+	Version    string           `json:"version,omitempty"`
+	Tools      []ToolDefinition `json:"tools"`
+	Middleware []ToolMiddleware `json:"-"` // Applied to every tool handler
 }
 
 func (McpClientToolsServer) mcpServer() {}
@@ -117,6 +156,14 @@ type McpStdioServerConfig struct {
 	Command string            `json:"command"`
 	Args    []string          `json:"args,omitempty"`
 	Env     map[string]string `json:"env,omitempty"`
+
+	// Local runs the server as a child process of the client instead of in
+	// the cloud sandbox. Its tools are listed when the session connects and
+	// exposed to the agent as client tools; calls are proxied to the process,
+	// which is restarted if it exits and then asked for its tools again.
+	Local  bool         `json:"-"`
+	Dir    string       `json:"-"` // Working directory of a local server
+	Logger *slog.Logger `json:"-"` // Receives the stderr of a local server. Default: slog.Default()
 }
 
 func (McpStdioServerConfig) mcpServer() {}
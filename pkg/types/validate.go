@@ -0,0 +1,349 @@
+package types
+
+import (
+	"fmt"
+	"math"
+	"sort"
+	"strings"
+)
+
+// DetailFieldErrors is the ChuckyError.Details key holding the []FieldError
+// of a validation error.
+const DetailFieldErrors = "fieldErrors"
+
+// FieldError describes one invalid option.
+type FieldError struct {
+	Field   string `json:"field"` // Go field path, e.g. "McpServers[1].Name"
+	Message string `json:"message"`
+}
+
+func (e FieldError) String() string {
+	return e.Field + ": " + e.Message
+}
+
+// FieldErrors returns the invalid options of a validation error, if any.
+func (e *ChuckyError) FieldErrors() []FieldError {
+	errs, _ := e.Details[DetailFieldErrors].([]FieldError)
+	return errs
+}
+
+// fieldErrors collects the problems found while validating options.
+type fieldErrors []FieldError
+
+func (errs *fieldErrors) add(field, format string, args ...any) {
+	*errs = append(*errs, FieldError{Field: field, Message: fmt.Sprintf(format, args...)})
+}
+
+// err returns a ValidationError listing every problem, or nil.
+func (errs fieldErrors) err(what string) error {
+	if len(errs) == 0 {
+		return nil
+	}
+	messages := make([]string, len(errs))
+	for i, e := range errs {
+		messages[i] = e.String()
+	}
+	return ValidationError(fmt.Sprintf("invalid %s: %s", what, strings.Join(messages, "; "))).WithDetails(map[string]any{
+		DetailFieldErrors: []FieldError(errs),
+	})
+}
+
+// KnownModels lists the models the SDK has constants for.
+var KnownModels = []Model{ModelClaudeSonnet, ModelClaudeOpus}
+
+// modelAliases are accepted by the server in place of a full model ID.
+var modelAliases = map[Model]bool{"sonnet": true, "opus": true, "haiku": true}
+
+// IsValid reports whether m names a Claude model: one of KnownModels, a
+// model alias (sonnet, opus, haiku) or a full "claude-" model ID.
+func (m Model) IsValid() bool {
+	if modelAliases[m] || strings.HasPrefix(string(m), "claude-") {
+		return true
+	}
+	for _, known := range KnownModels {
+		if m == known {
+			return true
+		}
+	}
+	return false
+}
+
+// IsValid reports whether p is a known permission mode.
+func (p PermissionMode) IsValid() bool {
+	switch p {
+	case PermissionModeDefault, PermissionModePlan, PermissionModeBypassPermissions:
+		return true
+	}
+	return false
+}
+
+// IsValid reports whether p is a known overflow policy.
+func (p OverflowPolicy) IsValid() bool {
+	switch p {
+	case OverflowBlock, OverflowDropOldest, OverflowError:
+		return true
+	}
+	return false
+}
+
+// IsValid reports whether l is a known tool location.
+func (l ExecuteLocation) IsValid() bool {
+	switch l {
+	case ExecuteInServer, ExecuteInBrowser:
+		return true
+	}
+	return false
+}
+
+// Setting sources the server can load settings from.
+const (
+	SettingSourceUser    = "user"
+	SettingSourceProject = "project"
+	SettingSourceLocal   = "local"
+)
+
+// Validate checks the options before they are sent to the server. All
+// problems are reported together in a ValidationError; use FieldErrors to
+// inspect them. Zero values are valid and mean "server default".
+func (o *SessionOptions) Validate() error {
+	var errs fieldErrors
+	o.BaseOptions.validate(&errs)
+
+	for i, source := range o.SettingSources {
+		switch source {
+		case SettingSourceUser, SettingSourceProject, SettingSourceLocal:
+		default:
+			errs.add(fmt.Sprintf("SettingSources[%d]", i), "unknown setting source %q", source)
+		}
+	}
+
+	if o.ForkSession && o.SessionID == "" {
+		errs.add("ForkSession", "requires SessionID")
+	}
+	if o.ResumeSessionAt != "" && o.SessionID == "" && !o.Continue {
+		errs.add("ResumeSessionAt", "requires SessionID or Continue")
+	}
+
+	events := make([]string, 0, len(o.Hooks))
+	for event := range o.Hooks {
+		events = append(events, string(event))
+	}
+	sort.Strings(events)
+	for _, event := range events {
+		for i, matcher := range o.Hooks[HookEvent(event)] {
+			field := fmt.Sprintf("Hooks[%s][%d]", event, i)
+			if len(matcher.Hooks) == 0 {
+				errs.add(field+".Hooks", "must not be empty")
+			}
+			for j, hook := range matcher.Hooks {
+				if hook == nil {
+					errs.add(fmt.Sprintf("%s.Hooks[%d]", field, j), "must not be nil")
+				}
+			}
+			if matcher.Timeout < 0 {
+				errs.add(field+".Timeout", "must not be negative")
+			}
+		}
+	}
+
+	if o.MessageBufferSize < 0 {
+		errs.add("MessageBufferSize", "must not be negative")
+	}
+	if o.Backpressure != "" && !o.Backpressure.IsValid() {
+		errs.add("Backpressure", "unknown overflow policy %q", o.Backpressure)
+	}
+
+	return errs.err("session options")
+}
+
+func (o *BaseOptions) validate(errs *fieldErrors) {
+	if o.Model != "" && !o.Model.IsValid() {
+		errs.add("Model", "unknown model %q", o.Model)
+	}
+	if o.FallbackModel != "" && !Model(o.FallbackModel).IsValid() {
+		errs.add("FallbackModel", "unknown model %q", o.FallbackModel)
+	}
+
+	switch p := o.SystemPrompt.(type) {
+	case nil, string:
+	case SystemPromptPreset:
+		validatePreset(errs, p)
+	case *SystemPromptPreset:
+		if p != nil {
+			validatePreset(errs, *p)
+		}
+	default:
+		errs.add("SystemPrompt", "must be a string or SystemPromptPreset, got %T", p)
+	}
+
+	if o.MaxTurns < 0 {
+		errs.add("MaxTurns", "must not be negative")
+	}
+	if o.MaxBudgetUsd < 0 || math.IsNaN(o.MaxBudgetUsd) || math.IsInf(o.MaxBudgetUsd, 0) {
+		errs.add("MaxBudgetUsd", "must be a non-negative amount")
+	}
+	if o.MaxThinkingTokens < 0 {
+		errs.add("MaxThinkingTokens", "must not be negative")
+	}
+
+	if tools, ok := o.Tools.([]string); ok {
+		for i, name := range tools {
+			if name == "" {
+				errs.add(fmt.Sprintf("Tools[%d]", i), "must not be empty")
+			}
+		}
+	}
+
+	validateMcpServers(errs, "McpServers", o.McpServers)
+
+	for _, name := range sortedAgentNames(o.Agents) {
+		agent := o.Agents[name]
+		field := fmt.Sprintf("Agents[%s]", name)
+		if name == "" {
+			errs.add("Agents", "agent name must not be empty")
+		}
+		if agent.Description == "" {
+			errs.add(field+".Description", "must not be empty")
+		}
+		if agent.Prompt == "" {
+			errs.add(field+".Prompt", "must not be empty")
+		}
+		if agent.Model != "" && agent.Model != AgentModelInherit && !Model(agent.Model).IsValid() {
+			errs.add(field+".Model", "unknown model %q", agent.Model)
+		}
+		for i, tool := range agent.Tools {
+			if tool == "" {
+				errs.add(fmt.Sprintf("%s.Tools[%d]", field, i), "must not be empty")
+			}
+		}
+		validateMcpServers(errs, field+".McpServers", agent.McpServers)
+	}
+	validateMcpServerNames(errs, o)
+
+	if o.PermissionMode != "" && !o.PermissionMode.IsValid() {
+		errs.add("PermissionMode", "unknown permission mode %q", o.PermissionMode)
+	}
+	if o.OutputFormat != nil {
+		if o.OutputFormat.Type == "" {
+			errs.add("OutputFormat.Type", "must not be empty")
+		}
+		if o.OutputFormat.Schema == nil {
+			errs.add("OutputFormat.Schema", "must not be nil")
+		}
+	}
+	for key := range o.Env {
+		if key == "" || strings.ContainsAny(key, "=\x00") {
+			errs.add("Env", "invalid variable name %q", key)
+		}
+	}
+}
+
+func validatePreset(errs *fieldErrors, p SystemPromptPreset) {
+	if p.Type != "preset" {
+		errs.add("SystemPrompt.Type", `must be "preset", got %q`, p.Type)
+	}
+	if p.Preset == "" {
+		errs.add("SystemPrompt.Preset", "must not be empty")
+	}
+}
+
+// validateMcpServerNames reports servers of different lists (the session's
+// and each agent's) that share a name, and client tools of different lists
+// that share a name. All of them are registered with the session, servers by
+// name and tool handlers by bare tool name, so one would silently replace
+// the other.
+func validateMcpServerNames(errs *fieldErrors, o *BaseOptions) {
+	first := make(map[string]string)     // Server name -> field of its first definition
+	firstTool := make(map[string]string) // Client tool name -> field of its first definition
+	check := func(prefix string, servers []McpServerDefinition) {
+		local := make(map[string]bool)
+		localTools := make(map[string]string)
+		for i, server := range servers {
+			if server == nil || server.GetName() == "" || local[server.GetName()] {
+				continue // Reported by validateMcpServers
+			}
+			name := server.GetName()
+			local[name] = true
+			field := fmt.Sprintf("%s[%d]", prefix, i)
+
+			if srv, ok := server.(McpClientToolsServer); ok {
+				for j, tool := range srv.Tools {
+					if tool.Name == "" {
+						continue // Reported by validateMcpServers
+					}
+					toolField := fmt.Sprintf("%s.Tools[%d]", field, j)
+					if other, ok := firstTool[tool.Name]; ok {
+						errs.add(toolField+".Name", "tool name %q is also defined in %s", tool.Name, other)
+					} else if _, ok := localTools[tool.Name]; !ok {
+						localTools[tool.Name] = toolField
+					}
+				}
+			}
+
+			if other, ok := first[name]; ok {
+				errs.add(field+".Name", "server name %q is also defined in %s", name, other)
+				continue
+			}
+			first[name] = field
+		}
+		for tool, field := range localTools {
+			firstTool[tool] = field
+		}
+	}
+	check("McpServers", o.McpServers)
+	for _, name := range sortedAgentNames(o.Agents) {
+		check(fmt.Sprintf("Agents[%s].McpServers", name), o.Agents[name].McpServers)
+	}
+}
+
+func validateMcpServers(errs *fieldErrors, prefix string, servers []McpServerDefinition) {
+	names := make(map[string]int)
+	tools := make(map[string]string) // Client tool name -> server
+	for i, server := range servers {
+		field := fmt.Sprintf("%s[%d]", prefix, i)
+		if server == nil {
+			errs.add(field, "must not be nil")
+			continue
+		}
+
+		name := server.GetName()
+		if name == "" {
+			errs.add(field+".Name", "must not be empty")
+		} else if first, ok := names[name]; ok {
+			errs.add(field+".Name", "duplicate server name %q (also %s[%d])", name, prefix, first)
+		} else {
+			names[name] = i
+		}
+
+		switch srv := server.(type) {
+		case McpClientToolsServer:
+			for j, tool := range srv.Tools {
+				toolField := fmt.Sprintf("%s.Tools[%d]", field, j)
+				if tool.Name == "" {
+					errs.add(toolField+".Name", "must not be empty")
+				} else if other, ok := tools[tool.Name]; ok {
+					errs.add(toolField+".Name", "duplicate tool name %q (also in server %q)", tool.Name, other)
+				} else {
+					tools[tool.Name] = name
+				}
+				if tool.ExecuteIn != "" && !tool.ExecuteIn.IsValid() {
+					errs.add(toolField+".ExecuteIn", "unknown location %q", tool.ExecuteIn)
+				}
+			}
+		case McpStdioServerConfig:
+			if srv.Command == "" {
+				errs.add(field+".Command", "must not be empty")
+			}
+		case McpSSEServerConfig:
+			if srv.URL == "" {
+				errs.add(field+".URL", "must not be empty")
+			}
+		case McpHTTPServerConfig:
+			if srv.URL == "" {
+				errs.add(field+".URL", "must not be empty")
+			}
+		default:
+			errs.add(field, "unsupported server type %T", server)
+		}
+	}
+}
@@ -0,0 +1,107 @@
+package types
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+)
+
+func TestValidateMcpServerNames(t *testing.T) {
+	stdio := func(name string) McpServerDefinition {
+		return McpStdioServerConfig{Name: name, Command: "mcp-" + name}
+	}
+	agent := func(servers ...McpServerDefinition) AgentDefinition {
+		return AgentDefinition{Description: "d", Prompt: "p", McpServers: servers}
+	}
+
+	tests := []struct {
+		name    string
+		servers []McpServerDefinition
+		agents  map[string]AgentDefinition
+		want    []string // Fields with errors
+	}{
+		{"unique", []McpServerDefinition{stdio("a")}, map[string]AgentDefinition{"x": agent(stdio("b")), "y": agent(stdio("c"))}, nil},
+		{"duplicate in session", []McpServerDefinition{stdio("a"), stdio("a")}, nil, []string{"McpServers[1].Name"}},
+		{"session and agent", []McpServerDefinition{stdio("a")}, map[string]AgentDefinition{"x": agent(stdio("b"), stdio("a"))},
+			[]string{"Agents[x].McpServers[1].Name"}},
+		{"two agents", nil, map[string]AgentDefinition{"x": agent(stdio("s")), "y": agent(stdio("s"))},
+			[]string{"Agents[y].McpServers[0].Name"}},
+		{"duplicate in agent reported once", []McpServerDefinition{stdio("a")}, map[string]AgentDefinition{"x": agent(stdio("a"), stdio("a"))},
+			[]string{"Agents[x].McpServers[1].Name", "Agents[x].McpServers[0].Name"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			opts := SessionOptions{BaseOptions: BaseOptions{McpServers: tt.servers, Agents: tt.agents}}
+			var got []string
+			var ce *ChuckyError
+			if err := opts.Validate(); errors.As(err, &ce) {
+				for _, fe := range ce.FieldErrors() {
+					got = append(got, fe.Field)
+				}
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("errors on %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestAllMcpServersKeepsEveryDefinition(t *testing.T) {
+	opts := SessionOptions{BaseOptions: BaseOptions{
+		McpServers: []McpServerDefinition{McpStdioServerConfig{Name: "a"}},
+		Agents: map[string]AgentDefinition{
+			"y": {McpServers: []McpServerDefinition{McpStdioServerConfig{Name: "c"}}},
+			"x": {McpServers: []McpServerDefinition{McpStdioServerConfig{Name: "b"}}},
+		},
+	}}
+	var names []string
+	for _, server := range opts.AllMcpServers() {
+		names = append(names, server.GetName())
+	}
+	if want := []string{"a", "b", "c"}; !reflect.DeepEqual(names, want) {
+		t.Errorf("AllMcpServers = %v, want %v", names, want)
+	}
+}
+
+func TestValidateToolNamesAcrossLists(t *testing.T) {
+	tools := func(server string, names ...string) McpServerDefinition {
+		srv := McpClientToolsServer{Name: server}
+		for _, name := range names {
+			srv.Tools = append(srv.Tools, ToolDefinition{Name: name})
+		}
+		return srv
+	}
+	agent := func(servers ...McpServerDefinition) AgentDefinition {
+		return AgentDefinition{Description: "d", Prompt: "p", McpServers: servers}
+	}
+
+	tests := []struct {
+		name    string
+		servers []McpServerDefinition
+		agents  map[string]AgentDefinition
+		want    []string // Fields with errors
+	}{
+		{"distinct", []McpServerDefinition{tools("app", "search")}, map[string]AgentDefinition{"r": agent(tools("research", "fetch"))}, nil},
+		{"session and agent", []McpServerDefinition{tools("app", "search")}, map[string]AgentDefinition{"r": agent(tools("research", "fetch", "search"))},
+			[]string{"Agents[r].McpServers[0].Tools[1].Name"}},
+		{"two agents", nil, map[string]AgentDefinition{"a": agent(tools("one", "search")), "b": agent(tools("two", "search"))},
+			[]string{"Agents[b].McpServers[0].Tools[0].Name"}},
+		{"duplicate in list reported once", []McpServerDefinition{tools("app", "search"), tools("other", "search")}, nil,
+			[]string{"McpServers[1].Tools[0].Name"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			opts := SessionOptions{BaseOptions: BaseOptions{McpServers: tt.servers, Agents: tt.agents}}
+			var got []string
+			var ce *ChuckyError
+			if err := opts.Validate(); errors.As(err, &ce) {
+				for _, fe := range ce.FieldErrors() {
+					got = append(got, fe.Field)
+				}
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("errors on %v, want %v", got, tt.want)
+			}
+		})
+	}
+}